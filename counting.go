@@ -0,0 +1,114 @@
+package cuckoofilter
+
+import "github.com/shaia/simdcuckoofilter/internal/filter"
+
+// CountingFilter is a cuckoo filter for multisets: it counts how many times
+// each item was added, for example to track reference counts. Each distinct
+// item takes one slot holding its fingerprint and a small counter, so adding
+// an item again doesn't use more space.
+//
+// Counters are WithCounterSize bits wide. Counts below 2^bits-1 (MaxCount)
+// are exact. A counter that reaches MaxCount is saturated: InsertN reports
+// ErrCounterSaturated, Count returns MaxCount as a lower bound, and Delete no
+// longer decrements it, so the item is never lost. Items whose fingerprints
+// collide share a counter, as they share a false positive in a plain filter.
+//
+// All methods are safe for concurrent use.
+type CountingFilter struct {
+	f *filter.Counting
+}
+
+// NewCounting creates a counting filter with room for capacity distinct items.
+// WithCounterSize sets the counter width; WithLockStripes has no effect.
+//
+// Example:
+//
+//	cf, _ := cuckoofilter.NewCounting(10000, cuckoofilter.WithCounterSize(8))
+//	cf.InsertN([]byte("blob"), 3)
+//	cf.Delete([]byte("blob"))
+//	cf.Count([]byte("blob")) // 2
+func NewCounting(capacity uint, opts ...Option) (*CountingFilter, error) {
+	if capacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &CountingFilter{f: filter.NewCounting(capacity, options.config(), options.counterBits)}, nil
+}
+
+// Insert adds one occurrence of an item.
+// Returns false if the item is new and the filter is full.
+func (c *CountingFilter) Insert(item []byte) bool {
+	ok, _ := c.f.InsertN(item, 1)
+	return ok
+}
+
+// InsertN adds n occurrences of an item. It returns ErrFilterFull, adding
+// nothing, if the item is new and the filter is full, and ErrCounterSaturated
+// if the item's counter reached MaxCount; the item is stored either way.
+func (c *CountingFilter) InsertN(item []byte, n uint) error {
+	ok, saturated := c.f.InsertN(item, n)
+	switch {
+	case !ok:
+		return ErrFilterFull
+	case saturated:
+		return ErrCounterSaturated
+	}
+	return nil
+}
+
+// Lookup checks if an item might have been added.
+// Returns false if the item is definitely not present.
+func (c *CountingFilter) Lookup(item []byte) bool {
+	return c.f.Lookup(item)
+}
+
+// Count returns how many times an item was added, 0 if it is not present.
+// A result of MaxCount means the counter saturated and the true count may be higher.
+func (c *CountingFilter) Count(item []byte) uint {
+	return c.f.Count(item)
+}
+
+// Delete removes one occurrence of an item; its slot is freed when the
+// count drops to 0. Saturated counters are left unchanged.
+// Returns false if the item is not present.
+func (c *CountingFilter) Delete(item []byte) bool {
+	return c.f.Delete(item)
+}
+
+// MaxCount returns the saturation value of the counters, 2^bits-1
+func (c *CountingFilter) MaxCount() uint {
+	return c.f.MaxCount()
+}
+
+// Distinct returns the number of distinct items stored
+func (c *CountingFilter) Distinct() uint {
+	return c.f.Distinct()
+}
+
+// Capacity returns the number of distinct items the filter has slots for
+func (c *CountingFilter) Capacity() uint {
+	return c.f.Capacity()
+}
+
+// LoadFactor returns the fraction of slots in use (0.0 to 1.0)
+func (c *CountingFilter) LoadFactor() float64 {
+	return c.f.LoadFactor()
+}
+
+// MemoryUsage returns the size of the fingerprint and counter tables in bytes
+func (c *CountingFilter) MemoryUsage() uint {
+	return c.f.MemoryUsage()
+}
+
+// Reset clears all items from the filter
+func (c *CountingFilter) Reset() {
+	c.f.Reset()
+}
@@ -0,0 +1,77 @@
+package cuckoofilter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestCountingFilter validates counting, saturation and full-filter errors
+func TestCountingFilter(t *testing.T) {
+	cf, err := NewCounting(1000, WithCounterSize(4), WithFingerprintSize(16))
+	if err != nil {
+		t.Fatalf("NewCounting failed: %v", err)
+	}
+	if cf.MaxCount() != 15 {
+		t.Errorf("MaxCount() = %d, want 15", cf.MaxCount())
+	}
+
+	blob := []byte("blob")
+	if err := cf.InsertN(blob, 3); err != nil {
+		t.Fatalf("InsertN failed: %v", err)
+	}
+	cf.Insert(blob)
+	if !cf.Delete(blob) || cf.Count(blob) != 3 || cf.Distinct() != 1 {
+		t.Errorf("Count() = %d, Distinct() = %d; want 3, 1", cf.Count(blob), cf.Distinct())
+	}
+	if err := cf.InsertN(blob, 0); err != nil || cf.Count(blob) != 3 {
+		t.Errorf("InsertN(0) = %v, count %d", err, cf.Count(blob))
+	}
+
+	if err := cf.InsertN(blob, 20); !errors.Is(err, ErrCounterSaturated) {
+		t.Errorf("Expected ErrCounterSaturated, got %v", err)
+	}
+	if !cf.Delete(blob) || cf.Count(blob) != cf.MaxCount() || !cf.Lookup(blob) {
+		t.Errorf("Saturated counter should stay at %d, got %d", cf.MaxCount(), cf.Count(blob))
+	}
+
+	if cf.Count([]byte("absent")) != 0 || cf.Delete([]byte("absent")) {
+		t.Error("Absent item reported present")
+	}
+
+	// New items fail with ErrFilterFull once the filter is full, but
+	// existing ones keep counting
+	var full error
+	for i := 0; full == nil; i++ {
+		full = cf.InsertN([]byte(fmt.Sprintf("fill-%d", i)), 2)
+	}
+	if !errors.Is(full, ErrFilterFull) {
+		t.Errorf("Expected ErrFilterFull, got %v", full)
+	}
+	if err := cf.InsertN([]byte("fill-0"), 1); err != nil || cf.Count([]byte("fill-0")) < 3 {
+		t.Errorf("Counting an existing item in a full filter: %v, count %d", err, cf.Count([]byte("fill-0")))
+	}
+	if cf.LoadFactor() < 0.5 || cf.MemoryUsage() == 0 || cf.Capacity() < 1000 {
+		t.Errorf("LoadFactor %.2f, MemoryUsage %d, Capacity %d", cf.LoadFactor(), cf.MemoryUsage(), cf.Capacity())
+	}
+
+	cf.Reset()
+	if cf.Distinct() != 0 || cf.Lookup(blob) {
+		t.Error("Filter not empty after Reset")
+	}
+}
+
+// TestNewCountingInvalid validates parameter checks
+func TestNewCountingInvalid(t *testing.T) {
+	if _, err := NewCounting(0); !errors.Is(err, ErrInvalidCapacity) {
+		t.Errorf("Expected ErrInvalidCapacity, got %v", err)
+	}
+	for _, bits := range []uint{0, 1, 17} {
+		if _, err := NewCounting(100, WithCounterSize(bits)); !errors.Is(err, ErrInvalidCounterSize) {
+			t.Errorf("WithCounterSize(%d): expected ErrInvalidCounterSize, got %v", bits, err)
+		}
+	}
+	if cf, err := NewCounting(100, WithCounterSize(16)); err != nil || cf.MaxCount() != 1<<16-1 {
+		t.Errorf("WithCounterSize(16) failed: %v", err)
+	}
+}
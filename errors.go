@@ -1,6 +1,10 @@
 package cuckoofilter
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+)
 
 var (
 	// ErrInvalidCapacity is returned when capacity is zero or invalid
@@ -10,8 +14,72 @@ var (
 	ErrInvalidBucketSize = errors.New("bucket size must be 2, 4, 8, 16, 32, or 64")
 
 	// ErrInvalidFingerprintSize is returned when fingerprint size is invalid
-	ErrInvalidFingerprintSize = errors.New("fingerprint size must be between 1 and 8 bits (stored as bytes)")
+	ErrInvalidFingerprintSize = errors.New("fingerprint size must be between 1 and 16 bits")
 
 	// ErrInvalidHashStrategy is returned when hash strategy is unknown
 	ErrInvalidHashStrategy = errors.New("invalid hash strategy")
+
+	// ErrInvalidFalsePositiveRate is returned when a target false positive rate is not in (0, 1)
+	ErrInvalidFalsePositiveRate = errors.New("false positive rate must be between 0 and 1")
+
+	// ErrInvalidLockStripes is returned when the number of lock stripes is out of range
+	ErrInvalidLockStripes = errors.New("lock stripes must be at most 65536")
+
+	// ErrInvalidCounterSize is returned when the counter size of a counting filter is out of range
+	ErrInvalidCounterSize = errors.New("counter size must be between 2 and 16 bits")
+
+	// ErrFilterFull is returned when an item cannot be added because the filter is full
+	ErrFilterFull = filter.ErrFull
+
+	// ErrCounterSaturated is returned by CountingFilter.InsertN when an item's
+	// counter reaches its maximum. The item is stored, but its count stops
+	// increasing and is no longer exact.
+	ErrCounterSaturated = errors.New("counter saturated")
+
+	// ErrInvalidShardCount is returned when the number of shards is out of range
+	ErrInvalidShardCount = errors.New("shard count must be between 1 and 65536")
+
+	// ErrUnattainableFalsePositiveRate is returned by NewWithFPR when the target
+	// rate is below what 16-bit fingerprints can provide
+	ErrUnattainableFalsePositiveRate = errors.New("false positive rate is not attainable with 16-bit fingerprints")
+
+	// ErrInvalidVictimCacheSize is returned when the victim cache size is out of range
+	ErrInvalidVictimCacheSize = errors.New("victim cache size must be between 1 and 255")
+
+	// ErrInvalidFormat is returned when serialized filter data is malformed or truncated
+	ErrInvalidFormat = filter.ErrInvalidFormat
+
+	// ErrUnsupportedVersion is returned when serialized data uses an unknown format version
+	ErrUnsupportedVersion = filter.ErrUnsupportedVersion
+
+	// ErrChecksumMismatch is returned when serialized data fails its integrity check
+	ErrChecksumMismatch = filter.ErrChecksumMismatch
+
+	// ErrIncompatibleFilter is returned when serialized data was produced with a
+	// different hash strategy, fingerprint size, seed, SipHash key or custom
+	// hash function than the receiving filter, and by Merge for filters with
+	// different layouts.
+	// The concrete error is an *IncompatibleFilterError.
+	ErrIncompatibleFilter = filter.ErrIncompatible
+
+	// ErrInvalidGrowthFactor is returned by Grow when the factor is not a power of 2 of at least 2
+	ErrInvalidGrowthFactor = filter.ErrInvalidGrowthFactor
+
+	// ErrGrowthLimit is returned by Grow when growing would leave no fingerprint
+	// bits to tell items apart, or the filter would be too large
+	ErrGrowthLimit = filter.ErrGrowthLimit
+
+	// ErrReadOnly is returned by Insert and Delete on a filter opened with OpenMmap
+	ErrReadOnly = filter.ErrReadOnly
+
+	// ErrKeyRequired is returned by Load and OpenMmap for a filter that uses
+	// SipHash when no key is given with WithSipHash
+	ErrKeyRequired = filter.ErrKeyRequired
+
+	// ErrHashRequired is returned by Load and OpenMmap for a filter that uses
+	// a custom hash function when none is given with WithCustomHash
+	ErrHashRequired = filter.ErrHashRequired
 )
+
+// IncompatibleFilterError describes the configuration mismatch behind ErrIncompatibleFilter
+type IncompatibleFilterError = filter.IncompatibleError
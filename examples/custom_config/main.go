@@ -9,9 +9,8 @@ import (
 
 func main() {
 	// Create filter with custom options
-	// Note: Fingerprint size is limited to 8 bits (1 byte) in this implementation
-	cf, err := cuckoofilter.New(10000,
-		cuckoofilter.WithFingerprintSize(8), // 8-bit fingerprints (maximum supported)
+	cf, err := cuckoofilter.NewBatch(10000,
+		cuckoofilter.WithFingerprintSize(8), // 8-bit fingerprints, one byte each
 		cuckoofilter.WithBucketSize(32),     // 32 fingerprints per bucket (optimal for AVX2/NEON SIMD)
 		cuckoofilter.WithMaxKicks(500),      // Standard relocation limit
 	)
@@ -20,9 +19,10 @@ func main() {
 	}
 
 	fmt.Println("Created Cuckoo filter with custom options:")
-	fmt.Printf("  Fingerprint size: 8 bits (1 byte)\n")
-	fmt.Printf("  Bucket size: 32 fingerprints (optimal for AMD64 AVX2 and ARM64 NEON)\n")
-	fmt.Printf("  Max kicks: 500 (relocation attempts)\n")
+	fmt.Printf("  Fingerprint size: %d bits\n", cf.FingerprintBits())
+	fmt.Printf("  Bucket size: %d fingerprints (optimal for AMD64 AVX2 and ARM64 NEON)\n", cf.BucketSize())
+	fmt.Printf("  Max kicks: %d (relocation attempts)\n", cf.MaxKicks())
+	fmt.Printf("  Hash: %s\n", cf.HashStrategy())
 	fmt.Printf("  Capacity: %d items\n\n", cf.Capacity())
 
 	// Insert many items
@@ -1,8 +1,10 @@
 package cuckoofilter
 
 import (
+	"encoding"
+	"io"
+
 	"github.com/shaia/simdcuckoofilter/internal/filter"
-	"github.com/shaia/simdcuckoofilter/internal/hash"
 )
 
 // CuckooFilter is a probabilistic data structure for set membership testing
@@ -29,6 +31,9 @@ type CuckooFilter interface {
 	// Capacity returns the total capacity of the filter
 	Capacity() uint
 
+	// MemoryUsage returns the size of the fingerprint table in bytes
+	MemoryUsage() uint
+
 	// Reset clears all items from the filter
 	Reset()
 }
@@ -50,17 +55,49 @@ type BatchFilter interface {
 	OptimalBatchSize() int
 }
 
+// SerializableFilter extends CuckooFilter with binary serialization.
+// The encoding is versioned and checksummed; see Load for restoring a filter.
+// WriteTo and ReadFrom stream the same encoding without buffering the whole
+// table, which suits very large filters.
+type SerializableFilter interface {
+	CuckooFilter
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+	io.WriterTo
+	io.ReaderFrom
+}
+
 // New creates a SIMD-optimized Cuckoo filter with the specified capacity.
 // Uses SIMD implementation based on platform:
 //   - AMD64: AVX2
 //   - ARM64: NEON
 //
+// The returned filter is a *Filter; use NewBatch to get the concrete type
+// with its batch operations and configuration getters directly.
+//
 // Examples:
 //
 //	cf, _ := cuckoofilter.New(10000)
 //	cf, _ := cuckoofilter.New(10000, cuckoofilter.WithFingerprintSize(8))
 //	cf, _ := cuckoofilter.New(10000, cuckoofilter.WithBucketSize(32))
 func New(capacity uint, opts ...Option) (CuckooFilter, error) {
+	f, err := NewBatch(capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// NewBatch creates a filter like New but returns the concrete *Filter, which
+// exposes batch operations, serialization and the configuration without
+// type assertions.
+//
+// Example:
+//
+//	cf, _ := cuckoofilter.NewBatch(10000, cuckoofilter.WithXXHash())
+//	results := cf.InsertBatch(items)
+//	fmt.Println(cf.BucketSize(), cf.FingerprintBits(), cf.HashStrategy())
+func NewBatch(capacity uint, opts ...Option) (*Filter, error) {
 	if capacity == 0 {
 		return nil, ErrInvalidCapacity
 	}
@@ -74,12 +111,279 @@ func New(capacity uint, opts ...Option) (CuckooFilter, error) {
 		return nil, err
 	}
 
-	return filter.New(
-		capacity,
-		options.bucketSize,
-		options.fingerprintBits,
-		options.maxKicks,
-		hash.HashStrategy(options.hashStrategy),
-		options.batchSize,
-	)
+	return newFilter(capacity, &options), nil
+}
+
+// newFilter builds a filter from validated options
+func newFilter(capacity uint, options *Options) *Filter {
+	f, _ := filter.NewWithConfig(capacity, options.config())
+	return &Filter{f: f}
+}
+
+// Filter is a SIMD-optimized cuckoo filter. It implements BatchFilter and
+// SerializableFilter and reports the configuration it was built with.
+//
+// All methods are safe for concurrent use.
+type Filter struct {
+	f *filter.Filter
+}
+
+// Insert adds an item to the filter.
+// Returns true if successful, false if filter is full.
+// With WithSetSemantics, an item that is already present is not added again.
+func (f *Filter) Insert(item []byte) bool {
+	return f.f.Insert(item)
+}
+
+// InsertStatus is the outcome of InsertUnique
+type InsertStatus = filter.InsertStatus
+
+const (
+	// InsertAdded means the item was added
+	InsertAdded = filter.Inserted
+
+	// InsertDuplicate means the item was already present and nothing was added
+	InsertDuplicate = filter.Duplicate
+
+	// InsertFull means the item is new but the filter has no room for it
+	InsertFull = filter.Full
+)
+
+// InsertUnique adds an item unless it is already present, checking both
+// candidate buckets before inserting, so repeated inserts of one item use a
+// single slot and Count stays exact for sets. Like Lookup, it can mistake a
+// new item for a present one with the false positive probability.
+func (f *Filter) InsertUnique(item []byte) InsertStatus {
+	return f.f.InsertUnique(item)
+}
+
+// InsertResult describes how an insert went: its Status, the number of
+// resident fingerprints Kicks moved to make room, and whether one was
+// Displaced into the victim stash because relocation ran out of kicks.
+// Rising kicks and displaced victims signal that the filter is filling up
+// before inserts start failing.
+type InsertResult = filter.InsertResult
+
+// InsertEx is Insert reporting an InsertResult. With WithSetSemantics, an
+// item already present is reported as InsertDuplicate.
+//
+// Example:
+//
+//	r := cf.InsertEx([]byte("apple"))
+//	if r.Status == cuckoofilter.InsertFull || r.Displaced {
+//		// grow or rotate the filter
+//	}
+func (f *Filter) InsertEx(item []byte) InsertResult {
+	return f.f.InsertEx(item)
+}
+
+// Lookup checks if an item might be in the filter.
+// Returns false if the item is definitely not present.
+func (f *Filter) Lookup(item []byte) bool {
+	return f.f.Lookup(item)
+}
+
+// Delete removes an item from the filter.
+// Returns true if the item was found and deleted.
+func (f *Filter) Delete(item []byte) bool {
+	return f.f.Delete(item)
+}
+
+// InsertHash adds the item whose 64-bit hash is h without hashing anything,
+// for callers that already hash their items, for example to shard them.
+// The fingerprint and bucket are derived from h as the filter derives them
+// from the hash of an item, so with WithCustomHash(fn), InsertHash(fn(item))
+// is Insert(item) at any filter size. With the other hash strategies, items
+// added by hash are only found by LookupHash and removed by DeleteHash.
+//
+// h should be spread over all 64 bits: items whose hashes are equal are
+// indistinguishable.
+func (f *Filter) InsertHash(h uint64) bool {
+	return f.f.InsertHashed(f.f.HashValue(h))
+}
+
+// LookupHash checks if the item whose 64-bit hash is h might be in the
+// filter, as InsertHash is for Insert
+func (f *Filter) LookupHash(h uint64) bool {
+	return f.f.LookupHashed(f.f.HashValue(h))
+}
+
+// DeleteHash removes the item whose 64-bit hash is h, as InsertHash is for Insert
+func (f *Filter) DeleteHash(h uint64) bool {
+	return f.f.DeleteHashed(f.f.HashValue(h))
+}
+
+// InsertBatch inserts multiple items, hashing them together
+func (f *Filter) InsertBatch(items [][]byte) []bool {
+	return f.f.InsertBatch(items)
+}
+
+// InsertBatchEx inserts multiple items, reporting an InsertResult per item
+func (f *Filter) InsertBatchEx(items [][]byte) []InsertResult {
+	return f.f.InsertBatchEx(items)
+}
+
+// LookupBatch checks multiple items, hashing them together
+func (f *Filter) LookupBatch(items [][]byte) []bool {
+	return f.f.LookupBatch(items)
+}
+
+// DeleteBatch deletes multiple items, hashing them together
+func (f *Filter) DeleteBatch(items [][]byte) []bool {
+	return f.f.DeleteBatch(items)
+}
+
+// OptimalBatchSize returns the recommended batch size for this filter
+func (f *Filter) OptimalBatchSize() int {
+	return f.f.OptimalBatchSize()
+}
+
+// Count returns the number of items in the filter
+func (f *Filter) Count() uint {
+	return f.f.Count()
+}
+
+// LoadFactor returns current load factor (0.0 to 1.0)
+func (f *Filter) LoadFactor() float64 {
+	return f.f.LoadFactor()
+}
+
+// Capacity returns the total number of fingerprint slots
+func (f *Filter) Capacity() uint {
+	return f.f.Capacity()
+}
+
+// MemoryUsage returns the size of the fingerprint table in bytes
+func (f *Filter) MemoryUsage() uint {
+	return f.f.MemoryUsage()
+}
+
+// Stats is a snapshot of a filter's health: the bucket occupancy histogram,
+// full buckets and stashed fingerprints, insert, failure and kick counters
+// since creation or the last Reset, and the false positive rate estimated
+// from the current load rather than the worst case.
+type Stats = filter.Stats
+
+// Stats returns the filter's statistics. The insert counters are kept
+// as inserts run and are cheap to read; the occupancy histogram scans every
+// bucket and holds off writers while it does.
+//
+// Example:
+//
+//	s := cf.Stats()
+//	fmt.Println(s.LoadFactor, s.FullBuckets, s.KicksPerInsert, s.EstimatedFalsePositiveRate)
+func (f *Filter) Stats() Stats {
+	return f.f.Stats()
+}
+
+// Merge adds the items of other to f, for example to combine filters built
+// in parallel over partitions of a data set. Stored fingerprints are moved
+// over directly, so the original items aren't needed, and lookups in f then
+// find the items of both filters.
+//
+// Both filters must have the same capacity, bucket size, fingerprint size and
+// hash strategy, or Merge returns an *IncompatibleFilterError. If the items
+// don't fit, Merge returns an error matching ErrFilterFull and f is unchanged.
+//
+// Example:
+//
+//	a, _ := cuckoofilter.NewBatch(100000)
+//	b, _ := cuckoofilter.NewBatch(100000)
+//	// fill a and b in parallel
+//	if err := a.Merge(b); err != nil {
+//		log.Fatal(err)
+//	}
+func (f *Filter) Merge(other *Filter) error {
+	return f.f.Merge(other.f)
+}
+
+// Grow returns a filter with factor times the capacity of f holding the
+// same items, for when a filter fills up and the original items are no
+// longer available. factor must be a power of 2 of at least 2; f is left
+// unchanged and can be discarded.
+//
+// The extra capacity is addressed with the top bits of each stored
+// fingerprint, so every doubling spends one fingerprint bit: with 16-bit
+// fingerprints, growing by 4 leaves the false positive rate of a 14-bit
+// filter, twice as high per doubling at the same load factor.
+// FalsePositiveRate reports the rate of the grown filter. Grow returns an
+// error matching ErrGrowthLimit once a single fingerprint bit would remain.
+//
+// Example:
+//
+//	if cf.LoadFactor() > 0.9 {
+//		grown, err := cf.Grow(2)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		cf = grown
+//	}
+func (f *Filter) Grow(factor uint) (*Filter, error) {
+	g, err := f.f.Grow(factor)
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{f: g}, nil
+}
+
+// GrowthFactor returns how many times the capacity of f has been multiplied
+// by Grow, or 1 for a filter that was never grown
+func (f *Filter) GrowthFactor() uint {
+	return f.f.GrowthFactor()
+}
+
+// Reset clears all items from the filter
+func (f *Filter) Reset() {
+	f.f.Reset()
+}
+
+// BucketSize returns the number of fingerprints per bucket
+func (f *Filter) BucketSize() uint {
+	return f.f.BucketSize()
+}
+
+// FingerprintBits returns the number of bits per fingerprint
+func (f *Filter) FingerprintBits() uint {
+	return f.f.FingerprintBits()
+}
+
+// HashStrategy returns the name of the hash function: "FNV-1a", "CRC32C",
+// "XXHash64", "SipHash-2-4" or "Custom"
+func (f *Filter) HashStrategy() string {
+	return hashStrategy(f.f.HashStrategy()).String()
+}
+
+// MaxKicks returns the number of relocations an insert attempts before
+// falling back to the victim stash
+func (f *Filter) MaxKicks() uint {
+	return f.f.MaxKicks()
+}
+
+// FalsePositiveRate returns the predicted worst-case false positive rate,
+// 2b/2^f for bucket size b and f fingerprint bits
+func (f *Filter) FalsePositiveRate() float64 {
+	return f.f.FalsePositiveRate()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	return f.f.MarshalBinary()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// Data saved with a different hash strategy or fingerprint size is rejected
+// with an *IncompatibleFilterError.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	return f.f.UnmarshalBinary(data)
+}
+
+// WriteTo implements io.WriterTo, streaming the same encoding as MarshalBinary
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	return f.f.WriteTo(w)
+}
+
+// ReadFrom implements io.ReaderFrom, replacing the contents of f with a
+// filter streamed by WriteTo. The filter is unchanged if reading fails.
+func (f *Filter) ReadFrom(r io.Reader) (int64, error) {
+	return f.f.ReadFrom(r)
 }
@@ -1,8 +1,12 @@
 package cuckoofilter
 
 import (
+	"encoding/binary"
 	"fmt"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // BenchmarkInsert benchmarks insert operations
@@ -79,6 +83,56 @@ func BenchmarkLookupBatch(b *testing.B) {
 	}
 }
 
+// BenchmarkNewLarge benchmarks creating a filter with 1M buckets.
+// The allocation count shows the cost of the table layout itself.
+func BenchmarkNewLarge(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(4 << 20); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLookupLargeFilter benchmarks lookups spread across a filter
+// much larger than the CPU caches, where memory layout dominates latency
+func BenchmarkLookupLargeFilter(b *testing.B) {
+	const numItems = 1 << 21
+	cf, _ := New(4 << 20)
+
+	items := make([][]byte, numItems)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("large-%d", i))
+		cf.Insert(items[i])
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cf.Lookup(items[(i*7919)&(numItems-1)])
+	}
+}
+
+// BenchmarkGCWithLargeFilter benchmarks a full garbage collection while a
+// large filter is live, measuring how much work the table adds to GC scans
+func BenchmarkGCWithLargeFilter(b *testing.B) {
+	cf, _ := New(4 << 20)
+	for i := 0; i < 1<<20; i++ {
+		cf.Insert([]byte(fmt.Sprintf("gc-%d", i)))
+	}
+	runtime.GC()
+
+	var pause time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		runtime.GC()
+		pause += time.Since(start)
+	}
+	b.ReportMetric(float64(pause.Microseconds())/float64(b.N), "us/gc")
+	runtime.KeepAlive(cf)
+}
+
 // BenchmarkHashStrategies benchmarks different hash strategies
 func BenchmarkHashStrategies(b *testing.B) {
 	tests := []struct {
@@ -105,7 +159,7 @@ func BenchmarkHashStrategies(b *testing.B) {
 
 // BenchmarkFingerprintSizes benchmarks different fingerprint sizes
 func BenchmarkFingerprintSizes(b *testing.B) {
-	sizes := []uint{4, 6, 8} // Only 1-8 bits supported (stored as bytes)
+	sizes := []uint{4, 8, 12, 16} // 8 and 16 are byte-aligned, others bit-packed
 
 	for _, size := range sizes {
 		b.Run(fmt.Sprintf("%d-bit", size), func(b *testing.B) {
@@ -123,6 +177,28 @@ func BenchmarkFingerprintSizes(b *testing.B) {
 	}
 }
 
+// BenchmarkLookupFingerprintLayouts benchmarks lookups for each storage layout
+func BenchmarkLookupFingerprintLayouts(b *testing.B) {
+	for _, bits := range []uint{8, 12, 16} {
+		for _, bucketSize := range []uint{4, 8, 32} {
+			b.Run(fmt.Sprintf("%d-bit/bucket-%d", bits, bucketSize), func(b *testing.B) {
+				cf, _ := New(1<<20, WithFingerprintSize(bits), WithBucketSize(bucketSize))
+				items := make([][]byte, 1<<16)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("layout-%d", i))
+					cf.Insert(items[i])
+				}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					cf.Lookup(items[i&(len(items)-1)])
+				}
+				b.ReportMetric(float64(cf.MemoryUsage())/(1<<20), "MiB")
+			})
+		}
+	}
+}
+
 // BenchmarkBucketSizes benchmarks different bucket sizes
 func BenchmarkBucketSizes(b *testing.B) {
 	sizes := []uint{4, 8, 16, 32, 64}
@@ -258,3 +334,107 @@ func BenchmarkConcurrentMixed(b *testing.B) {
 		}
 	})
 }
+
+// lockModes returns the locking configurations compared by the parallel
+// benchmarks. Run them with -cpu=1,2,4,... to see how each scales.
+func lockModes() []struct {
+	name string
+	opts []Option
+} {
+	return []struct {
+		name string
+		opts []Option
+	}{
+		{"SingleLock", nil},
+		{"Striped", []Option{WithLockStripes(uint(4 * runtime.GOMAXPROCS(0)))}},
+	}
+}
+
+// parallelItems returns distinct items for the parallel benchmarks
+func parallelItems(n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("parallel-%d", i))
+	}
+	return items
+}
+
+// BenchmarkParallelWrites benchmarks an insert/delete churn from every
+// goroutine, the write-heavy ingest pattern lock striping targets
+func BenchmarkParallelWrites(b *testing.B) {
+	items := parallelItems(1 << 16)
+
+	for _, mode := range lockModes() {
+		b.Run(mode.name, func(b *testing.B) {
+			cf, _ := New(1<<20, mode.opts...)
+			var next atomic.Int64
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(next.Add(1)) * 7919
+				for pb.Next() {
+					item := items[i%len(items)]
+					cf.Insert(item)
+					cf.Delete(item)
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkParallelReadMostly benchmarks lookups with one insert/delete
+// pair in every 16 operations
+func BenchmarkParallelReadMostly(b *testing.B) {
+	items := parallelItems(1 << 16)
+
+	for _, mode := range lockModes() {
+		b.Run(mode.name, func(b *testing.B) {
+			cf, _ := New(1<<20, mode.opts...)
+			for _, item := range items[:len(items)/2] {
+				cf.Insert(item)
+			}
+			var next atomic.Int64
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(next.Add(1)) * 7919
+				for pb.Next() {
+					item := items[i%len(items)]
+					if i%16 == 0 {
+						cf.Insert(item)
+						cf.Delete(item)
+					} else {
+						cf.Lookup(item)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkTypedLookup compares integer keys looked up through TypedFilter
+// with encoding them to byte slices by hand
+func BenchmarkTypedLookup(b *testing.B) {
+	for _, opt := range []Option{WithFNVHash(), WithCRC32Hash(), WithXXHash()} {
+		cf, _ := NewBatch(100000, opt)
+		ids := NewTyped(cf, IntegerEncoder[uint64]{})
+		for i := range uint64(50000) {
+			ids.Insert(i)
+		}
+
+		b.Run(cf.HashStrategy()+"/Typed", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ids.Lookup(uint64(i))
+			}
+		})
+		b.Run(cf.HashStrategy()+"/Bytes", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cf.Lookup(binary.LittleEndian.AppendUint64(nil, uint64(i)))
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package cuckoofilter
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"runtime"
+	"sync"
 	"testing"
 )
 
@@ -26,6 +29,73 @@ func TestNew(t *testing.T) {
 	}
 }
 
+// TestNewBatch validates the concrete filter type and its configuration getters
+func TestNewBatch(t *testing.T) {
+	var (
+		_ BatchFilter        = (*Filter)(nil)
+		_ SerializableFilter = (*Filter)(nil)
+	)
+
+	tests := []struct {
+		opts     []Option
+		bucket   uint
+		bits     uint
+		strategy string
+		kicks    uint
+	}{
+		{nil, 4, 8, "FNV-1a", 500},
+		{[]Option{WithXXHash(), WithBucketSize(16), WithFingerprintSize(12)}, 16, 12, "XXHash64", 500},
+		{[]Option{WithCRC32Hash(), WithMaxKicks(100)}, 4, 8, "CRC32C", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			cf, err := NewBatch(1000, tt.opts...)
+			if err != nil {
+				t.Fatalf("NewBatch failed: %v", err)
+			}
+
+			if cf.BucketSize() != tt.bucket || cf.FingerprintBits() != tt.bits {
+				t.Errorf("Layout %d×%d-bit, want %d×%d-bit", cf.BucketSize(), cf.FingerprintBits(), tt.bucket, tt.bits)
+			}
+			if cf.HashStrategy() != tt.strategy {
+				t.Errorf("Expected hash strategy %q, got %q", tt.strategy, cf.HashStrategy())
+			}
+			if cf.MaxKicks() != tt.kicks {
+				t.Errorf("Expected maxKicks %d, got %d", tt.kicks, cf.MaxKicks())
+			}
+
+			items := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+			for i, ok := range cf.InsertBatch(items) {
+				if !ok {
+					t.Errorf("InsertBatch failed for item %d", i)
+				}
+			}
+			for i, found := range cf.LookupBatch(items) {
+				if !found {
+					t.Errorf("LookupBatch missed item %d", i)
+				}
+			}
+			if cf.Count() != 3 {
+				t.Errorf("Expected count 3, got %d", cf.Count())
+			}
+		})
+	}
+
+	if _, err := NewBatch(0); err != ErrInvalidCapacity {
+		t.Errorf("Expected ErrInvalidCapacity, got %v", err)
+	}
+	if cf, err := NewBatch(1000, WithBucketSize(3)); err != ErrInvalidBucketSize || cf != nil {
+		t.Errorf("Expected nil filter and ErrInvalidBucketSize, got %v, %v", cf, err)
+	}
+
+	// New hands out the same type
+	cf, _ := New(1000)
+	if _, ok := cf.(*Filter); !ok {
+		t.Errorf("New returned %T, want *Filter", cf)
+	}
+}
+
 // TestInsertAndLookup validates basic insert and lookup operations
 func TestInsertAndLookup(t *testing.T) {
 	cf, _ := New(1000)
@@ -164,6 +234,100 @@ func TestInvalidOptions(t *testing.T) {
 	if err != ErrInvalidFingerprintSize {
 		t.Errorf("Expected ErrInvalidFingerprintSize for 17 bits, got %v", err)
 	}
+
+	_, err = New(1000, WithVictimCacheSize(0)) // Invalid: stash is required
+	if err != ErrInvalidVictimCacheSize {
+		t.Errorf("Expected ErrInvalidVictimCacheSize for 0 entries, got %v", err)
+	}
+
+	_, err = New(1000, WithVictimCacheSize(256)) // Invalid: too large (max is 255)
+	if err != ErrInvalidVictimCacheSize {
+		t.Errorf("Expected ErrInvalidVictimCacheSize for 256 entries, got %v", err)
+	}
+
+	_, err = New(1000, WithLockStripes(1<<16+1)) // Invalid: too many stripes
+	if err != ErrInvalidLockStripes {
+		t.Errorf("Expected ErrInvalidLockStripes, got %v", err)
+	}
+
+	_, err = New(1000, WithCustomHash(nil)) // Invalid: no hash function
+	if err != ErrInvalidHashStrategy {
+		t.Errorf("Expected ErrInvalidHashStrategy, got %v", err)
+	}
+}
+
+// TestLockStripes validates concurrent writers on a lock-striped filter
+func TestLockStripes(t *testing.T) {
+	cf, err := NewBatch(100000, WithLockStripes(64))
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+
+	const workers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				item := []byte(fmt.Sprintf("striped-%d-%d", w, i))
+				if !cf.Insert(item) {
+					t.Errorf("Insert failed for %q", item)
+					return
+				}
+				if i%2 == 0 && !cf.Delete(item) {
+					t.Errorf("Delete failed for %q", item)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cf.Count() != workers*1000 {
+		t.Errorf("Expected %d items, got %d", workers*1000, cf.Count())
+	}
+	for w := 0; w < workers; w++ {
+		for i := 1; i < 2000; i += 2 {
+			if !cf.Lookup([]byte(fmt.Sprintf("striped-%d-%d", w, i))) {
+				t.Fatalf("Item striped-%d-%d not found", w, i)
+			}
+		}
+	}
+}
+
+// TestNoFalseNegativesPastCapacity validates that filling a filter beyond its
+// capacity never evicts an item whose insert succeeded
+func TestNoFalseNegativesPastCapacity(t *testing.T) {
+	for _, victims := range []uint{1, 8} {
+		t.Run(fmt.Sprintf("victims-%d", victims), func(t *testing.T) {
+			cf, _ := New(1000, WithFingerprintSize(12), WithMaxKicks(20), WithVictimCacheSize(victims))
+
+			var accepted [][]byte
+			rejected := 0
+			for i := 0; i < 3000; i++ {
+				item := []byte(fmt.Sprintf("overfill-%d", i))
+				if cf.Insert(item) {
+					accepted = append(accepted, item)
+				} else {
+					rejected++
+				}
+			}
+
+			if rejected == 0 {
+				t.Fatal("Filter should reject inserts once it is full")
+			}
+			if cf.Count() != uint(len(accepted)) {
+				t.Errorf("Count() = %d, accepted %d items", cf.Count(), len(accepted))
+			}
+
+			for _, item := range accepted {
+				if !cf.Lookup(item) {
+					t.Errorf("False negative for accepted item %q", item)
+				}
+			}
+		})
+	}
 }
 
 // TestLoadFactor validates load factor calculation
@@ -257,6 +421,8 @@ func TestAllHashStrategies(t *testing.T) {
 		{"XXHash64", WithXXHash()},
 		{"CRC32C", WithCRC32Hash()},
 		{"FNV-1a", WithFNVHash()},
+		{"SipHash-2-4", WithSipHash([16]byte{1, 2, 3})},
+		{"Custom", WithCustomHash(customHash)},
 	}
 
 	for _, tt := range tests {
@@ -279,10 +445,92 @@ func TestAllHashStrategies(t *testing.T) {
 	}
 }
 
+// TestWithSeed validates that seeds change where items are stored
+func TestWithSeed(t *testing.T) {
+	for _, opt := range []Option{WithFNVHash(), WithCRC32Hash(), WithXXHash()} {
+		var tables [][]byte
+		for _, seed := range []uint64{0, 1, 2} {
+			cf, _ := NewBatch(10000, opt, WithSeed(seed))
+			items := make([][]byte, 1000)
+			for i := range items {
+				items[i] = []byte(fmt.Sprintf("seed-%d", i))
+			}
+			cf.InsertBatch(items)
+			for i, found := range cf.LookupBatch(items) {
+				if !found || !cf.Lookup(items[i]) {
+					t.Fatalf("%s with seed %d: item %d not found", cf.HashStrategy(), seed, i)
+				}
+			}
+
+			data, _ := cf.MarshalBinary()
+			for j, other := range tables {
+				if bytes.Equal(data[64:], other[64:]) {
+					t.Errorf("%s: seeds %d and %d stored items identically", cf.HashStrategy(), seed, j)
+				}
+			}
+			tables = append(tables, data)
+		}
+	}
+}
+
+// customHash stands in for a hash function computed ahead of the filter
+func customHash(item []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for _, b := range item {
+		h = (h ^ uint64(b)) * 1099511628211
+	}
+	return h
+}
+
+// TestInsertHash validates that items given by their hash are placed like
+// items hashed by the filter's custom hash function
+func TestInsertHash(t *testing.T) {
+	for _, stripes := range []uint{0, 8} {
+		cf, err := NewBatch(20000, WithCustomHash(customHash), WithFingerprintSize(16), WithLockStripes(stripes))
+		if err != nil {
+			t.Fatalf("NewBatch failed: %v", err)
+		}
+		if cf.HashStrategy() != "Custom" {
+			t.Errorf("Expected hash strategy %q, got %q", "Custom", cf.HashStrategy())
+		}
+
+		items := make([][]byte, 10000)
+		for i := range items {
+			items[i] = []byte(fmt.Sprintf("prehashed-%d", i))
+		}
+		for i, item := range items[:5000] {
+			if !cf.InsertHash(customHash(item)) {
+				t.Fatalf("InsertHash failed for item %d", i)
+			}
+		}
+		cf.InsertBatch(items[5000:])
+
+		for i, item := range items {
+			if !cf.Lookup(item) || !cf.LookupHash(customHash(item)) {
+				t.Fatalf("Stripes %d: item %d not found", stripes, i)
+			}
+		}
+		for i, item := range items {
+			if !cf.DeleteHash(customHash(item)) {
+				t.Fatalf("DeleteHash failed for item %d", i)
+			}
+		}
+		if cf.Count() != 0 {
+			t.Errorf("Expected empty filter, count %d", cf.Count())
+		}
+	}
+
+	// Filters using built-in strategies take hashes too
+	cf, _ := NewBatch(1000, WithXXHash())
+	if !cf.InsertHash(42) || !cf.LookupHash(42) || !cf.DeleteHash(42) || cf.LookupHash(42) {
+		t.Error("Hash operations failed on an XXHash filter")
+	}
+}
+
 // TestAllFingerprintSizes validates all supported fingerprint sizes
 func TestAllFingerprintSizes(t *testing.T) {
-	// Fingerprints are stored as bytes, so only 1-8 bits are supported
-	sizes := []uint{4, 8}
+	// 8 and 16 bits use byte and uint16 layouts, all other sizes are bit-packed
+	sizes := []uint{4, 8, 12, 16}
 
 	for _, size := range sizes {
 		t.Run(fmt.Sprintf("%d-bit", size), func(t *testing.T) {
@@ -304,6 +552,31 @@ func TestAllFingerprintSizes(t *testing.T) {
 	}
 }
 
+// TestMemoryUsage validates that memory usage reflects the fingerprint size
+func TestMemoryUsage(t *testing.T) {
+	tests := []struct {
+		bits uint
+		want uint // bytes for 1M slots
+	}{
+		{16, 2 << 20},
+		{8, 1 << 20},
+		{12, 3 << 19},
+		{4, 1 << 19},
+	}
+
+	for _, tt := range tests {
+		cf, _ := New(1<<20, WithFingerprintSize(tt.bits))
+		if cf.Capacity() != 1<<20 {
+			t.Fatalf("Expected capacity %d, got %d", 1<<20, cf.Capacity())
+		}
+
+		// Packed layouts carry a few bytes of padding
+		if got := cf.MemoryUsage(); got < tt.want || got > tt.want+64 {
+			t.Errorf("%d-bit: expected ~%d bytes, got %d", tt.bits, tt.want, got)
+		}
+	}
+}
+
 // TestAllBucketSizes validates all supported bucket sizes
 func TestAllBucketSizes(t *testing.T) {
 	sizes := []uint{4, 8, 16, 32, 64}
@@ -530,3 +803,224 @@ func TestBatchWithDifferentHashStrategies(t *testing.T) {
 		})
 	}
 }
+
+// TestInsertUnique tests duplicate detection in both locking modes
+func TestInsertUnique(t *testing.T) {
+	for _, stripes := range []uint{0, 8} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			cf, err := NewBatch(1000, WithFingerprintSize(16), WithXXHash(), WithLockStripes(stripes))
+			if err != nil {
+				t.Fatalf("NewBatch failed: %v", err)
+			}
+
+			for i := 0; i < 300; i++ {
+				item := []byte(fmt.Sprintf("unique-%d", i))
+				if status := cf.InsertUnique(item); status != InsertAdded {
+					t.Fatalf("First InsertUnique of %q = %v, want %v", item, status, InsertAdded)
+				}
+				if status := cf.InsertUnique(item); status != InsertDuplicate {
+					t.Fatalf("Second InsertUnique of %q = %v, want %v", item, status, InsertDuplicate)
+				}
+			}
+			if cf.Count() != 300 {
+				t.Errorf("Count() = %d, want 300", cf.Count())
+			}
+
+			// Fill up, then new items are rejected but known ones still detected
+			status := InsertAdded
+			for i := 0; status == InsertAdded || status == InsertDuplicate; i++ {
+				status = cf.InsertUnique([]byte(fmt.Sprintf("fill-%d", i)))
+			}
+			if status != InsertFull {
+				t.Errorf("Expected %v, got %v", InsertFull, status)
+			}
+			if status := cf.InsertUnique([]byte("unique-0")); status != InsertDuplicate {
+				t.Errorf("InsertUnique on a full filter = %v, want %v", status, InsertDuplicate)
+			}
+		})
+	}
+}
+
+// TestInsertEx tests that InsertEx and InsertBatchEx report relocations
+// before the filter fills up
+func TestInsertEx(t *testing.T) {
+	for _, stripes := range []uint{0, 8} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			cf, err := NewBatch(1000, WithFingerprintSize(16), WithXXHash(), WithLockStripes(stripes))
+			if err != nil {
+				t.Fatalf("NewBatch failed: %v", err)
+			}
+
+			if r := cf.InsertEx([]byte("first")); r != (InsertResult{Status: InsertAdded}) {
+				t.Errorf("InsertEx on an empty filter = %+v", r)
+			}
+
+			var items [][]byte
+			for i := 0; i < int(cf.Capacity()); i++ {
+				items = append(items, []byte(fmt.Sprintf("ex-%d", i)))
+			}
+			var kicks uint
+			firstKick := -1
+			for i, r := range cf.InsertBatchEx(items) {
+				if r.Status == InsertDuplicate {
+					t.Fatalf("InsertBatchEx reported %q as a duplicate without set semantics", items[i])
+				}
+				if r.Kicks > 0 && firstKick < 0 {
+					firstKick = i
+				}
+				kicks += r.Kicks
+			}
+			if kicks == 0 {
+				t.Fatal("Expected relocations while filling the filter")
+			}
+			if firstKick < int(cf.Capacity())/4 {
+				t.Errorf("First relocation at item %d, expected a lightly loaded filter to have room", firstKick)
+			}
+		})
+	}
+}
+
+// TestStats tests that Stats tracks inserts made through the public API
+func TestStats(t *testing.T) {
+	cf, err := NewBatch(4000, WithFingerprintSize(16), WithBucketSize(4))
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+
+	var items [][]byte
+	for i := range 2000 {
+		items = append(items, []byte(fmt.Sprintf("stats-%d", i)))
+	}
+	var kicks uint64
+	for _, r := range cf.InsertBatchEx(items) {
+		kicks += uint64(r.Kicks)
+	}
+
+	s := cf.Stats()
+	if s.Inserts != uint64(cf.Count()) || s.Items != cf.Count() || s.Kicks != kicks {
+		t.Errorf("Inserts %d, Items %d, Kicks %d; want %d, %d, %d", s.Inserts, s.Items, s.Kicks, cf.Count(), cf.Count(), kicks)
+	}
+	if len(s.Occupancy) != 5 || s.MemoryBytes != cf.MemoryUsage() || s.Capacity != cf.Capacity() {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+	if s.EstimatedFalsePositiveRate >= cf.FalsePositiveRate() {
+		t.Errorf("Estimated FPR %g at load %.2f, worst case %g", s.EstimatedFalsePositiveRate, s.LoadFactor, cf.FalsePositiveRate())
+	}
+}
+
+// TestWithSetSemantics tests that Insert and InsertBatch skip items already present
+func TestWithSetSemantics(t *testing.T) {
+	cf, err := NewBatch(1000, WithSetSemantics())
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+
+	item := []byte("repeated")
+	for range 10 {
+		if !cf.Insert(item) {
+			t.Fatal("Insert of a present item should succeed")
+		}
+	}
+	for _, ok := range cf.InsertBatch([][]byte{item, item, []byte("other")}) {
+		if !ok {
+			t.Fatal("InsertBatch failed")
+		}
+	}
+	if cf.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", cf.Count())
+	}
+	if !cf.Delete(item) || cf.Lookup(item) {
+		t.Error("A single Delete should remove a repeated item")
+	}
+
+	// Without the option every Insert stores a fingerprint
+	plain, _ := NewBatch(1000)
+	plain.Insert(item)
+	plain.Insert(item)
+	if plain.Count() != 2 {
+		t.Errorf("Count() = %d without set semantics, want 2", plain.Count())
+	}
+}
+
+// TestMerge tests merging filters built in parallel
+func TestMerge(t *testing.T) {
+	parts := make([]*Filter, 4)
+	var wg sync.WaitGroup
+	for p := range parts {
+		parts[p], _ = NewBatch(8000, WithXXHash(), WithFingerprintSize(16))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				parts[p].Insert([]byte(fmt.Sprintf("part-%d-%d", p, i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, part := range parts[1:] {
+		if err := parts[0].Merge(part); err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+	}
+	for p := range parts {
+		for i := range 500 {
+			if item := []byte(fmt.Sprintf("part-%d-%d", p, i)); !parts[0].Lookup(item) {
+				t.Fatalf("False negative for %q after Merge", item)
+			}
+		}
+	}
+
+	// Incompatible layouts and a full destination are rejected
+	other, _ := NewBatch(8000, WithCRC32Hash(), WithFingerprintSize(16))
+	if err := parts[0].Merge(other); !errors.Is(err, ErrIncompatibleFilter) {
+		t.Errorf("Expected ErrIncompatibleFilter, got %v", err)
+	}
+	count := parts[0].Count()
+	err := parts[0].Merge(parts[0])
+	for err == nil {
+		count = parts[0].Count()
+		err = parts[0].Merge(parts[0])
+	}
+	if !errors.Is(err, ErrFilterFull) || parts[0].Count() != count {
+		t.Errorf("Expected ErrFilterFull without changes, got %v with count %d, want %d", err, parts[0].Count(), count)
+	}
+}
+
+// TestGrow tests that a grown filter keeps its items and the expected false positive rate
+func TestGrow(t *testing.T) {
+	cf, err := NewBatch(4000, WithFingerprintSize(16), WithCRC32Hash())
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+	var items [][]byte
+	for i := range 2000 {
+		item := []byte(fmt.Sprintf("grow-%d", i))
+		if cf.Insert(item) {
+			items = append(items, item)
+		}
+	}
+
+	grown, err := cf.Grow(4)
+	if err != nil {
+		t.Fatalf("Grow failed: %v", err)
+	}
+	if grown.Capacity() != 4*cf.Capacity() || grown.Count() != cf.Count() || grown.GrowthFactor() != 4 {
+		t.Errorf("Grown filter has capacity %d, count %d, growth factor %d", grown.Capacity(), grown.Count(), grown.GrowthFactor())
+	}
+	if want := 4 * cf.FalsePositiveRate(); grown.FalsePositiveRate() != want {
+		t.Errorf("FalsePositiveRate() = %g, want %g", grown.FalsePositiveRate(), want)
+	}
+	for _, found := range grown.LookupBatch(items) {
+		if !found {
+			t.Fatal("False negative after Grow")
+		}
+	}
+
+	if _, err := cf.Grow(3); !errors.Is(err, ErrInvalidGrowthFactor) {
+		t.Errorf("Grow(3): expected ErrInvalidGrowthFactor, got %v", err)
+	}
+	if _, err := cf.Grow(1 << 16); !errors.Is(err, ErrGrowthLimit) {
+		t.Errorf("Grow(65536): expected ErrGrowthLimit, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package cuckoofilter
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+)
+
+// bucketLoadFactors holds the load factor a filter reliably reaches for
+// each bucket size, from Fan et al., "Cuckoo Filter: Practically Better Than Bloom"
+var bucketLoadFactors = []struct {
+	bucketSize uint
+	loadFactor float64
+}{
+	{2, 0.84},
+	{4, 0.95},
+	{8, 0.98},
+	{16, 0.99},
+	{32, 0.99},
+	{64, 0.99},
+}
+
+// fingerprintBitsFor returns the smallest fingerprint size whose worst-case
+// false positive rate, 2b/2^f for buckets of b entries, is at most fpr
+func fingerprintBitsFor(bucketSize uint, fpr float64) uint {
+	bits := math.Ceil(math.Log2(2 * float64(bucketSize) / fpr))
+	return uint(max(bits, 1))
+}
+
+// NewWithFPR creates a filter for expectedItems items whose false positive
+// rate stays below fpr. The fingerprint size follows from the bound 2b/2^f,
+// and the bucket size is the one that needs the fewest bits per item at its
+// achievable load factor (84% for 2, 95% for 4, 98% for 8, 99% beyond).
+// WithBucketSize restricts the choice to one bucket size; WithFingerprintSize
+// has no effect. Targets below what 16-bit fingerprints allow fail with
+// ErrUnattainableFalsePositiveRate.
+//
+// Example:
+//
+//	cf, _ := cuckoofilter.NewWithFPR(1_000_000, 0.001)
+//	fmt.Println(cf.BucketSize(), cf.FingerprintBits(), cf.FalsePositiveRate())
+func NewWithFPR(expectedItems uint, fpr float64, opts ...Option) (*Filter, error) {
+	if expectedItems == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	if !(fpr > 0 && fpr < 1) {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	options := defaultOptions()
+	options.bucketSize = 0 // Chosen below unless set by an option
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	bucketSize, loadFactor, bits := uint(0), 0.0, uint(0)
+	bestCost := math.Inf(1)
+	for _, lf := range bucketLoadFactors {
+		if options.bucketSize != 0 && lf.bucketSize != options.bucketSize {
+			continue
+		}
+		f := fingerprintBitsFor(lf.bucketSize, fpr)
+		if cost := float64(f) / lf.loadFactor; f <= 16 && cost < bestCost {
+			bucketSize, loadFactor, bits, bestCost = lf.bucketSize, lf.loadFactor, f, cost
+		}
+	}
+
+	if bucketSize == 0 {
+		b := options.bucketSize
+		if b == 0 {
+			b = bucketLoadFactors[0].bucketSize
+		} else if err := options.Validate(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: requested %g, best with bucket size %d and 16-bit fingerprints is %g",
+			ErrUnattainableFalsePositiveRate, fpr, b, filter.FalsePositiveBound(b, 16))
+	}
+
+	options.bucketSize = bucketSize
+	options.fingerprintBits = bits
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	capacity := uint(math.Ceil(float64(expectedItems) / loadFactor))
+	return newFilter(capacity, &options), nil
+}
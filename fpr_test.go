@@ -0,0 +1,98 @@
+package cuckoofilter
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestNewWithFPR validates the parameters chosen for common targets
+func TestNewWithFPR(t *testing.T) {
+	tests := []struct {
+		fpr        float64
+		bucketSize uint
+		bits       uint
+	}{
+		{0.1, 2, 6},
+		{0.01, 4, 10},
+		{0.001, 4, 13},
+		{0.0001, 2, 16}, // 8/2^16 misses the target, so only bucket size 2 qualifies
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.fpr), func(t *testing.T) {
+			cf, err := NewWithFPR(100000, tt.fpr)
+			if err != nil {
+				t.Fatalf("NewWithFPR failed: %v", err)
+			}
+
+			if cf.BucketSize() != tt.bucketSize || cf.FingerprintBits() != tt.bits {
+				t.Errorf("Chose %d×%d-bit, want %d×%d-bit",
+					cf.BucketSize(), cf.FingerprintBits(), tt.bucketSize, tt.bits)
+			}
+			if cf.FalsePositiveRate() > tt.fpr {
+				t.Errorf("Predicted FPR %g exceeds target %g", cf.FalsePositiveRate(), tt.fpr)
+			}
+			if cf.Capacity() < 100000 {
+				t.Errorf("Capacity %d is below expected items", cf.Capacity())
+			}
+			for _, lf := range bucketLoadFactors {
+				if load := 100000 / float64(cf.Capacity()); lf.bucketSize == cf.BucketSize() && load > lf.loadFactor {
+					t.Errorf("Expected items reach load %.3f, above the %.2f limit for bucket size %d",
+						load, lf.loadFactor, lf.bucketSize)
+				}
+			}
+		})
+	}
+}
+
+// TestNewWithFPRBucketSizeOption validates that an explicit bucket size is kept
+func TestNewWithFPRBucketSizeOption(t *testing.T) {
+	cf, err := NewWithFPR(10000, 0.01, WithBucketSize(16))
+	if err != nil {
+		t.Fatalf("NewWithFPR failed: %v", err)
+	}
+	if cf.BucketSize() != 16 {
+		t.Errorf("Expected bucket size 16, got %d", cf.BucketSize())
+	}
+	if cf.FingerprintBits() != 12 {
+		t.Errorf("Expected 12-bit fingerprints for 32/2^f <= 0.01, got %d", cf.FingerprintBits())
+	}
+}
+
+// TestNewWithFPRUnattainable validates rejection of targets beyond 16-bit fingerprints
+func TestNewWithFPRUnattainable(t *testing.T) {
+	for _, tc := range []struct {
+		fpr  float64
+		opts []Option
+	}{
+		{1e-5, nil},
+		{1e-4, []Option{WithBucketSize(8)}},
+	} {
+		_, err := NewWithFPR(1000, tc.fpr, tc.opts...)
+		if !errors.Is(err, ErrUnattainableFalsePositiveRate) {
+			t.Errorf("FPR %g: expected ErrUnattainableFalsePositiveRate, got %v", tc.fpr, err)
+		}
+		t.Logf("Rejected: %v", err)
+	}
+
+	// 2·2/2^16 ≈ 6.1e-5 is the best any configuration can do
+	if _, err := NewWithFPR(1000, 7e-5); err != nil {
+		t.Errorf("FPR 7e-5 should be attainable with bucket size 2: %v", err)
+	}
+}
+
+// TestNewWithFPRInvalidParameters validates argument checking
+func TestNewWithFPRInvalidParameters(t *testing.T) {
+	if _, err := NewWithFPR(0, 0.01); err != ErrInvalidCapacity {
+		t.Errorf("Expected ErrInvalidCapacity, got %v", err)
+	}
+	for _, fpr := range []float64{0, -1, 1, 1.5} {
+		if _, err := NewWithFPR(1000, fpr); err != ErrInvalidFalsePositiveRate {
+			t.Errorf("Expected ErrInvalidFalsePositiveRate for %g, got %v", fpr, err)
+		}
+	}
+	if _, err := NewWithFPR(1000, 0.01, WithBucketSize(3)); err != ErrInvalidBucketSize {
+		t.Errorf("Expected ErrInvalidBucketSize, got %v", err)
+	}
+}
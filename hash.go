@@ -7,9 +7,11 @@ import "github.com/shaia/simdcuckoofilter/internal/hash"
 type hashStrategy int
 
 const (
-	hashStrategyFNV    hashStrategy = hashStrategy(hash.HashStrategyFNV)
-	hashStrategyCRC32  hashStrategy = hashStrategy(hash.HashStrategyCRC32)
-	hashStrategyXXHash hashStrategy = hashStrategy(hash.HashStrategyXXHash)
+	hashStrategyFNV     hashStrategy = hashStrategy(hash.HashStrategyFNV)
+	hashStrategyCRC32   hashStrategy = hashStrategy(hash.HashStrategyCRC32)
+	hashStrategyXXHash  hashStrategy = hashStrategy(hash.HashStrategyXXHash)
+	hashStrategySipHash hashStrategy = hashStrategy(hash.HashStrategySipHash)
+	hashStrategyCustom  hashStrategy = hashStrategy(hash.HashStrategyCustom)
 )
 
 // String returns the string representation of the hash strategy
@@ -21,6 +23,10 @@ func (s hashStrategy) String() string {
 		return "CRC32C"
 	case hashStrategyXXHash:
 		return "XXHash64"
+	case hashStrategySipHash:
+		return "SipHash-2-4"
+	case hashStrategyCustom:
+		return "Custom"
 	default:
 		return "Unknown"
 	}
@@ -52,3 +58,55 @@ func WithXXHash() Option {
 		o.hashStrategy = hashStrategyXXHash
 	}
 }
+
+// WithSipHash configures the filter to use SipHash-2-4 keyed with key.
+// The other hash functions are public and deterministic, so anyone who knows
+// a filter uses them can craft items that all land in the same buckets with
+// the same fingerprint, making inserts fail and lookups match items that were
+// never added. SipHash placements can't be predicted without the key, which
+// makes it the choice for filters fed by untrusted input. It is several times
+// slower than the other hash functions.
+//
+// The key must be random, kept secret and reused whenever the filter is
+// loaded: it is not stored by MarshalBinary or WriteTo, and Load and
+// OpenMmap need WithSipHash with the same key to read the filter.
+func WithSipHash(key [16]byte) Option {
+	return func(o *Options) {
+		o.hashStrategy = hashStrategySipHash
+		o.sipKey = key
+	}
+}
+
+// WithSeed makes the FNV-1a, CRC32C and XXHash64 hash functions place items
+// as a variant selected by seed, so filters with different seeds put the
+// same item in different buckets with different fingerprints. A random seed
+// keeps precomputed colliding items from working against a filter, but not
+// an attacker who can probe it, and CRC32C items of equal length that
+// collide do so under every seed, because CRCs are linear. Use WithSipHash to
+// resist deliberate collisions.
+//
+// The seed is stored by MarshalBinary and WriteTo. Filters with different
+// seeds can't be merged or loaded into each other. WithSipHash ignores it.
+// Default: 0
+func WithSeed(seed uint64) Option {
+	return func(o *Options) {
+		o.seed = seed
+	}
+}
+
+// WithCustomHash configures the filter to hash items with fn, a 64-bit hash
+// function the caller already uses, such as the one items are sharded by.
+// Fingerprints and buckets are derived from fn(item) as from the built-in
+// hashes, after a 64-bit finalizer spreads its bits, and Filter.InsertHash,
+// LookupHash and DeleteHash take fn's values directly.
+//
+// fn must be deterministic and safe for concurrent use. It isn't stored by
+// MarshalBinary or WriteTo, only its hash of an empty item as a check value:
+// Load and OpenMmap need WithCustomHash with the same function to read the
+// filter. fn must not be nil.
+func WithCustomHash(fn func([]byte) uint64) Option {
+	return func(o *Options) {
+		o.hashStrategy = hashStrategyCustom
+		o.customHash = fn
+	}
+}
@@ -15,6 +15,16 @@ func NewBucket(size uint) *Bucket {
 	}
 }
 
+// View returns a bucket backed by an existing fingerprint slice, without copying.
+// The bucket size is len(fingerprints). Views let contiguous fingerprint
+// storage, such as a memory-mapped table, use the bucket operations directly.
+func View(fingerprints []uint16) Bucket {
+	return Bucket{
+		fingerprints: fingerprints,
+		size:         uint(len(fingerprints)),
+	}
+}
+
 // Insert adds a fingerprint to the bucket if there's space
 // Returns true if successful, false if bucket is full
 func (b *Bucket) Insert(fp uint16) bool {
@@ -33,8 +43,9 @@ func (b *Bucket) Remove(fp uint16) bool {
 }
 
 // Contains checks if a fingerprint exists in the bucket
+// Uses the platform SIMD comparison (AVX2 on AMD64) for larger buckets
 func (b *Bucket) Contains(fp uint16) bool {
-	return inlineContains(b.fingerprints[:b.size], fp)
+	return containsSIMD(b.fingerprints[:b.size], fp)
 }
 
 // IsFull returns true if the bucket has no empty slots
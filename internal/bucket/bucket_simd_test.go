@@ -33,9 +33,9 @@ func TestSIMDBucketContains(t *testing.T) {
 			// Verify SIMD and scalar give same results
 			for fp := 0; fp < 255; fp++ {
 				simdResult := b.ContainsSIMD(uint16(fp))
-				scalarResult := b.Contains(uint16(fp))
+				scalarResult := inlineContains(b.GetFingerprints(), uint16(fp))
 				if simdResult != scalarResult {
-					t.Errorf("ContainsSIMD(%d) = %v, Contains(%d) = %v", fp, simdResult, fp, scalarResult)
+					t.Errorf("ContainsSIMD(%d) = %v, inlineContains(%d) = %v", fp, simdResult, fp, scalarResult)
 				}
 			}
 		})
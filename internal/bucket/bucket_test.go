@@ -0,0 +1,45 @@
+package bucket
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBucketView tests that a view operates on the caller's storage in place
+func TestBucketView(t *testing.T) {
+	sizes := []int{2, 4, 8, 16, 32, 64}
+
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("Size%d", size), func(t *testing.T) {
+			// Two adjacent buckets in one contiguous table
+			table := make([]uint16, 2*size)
+			first := View(table[:size])
+			second := View(table[size:])
+
+			for i := 0; i < size; i++ {
+				if !second.Insert(uint16(1000 + i)) {
+					t.Fatalf("Insert %d failed", i)
+				}
+			}
+
+			if first.Count() != 0 {
+				t.Errorf("Writes to one view leaked into its neighbour: count=%d", first.Count())
+			}
+			if !second.IsFull() {
+				t.Error("View should be full")
+			}
+			for i := 0; i < size; i++ {
+				if table[size+i] != uint16(1000+i) {
+					t.Errorf("table[%d] = %d, want %d", size+i, table[size+i], 1000+i)
+				}
+			}
+
+			for fp := 0; fp < 1100; fp++ {
+				want := inlineContains(table[size:], uint16(fp))
+				if got := second.Contains(uint16(fp)); got != want {
+					t.Errorf("Contains(%d) = %v, want %v", fp, got, want)
+				}
+			}
+		})
+	}
+}
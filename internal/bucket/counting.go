@@ -0,0 +1,100 @@
+package bucket
+
+// CountingTable stores a counter alongside every fingerprint, for filters
+// that count how often each item was added. Fingerprints live in a Table with
+// the usual layouts, so membership checks keep the SIMD Contains kernels, and
+// counters live in a second table of the same geometry whose "fingerprints"
+// are counterBits wide.
+//
+// An occupied slot has a count from 1 to MaxCount; an empty slot has
+// fingerprint 0 and count 0. The methods below keep both tables in step.
+type CountingTable struct {
+	fingerprints Table
+	counters     Table
+}
+
+// NewCountingTable allocates an empty counting table.
+// counterBits must be between 1 and 16.
+func NewCountingTable(numBuckets, bucketSize, fingerprintBits, counterBits uint) *CountingTable {
+	return &CountingTable{
+		fingerprints: NewTable(numBuckets, bucketSize, fingerprintBits),
+		counters:     NewTable(numBuckets, bucketSize, counterBits),
+	}
+}
+
+// MaxCount returns the largest count a slot can hold
+func (t *CountingTable) MaxCount() uint {
+	return 1<<t.counters.FingerprintBits() - 1
+}
+
+// CounterBits returns the number of bits per counter
+func (t *CountingTable) CounterBits() uint {
+	return t.counters.FingerprintBits()
+}
+
+// Contains checks if fp exists in bucket i
+func (t *CountingTable) Contains(i uint, fp uint16) bool {
+	return t.fingerprints.Contains(i, fp)
+}
+
+// Find returns the slot of bucket i holding fp
+func (t *CountingTable) Find(i uint, fp uint16) (pos uint, ok bool) {
+	if !t.fingerprints.Contains(i, fp) {
+		return 0, false
+	}
+	for pos := uint(0); pos < t.fingerprints.BucketSize(); pos++ {
+		if t.fingerprints.Get(i, pos) == fp {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+// Get returns the fingerprint and count at slot pos of bucket i
+func (t *CountingTable) Get(i, pos uint) (fp uint16, count uint) {
+	return t.fingerprints.Get(i, pos), uint(t.counters.Get(i, pos))
+}
+
+// SetCount replaces the count at slot pos of bucket i. count must be
+// between 1 and MaxCount for an occupied slot.
+func (t *CountingTable) SetCount(i, pos, count uint) {
+	t.counters.Swap(i, pos, uint16(count))
+}
+
+// Insert stores fp with the given count in a free slot of bucket i
+func (t *CountingTable) Insert(i uint, fp uint16, count uint) bool {
+	for pos := uint(0); pos < t.fingerprints.BucketSize(); pos++ {
+		if t.fingerprints.Get(i, pos) == 0 {
+			t.fingerprints.Swap(i, pos, fp)
+			t.counters.Swap(i, pos, uint16(count))
+			return true
+		}
+	}
+	return false
+}
+
+// Swap replaces slot pos of bucket i and returns its previous fingerprint and count
+func (t *CountingTable) Swap(i, pos uint, fp uint16, count uint) (uint16, uint) {
+	return t.fingerprints.Swap(i, pos, fp), uint(t.counters.Swap(i, pos, uint16(count)))
+}
+
+// Clear empties slot pos of bucket i
+func (t *CountingTable) Clear(i, pos uint) {
+	t.Swap(i, pos, 0, 0)
+}
+
+// Count returns the number of occupied slots in bucket i
+func (t *CountingTable) Count(i uint) uint {
+	return t.fingerprints.Count(i)
+}
+
+// Size returns the combined storage size of fingerprints and counters in bytes
+func (t *CountingTable) Size() uint {
+	return uint(len(t.fingerprints.Bytes()) + len(t.counters.Bytes()))
+}
+
+// Reset clears every slot
+func (t *CountingTable) Reset() {
+	t.fingerprints.Reset()
+	t.counters.Reset()
+}
@@ -0,0 +1,67 @@
+package bucket
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestCountingTable tests that counters follow their fingerprints through
+// every operation
+func TestCountingTable(t *testing.T) {
+	for _, bits := range []uint{5, 8, 12, 16} {
+		for _, counterBits := range []uint{2, 4, 8, 16} {
+			t.Run(fmt.Sprintf("%dbits/%dcounter", bits, counterBits), func(t *testing.T) {
+				const numBuckets, bucketSize = 8, 4
+				table := NewCountingTable(numBuckets, bucketSize, bits, counterBits)
+				maxCount := uint(1)<<counterBits - 1
+				if table.MaxCount() != maxCount || table.CounterBits() != counterBits {
+					t.Fatalf("MaxCount() = %d, CounterBits() = %d", table.MaxCount(), table.CounterBits())
+				}
+				want := TableSize(numBuckets, bucketSize, bits) + TableSize(numBuckets, bucketSize, counterBits)
+				if table.Size() != want {
+					t.Errorf("Size() = %d, want %d", table.Size(), want)
+				}
+
+				for pos := uint(0); pos < bucketSize; pos++ {
+					if !table.Insert(3, uint16(pos+1), min(pos+1, maxCount)) {
+						t.Fatalf("Insert into slot %d failed", pos)
+					}
+				}
+				if table.Insert(3, 9, 1) || table.Count(3) != bucketSize {
+					t.Fatal("Insert into a full bucket succeeded")
+				}
+
+				pos, ok := table.Find(3, 2)
+				if !ok || pos != 1 {
+					t.Fatalf("Find(3, 2) = %d, %v", pos, ok)
+				}
+				table.SetCount(3, pos, maxCount)
+				if fp, count := table.Get(3, pos); fp != 2 || count != maxCount {
+					t.Errorf("Get = %d×%d, want 2×%d", fp, count, maxCount)
+				}
+				if fp, count := table.Swap(3, pos, 7, 1); fp != 2 || count != maxCount {
+					t.Errorf("Swap returned %d×%d, want 2×%d", fp, count, maxCount)
+				}
+
+				table.Clear(3, pos)
+				if _, ok := table.Find(3, 7); ok || table.Contains(3, 7) || table.Count(3) != bucketSize-1 {
+					t.Error("Clear left the slot occupied")
+				}
+				if _, count := table.Get(3, pos); count != 0 {
+					t.Errorf("Cleared slot has count %d", count)
+				}
+				if _, ok := table.Find(2, 1); ok {
+					t.Error("Find matched in the wrong bucket")
+				}
+
+				table.Reset()
+				if table.Count(3) != 0 {
+					t.Error("Reset left slots occupied")
+				}
+				if _, count := table.Get(3, 0); count != 0 {
+					t.Error("Reset left counters set")
+				}
+			})
+		}
+	}
+}
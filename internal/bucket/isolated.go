@@ -0,0 +1,151 @@
+package bucket
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"unsafe"
+)
+
+// IsolationGroup is the number of consecutive buckets that share storage
+// words in an isolated table. Groups start on 32-bit word boundaries for
+// every supported bucket size and fingerprint size.
+const IsolationGroup = 16
+
+// maxBucketWords is the number of 32-bit words needed to snapshot the
+// largest bucket (64×16-bit) at any bit offset, plus one word of slack so
+// packed fingerprints can be read with 32-bit loads
+const maxBucketWords = (64*16+31)/32 + 2
+
+// ConcurrentTable is a Table that can be read while it is being written.
+// Writers still need mutual exclusion per group of IsolationGroup buckets,
+// but readers can check a bucket with ContainsAtomic without taking any lock
+// and validate the result afterwards, for example with a sequence counter.
+type ConcurrentTable interface {
+	Table
+
+	// ContainsAtomic is Contains for a bucket that may be written
+	// concurrently. The bucket is copied with atomic loads and compared with
+	// the same kernels as Contains; the result is only meaningful if no write
+	// to the bucket overlapped the call.
+	ContainsAtomic(i uint, fp uint16) bool
+}
+
+// isolatedTable stores every fingerprint with atomic 32-bit word stores
+// and leaves the reads of writers, who hold the bucket's lock, to the
+// underlying layout
+type isolatedTable struct {
+	Table
+	words      []uint32 // The table storage as 32-bit words
+	bucketSize uint
+	bits       uint
+	mask       uint32
+}
+
+// Isolated returns a concurrent table with the contents of t. Operations on
+// a bucket only touch storage words of its group of IsolationGroup buckets,
+// so groups can be written concurrently, and every write is an atomic word
+// store, so buckets can be read with ContainsAtomic at any time. The storage
+// of t is shared unless it has to be copied to be word aligned.
+func Isolated(t Table) ConcurrentTable {
+	data := t.Bytes()
+	n := uint(len(data))
+	if uintptr(unsafe.Pointer(unsafe.SliceData(data)))%4 != 0 || n%4 != 0 {
+		buf := AlignedBytes((n + 3) &^ 3)
+		copy(buf, data)
+		data = buf[:n]
+		t = NewTableFrom(data, t.NumBuckets(), t.BucketSize(), t.FingerprintBits())
+	}
+
+	if p, ok := t.(*packedTable); ok {
+		// Neighbouring groups may be written while a writer reads its bucket
+		isolated := *p
+		isolated.isolated = true
+		isolated.wordBucket = false
+		t = &isolated
+	}
+
+	return &isolatedTable{
+		Table:      t,
+		words:      unsafe.Slice((*uint32)(unsafe.Pointer(unsafe.SliceData(data))), (n+3)/4),
+		bucketSize: t.BucketSize(),
+		bits:       t.FingerprintBits(),
+		mask:       1<<t.FingerprintBits() - 1,
+	}
+}
+
+// set stores fp in slot s with atomic stores of the one or two words it spans
+func (t *isolatedTable) set(s uint, fp uint16) {
+	bit := s * t.bits
+	w, shift := bit>>5, bit&31
+	spans := shift+t.bits > 32
+
+	cur := uint64(atomic.LoadUint32(&t.words[w]))
+	if spans {
+		cur |= uint64(atomic.LoadUint32(&t.words[w+1])) << 32
+	}
+	cur = cur&^(uint64(t.mask)<<shift) | uint64(fp)<<shift
+
+	atomic.StoreUint32(&t.words[w], uint32(cur))
+	if spans {
+		atomic.StoreUint32(&t.words[w+1], uint32(cur>>32))
+	}
+}
+
+func (t *isolatedTable) Insert(i uint, fp uint16) bool {
+	for pos := uint(0); pos < t.bucketSize; pos++ {
+		if t.Get(i, pos) == 0 {
+			t.set(i*t.bucketSize+pos, fp)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *isolatedTable) Remove(i uint, fp uint16) bool {
+	for pos := uint(0); pos < t.bucketSize; pos++ {
+		if t.Get(i, pos) == fp {
+			t.set(i*t.bucketSize+pos, 0)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *isolatedTable) Swap(i, pos uint, fp uint16) uint16 {
+	old := t.Get(i, pos)
+	t.set(i*t.bucketSize+pos, fp)
+	return old
+}
+
+func (t *isolatedTable) ContainsAtomic(i uint, fp uint16) bool {
+	var buf [maxBucketWords]uint32
+	start := i * t.bucketSize * t.bits
+	first, end := start>>5, (start+t.bucketSize*t.bits+31)>>5
+	for w := first; w < end; w++ {
+		buf[w-first] = atomic.LoadUint32(&t.words[w])
+	}
+
+	shift := start & 31
+	data := unsafe.Slice((*byte)(unsafe.Pointer(&buf)), 4*len(buf))
+	switch t.bits {
+	case 16:
+		fingerprints := unsafe.Slice((*uint16)(unsafe.Pointer(&buf)), 2*len(buf))
+		return containsSIMD(fingerprints[shift/16:][:t.bucketSize], fp)
+	case 8:
+		return contains8(data[shift/8:][:t.bucketSize], fp)
+	}
+
+	for k := uint(0); k < t.bucketSize; k++ {
+		bit := shift + k*t.bits
+		if uint16(binary.LittleEndian.Uint32(data[bit>>3:])>>(bit&7)&t.mask) == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *isolatedTable) Reset() {
+	for w := range t.words {
+		atomic.StoreUint32(&t.words[w], 0)
+	}
+}
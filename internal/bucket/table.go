@@ -0,0 +1,104 @@
+package bucket
+
+import "unsafe"
+
+// CacheLineSize is the alignment of table storage in bytes
+const CacheLineSize = 64
+
+// Table stores every bucket of a filter in one contiguous, pointer-free
+// allocation that the garbage collector never has to scan. Buckets are
+// addressed by index and fingerprints occupy only the bits they need:
+//   - 16-bit fingerprints use a []uint16 layout with the AVX2/NEON uint16 kernels
+//   - 8-bit fingerprints use a []byte layout with the lookup.BucketLookup kernels
+//   - any other size is bit-packed, so a bucket of 4×12-bit fingerprints is 6 bytes
+//
+// The layout is selected by NewTable from the fingerprint size, the same way
+// hash.NewHashFunction selects a hash implementation from its strategy.
+type Table interface {
+	// Insert adds fp to bucket i if there's space
+	Insert(i uint, fp uint16) bool
+
+	// Remove removes one occurrence of fp from bucket i
+	Remove(i uint, fp uint16) bool
+
+	// Contains checks if fp exists in bucket i
+	Contains(i uint, fp uint16) bool
+
+	// Get returns the fingerprint at slot pos of bucket i
+	Get(i, pos uint) uint16
+
+	// Swap replaces the fingerprint at slot pos of bucket i and returns the old value
+	Swap(i, pos uint, fp uint16) uint16
+
+	// Count returns the number of non-zero fingerprints in bucket i
+	Count(i uint) uint
+
+	// NumBuckets returns the number of buckets in the table
+	NumBuckets() uint
+
+	// BucketSize returns the number of fingerprints per bucket
+	BucketSize() uint
+
+	// FingerprintBits returns the number of bits stored per fingerprint
+	FingerprintBits() uint
+
+	// Bytes returns the raw table storage in its little-endian layout.
+	// Its length is TableSize(NumBuckets(), BucketSize(), FingerprintBits()).
+	Bytes() []byte
+
+	// Reset clears every fingerprint in the table
+	Reset()
+}
+
+// NewTable allocates an empty table of numBuckets buckets holding
+// fingerprints of fingerprintBits bits. The storage starts on a cache-line boundary.
+func NewTable(numBuckets, bucketSize, fingerprintBits uint) Table {
+	return NewTableFrom(AlignedBytes(TableSize(numBuckets, bucketSize, fingerprintBits)),
+		numBuckets, bucketSize, fingerprintBits)
+}
+
+// NewTableFrom creates a table over existing storage without copying, for
+// example a memory-mapped file. data must hold exactly
+// TableSize(numBuckets, bucketSize, fingerprintBits) bytes in the layout
+// returned by Bytes, and must be 2-byte aligned for 16-bit fingerprints.
+func NewTableFrom(data []byte, numBuckets, bucketSize, fingerprintBits uint) Table {
+	switch fingerprintBits {
+	case 16:
+		return &table16{
+			fingerprints: unsafe.Slice((*uint16)(unsafe.Pointer(unsafe.SliceData(data))), len(data)/2),
+			bucketSize:   bucketSize,
+			numBuckets:   numBuckets,
+		}
+	case 8:
+		return &table8{
+			fingerprints: data,
+			bucketSize:   bucketSize,
+			numBuckets:   numBuckets,
+		}
+	default:
+		return newPackedTable(data, numBuckets, bucketSize, fingerprintBits)
+	}
+}
+
+// TableSize returns the storage size in bytes of a table
+func TableSize(numBuckets, bucketSize, fingerprintBits uint) uint {
+	slots := numBuckets * bucketSize
+	switch fingerprintBits {
+	case 16:
+		return 2 * slots
+	case 8:
+		return slots
+	default:
+		return (slots*fingerprintBits+7)/8 + packedPadding
+	}
+}
+
+// AlignedBytes allocates n zeroed bytes starting on a cache-line boundary
+func AlignedBytes(n uint) []byte {
+	buf := make([]byte, n+CacheLineSize)
+	offset := uint(uintptr(unsafe.Pointer(unsafe.SliceData(buf))) % CacheLineSize)
+	if offset != 0 {
+		offset = CacheLineSize - offset
+	}
+	return buf[offset : offset+n : offset+n]
+}
@@ -0,0 +1,65 @@
+package bucket
+
+import "unsafe"
+
+// table16 stores 16-bit fingerprints as a flat []uint16.
+// Bucket i occupies fingerprints[i*bucketSize : (i+1)*bucketSize].
+type table16 struct {
+	fingerprints []uint16
+	bucketSize   uint
+	numBuckets   uint
+}
+
+// bucket returns the fingerprint slots of bucket i
+func (t *table16) bucket(i uint) []uint16 {
+	start := i * t.bucketSize
+	return t.fingerprints[start : start+t.bucketSize : start+t.bucketSize]
+}
+
+func (t *table16) Insert(i uint, fp uint16) bool {
+	data := t.bucket(i)
+	idx := inlineFindFirstZero(data)
+	if idx < uint(len(data)) {
+		data[idx] = fp
+		return true
+	}
+	return false
+}
+
+func (t *table16) Remove(i uint, fp uint16) bool {
+	return inlineRemove(t.bucket(i), fp)
+}
+
+// Contains uses the platform SIMD comparison (AVX2 on AMD64, NEON on ARM64)
+func (t *table16) Contains(i uint, fp uint16) bool {
+	return containsSIMD(t.bucket(i), fp)
+}
+
+func (t *table16) Get(i, pos uint) uint16 {
+	return t.bucket(i)[pos]
+}
+
+func (t *table16) Swap(i, pos uint, fp uint16) uint16 {
+	data := t.bucket(i)
+	old := data[pos]
+	data[pos] = fp
+	return old
+}
+
+func (t *table16) Count(i uint) uint {
+	return inlineCount(t.bucket(i))
+}
+
+func (t *table16) NumBuckets() uint      { return t.numBuckets }
+func (t *table16) BucketSize() uint      { return t.bucketSize }
+func (t *table16) FingerprintBits() uint { return 16 }
+
+// Bytes returns the fingerprints as bytes. amd64 and arm64 are
+// little-endian, so no conversion is needed.
+func (t *table16) Bytes() []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(t.fingerprints))), 2*len(t.fingerprints))
+}
+
+func (t *table16) Reset() {
+	clear(t.fingerprints)
+}
@@ -0,0 +1,112 @@
+package bucket
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/shaia/simdcuckoofilter/internal/lookup"
+)
+
+// table8 stores 8-bit fingerprints as a flat []byte, half the size of the
+// uint16 layout. Bucket i occupies fingerprints[i*bucketSize : (i+1)*bucketSize].
+type table8 struct {
+	fingerprints []byte
+	bucketSize   uint
+	numBuckets   uint
+}
+
+// bucket returns the fingerprint slots of bucket i
+func (t *table8) bucket(i uint) []byte {
+	start := i * t.bucketSize
+	return t.fingerprints[start : start+t.bucketSize : start+t.bucketSize]
+}
+
+func (t *table8) Insert(i uint, fp uint16) bool {
+	data := t.bucket(i)
+	for j, b := range data {
+		if b == 0 {
+			data[j] = byte(fp)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *table8) Remove(i uint, fp uint16) bool {
+	data := t.bucket(i)
+	for j, b := range data {
+		if b == byte(fp) {
+			data[j] = 0
+			return true
+		}
+	}
+	return false
+}
+
+// Contains compares a whole bucket at once. Buckets of 16 or more bytes use
+// the AVX2/NEON byte kernels in internal/lookup; 8-byte buckets are compared
+// as a single 64-bit word.
+func (t *table8) Contains(i uint, fp uint16) bool {
+	return contains8(t.bucket(i), fp)
+}
+
+// contains8 reports whether the 8-bit fingerprint bucket data holds fp
+func contains8(data []byte, fp uint16) bool {
+	switch {
+	case len(data) >= 16:
+		return lookup.BucketLookup(data, byte(fp))
+	case len(data) == 8:
+		return hasByte(binary.LittleEndian.Uint64(data), byte(fp))
+	}
+	for _, b := range data {
+		if b == byte(fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasByte reports whether any byte of w equals b
+func hasByte(w uint64, b byte) bool {
+	const lo = 0x0101010101010101
+	const hi = 0x8080808080808080
+	x := w ^ (lo * uint64(b))
+	return (x-lo)&^x&hi != 0
+}
+
+func (t *table8) Get(i, pos uint) uint16 {
+	return uint16(t.bucket(i)[pos])
+}
+
+func (t *table8) Swap(i, pos uint, fp uint16) uint16 {
+	data := t.bucket(i)
+	old := data[pos]
+	data[pos] = byte(fp)
+	return uint16(old)
+}
+
+func (t *table8) Count(i uint) uint {
+	data := t.bucket(i)
+	if len(data) == 8 {
+		w := binary.LittleEndian.Uint64(data)
+		// Set the high bit of every non-zero byte
+		w = (w | (w&0x7f7f7f7f7f7f7f7f + 0x7f7f7f7f7f7f7f7f)) & 0x8080808080808080
+		return uint(bits.OnesCount64(w))
+	}
+	count := uint(0)
+	for _, b := range data {
+		if b != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *table8) NumBuckets() uint      { return t.numBuckets }
+func (t *table8) BucketSize() uint      { return t.bucketSize }
+func (t *table8) FingerprintBits() uint { return 8 }
+func (t *table8) Bytes() []byte         { return t.fingerprints }
+
+func (t *table8) Reset() {
+	clear(t.fingerprints)
+}
@@ -0,0 +1,163 @@
+package bucket
+
+import "encoding/binary"
+
+// packedPadding is the number of bytes reserved after a packed table so a
+// fingerprint or bucket can always be read with one 64-bit load
+const packedPadding = 8
+
+// packedTable stores fingerprints of any size as a contiguous bit string.
+// Slot s of the table occupies bits [s*bits, (s+1)*bits), little-endian, so
+// a bucket of 4×12-bit fingerprints takes exactly 6 bytes.
+type packedTable struct {
+	data       []byte
+	bucketSize uint
+	numBuckets uint
+	bits       uint
+	mask       uint64
+
+	// wordBucket is set when a whole bucket fits in one unaligned 64-bit
+	// load, which lets Contains and Count read it once
+	wordBucket bool
+
+	// isolated restricts every access to the bytes a slot occupies; see Isolated
+	isolated bool
+}
+
+func newPackedTable(data []byte, numBuckets, bucketSize, bits uint) *packedTable {
+	return &packedTable{
+		data:       data,
+		bucketSize: bucketSize,
+		numBuckets: numBuckets,
+		bits:       bits,
+		mask:       1<<bits - 1,
+		wordBucket: bucketSize*bits+7 <= 64,
+	}
+}
+
+// get returns the fingerprint in slot s
+func (t *packedTable) get(s uint) uint16 {
+	bit := s * t.bits
+	if t.isolated {
+		return uint16(t.load(bit) >> (bit & 7) & uint32(t.mask))
+	}
+	return uint16(binary.LittleEndian.Uint32(t.data[bit>>3:]) >> (bit & 7) & uint32(t.mask))
+}
+
+// set stores fp in slot s
+func (t *packedTable) set(s uint, fp uint16) {
+	bit := s * t.bits
+	p := t.data[bit>>3:]
+	shift := bit & 7
+	if t.isolated {
+		w := t.load(bit)
+		t.store(bit, w&^(uint32(t.mask)<<shift)|uint32(fp)<<shift)
+		return
+	}
+	w := binary.LittleEndian.Uint32(p)
+	w = w&^(uint32(t.mask)<<shift) | uint32(fp)<<shift
+	binary.LittleEndian.PutUint32(p, w)
+}
+
+// slotBytes returns the bytes holding the slot that starts at bit
+func (t *packedTable) slotBytes(bit uint) []byte {
+	return t.data[bit>>3 : (bit+t.bits+7)>>3]
+}
+
+// load reads the bytes of the slot starting at bit, and no others
+func (t *packedTable) load(bit uint) uint32 {
+	var w uint32
+	for j, b := range t.slotBytes(bit) {
+		w |= uint32(b) << (8 * j)
+	}
+	return w
+}
+
+// store writes w back to the bytes of the slot starting at bit, and no others
+func (t *packedTable) store(bit uint, w uint32) {
+	p := t.slotBytes(bit)
+	for j := range p {
+		p[j] = byte(w >> (8 * j))
+	}
+}
+
+// word returns the bits of bucket i in the low bits of a 64-bit word.
+// Only valid when wordBucket is set.
+func (t *packedTable) word(i uint) uint64 {
+	bit := i * t.bucketSize * t.bits
+	return binary.LittleEndian.Uint64(t.data[bit>>3:]) >> (bit & 7)
+}
+
+func (t *packedTable) Insert(i uint, fp uint16) bool {
+	start := i * t.bucketSize
+	for s := start; s < start+t.bucketSize; s++ {
+		if t.get(s) == 0 {
+			t.set(s, fp)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *packedTable) Remove(i uint, fp uint16) bool {
+	start := i * t.bucketSize
+	for s := start; s < start+t.bucketSize; s++ {
+		if t.get(s) == fp {
+			t.set(s, 0)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *packedTable) Contains(i uint, fp uint16) bool {
+	if t.wordBucket {
+		w := t.word(i)
+		for range t.bucketSize {
+			if uint16(w&t.mask) == fp {
+				return true
+			}
+			w >>= t.bits
+		}
+		return false
+	}
+
+	start := i * t.bucketSize
+	for s := start; s < start+t.bucketSize; s++ {
+		if t.get(s) == fp {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *packedTable) Get(i, pos uint) uint16 {
+	return t.get(i*t.bucketSize + pos)
+}
+
+func (t *packedTable) Swap(i, pos uint, fp uint16) uint16 {
+	s := i*t.bucketSize + pos
+	old := t.get(s)
+	t.set(s, fp)
+	return old
+}
+
+func (t *packedTable) Count(i uint) uint {
+	count := uint(0)
+	start := i * t.bucketSize
+	for s := start; s < start+t.bucketSize; s++ {
+		if t.get(s) != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func (t *packedTable) NumBuckets() uint      { return t.numBuckets }
+func (t *packedTable) BucketSize() uint      { return t.bucketSize }
+func (t *packedTable) FingerprintBits() uint { return t.bits }
+func (t *packedTable) Bytes() []byte         { return t.data }
+
+func (t *packedTable) Reset() {
+	clear(t.data)
+}
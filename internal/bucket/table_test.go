@@ -0,0 +1,285 @@
+package bucket
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+// TestTableAlignment tests that table storage starts on a cache line
+func TestTableAlignment(t *testing.T) {
+	for _, numBuckets := range []uint{1, 3, 64, 1000} {
+		for _, bits := range []uint{4, 8, 12, 16} {
+			table := NewTable(numBuckets, 4, bits)
+			data := table.Bytes()
+
+			if uint(len(data)) != TableSize(numBuckets, 4, bits) {
+				t.Errorf("%d buckets/%d bits: expected %d bytes, got %d",
+					numBuckets, bits, TableSize(numBuckets, 4, bits), len(data))
+			}
+			if addr := uintptr(unsafe.Pointer(unsafe.SliceData(data))); addr%CacheLineSize != 0 {
+				t.Errorf("%d buckets/%d bits: storage at %#x is not cache-line aligned", numBuckets, bits, addr)
+			}
+		}
+	}
+}
+
+// TestTableSize tests that fingerprints occupy only the bits they need
+func TestTableSize(t *testing.T) {
+	tests := []struct {
+		bucketSize, bits uint
+		bytesPerBucket   float64
+	}{
+		{4, 16, 8},
+		{8, 8, 8},
+		{4, 8, 4},
+		{4, 12, 6},
+		{8, 12, 12},
+		{4, 4, 2},
+		{2, 5, 1.25},
+	}
+
+	const numBuckets = 1 << 16
+	for _, tt := range tests {
+		size := TableSize(numBuckets, tt.bucketSize, tt.bits)
+		want := uint(tt.bytesPerBucket * numBuckets)
+		if size < want || size > want+packedPadding {
+			t.Errorf("%d×%d-bit: table is %d bytes, want %d", tt.bucketSize, tt.bits, size, want)
+		}
+	}
+}
+
+// TestTableLayouts tests every layout, plain and isolated, against a reference model
+func TestTableLayouts(t *testing.T) {
+	for _, bits := range []uint{1, 4, 7, 8, 9, 12, 15, 16} {
+		for _, bucketSize := range []uint{2, 4, 8, 16, 32, 64} {
+			for _, isolated := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%dbits/Size%d/Isolated=%v", bits, bucketSize, isolated), func(t *testing.T) {
+					const numBuckets = 16
+					table := NewTable(numBuckets, bucketSize, bits)
+					if isolated {
+						table = Isolated(table)
+					}
+					model := make([][]uint16, numBuckets)
+					for i := range model {
+						model[i] = make([]uint16, bucketSize)
+					}
+
+					rng := rand.New(rand.NewPCG(uint64(bits), uint64(bucketSize)))
+					randomFp := func() uint16 { return uint16(rng.Uint64N(1<<bits-1) + 1) }
+
+					for op := 0; op < 5000; op++ {
+						i := rng.UintN(numBuckets)
+						fp := randomFp()
+
+						switch rng.IntN(5) {
+						case 0:
+							want := false
+							for j, v := range model[i] {
+								if v == 0 {
+									model[i][j] = fp
+									want = true
+									break
+								}
+							}
+							if got := table.Insert(uint(i), fp); got != want {
+								t.Fatalf("Insert(%d, %d) = %v, want %v", i, fp, got, want)
+							}
+						case 1:
+							want := false
+							for j, v := range model[i] {
+								if v == fp {
+									model[i][j] = 0
+									want = true
+									break
+								}
+							}
+							if got := table.Remove(uint(i), fp); got != want {
+								t.Fatalf("Remove(%d, %d) = %v, want %v", i, fp, got, want)
+							}
+						case 2:
+							pos := rng.UintN(bucketSize)
+							want := model[i][pos]
+							model[i][pos] = fp
+							if got := table.Swap(uint(i), pos, fp); got != want {
+								t.Fatalf("Swap(%d, %d, %d) = %d, want %d", i, pos, fp, got, want)
+							}
+						case 3:
+							if got, want := table.Contains(uint(i), fp), modelContains(model[i], fp); got != want {
+								t.Fatalf("Contains(%d, %d) = %v, want %v", i, fp, got, want)
+							}
+							if ct, ok := table.(ConcurrentTable); ok {
+								if got, want := ct.ContainsAtomic(uint(i), fp), modelContains(model[i], fp); got != want {
+									t.Fatalf("ContainsAtomic(%d, %d) = %v, want %v", i, fp, got, want)
+								}
+							}
+						case 4:
+							pos := rng.UintN(bucketSize)
+							if got, want := table.Get(uint(i), pos), model[i][pos]; got != want {
+								t.Fatalf("Get(%d, %d) = %d, want %d", i, pos, got, want)
+							}
+						}
+					}
+
+					for i := range model {
+						want := uint(0)
+						for _, v := range model[i] {
+							if v != 0 {
+								want++
+								if !table.Contains(uint(i), v) {
+									t.Errorf("Bucket %d lost fingerprint %d", i, v)
+								}
+							}
+						}
+						if got := table.Count(uint(i)); got != want {
+							t.Errorf("Count(%d) = %d, want %d", i, got, want)
+						}
+					}
+
+					table.Reset()
+					for i := uint(0); i < numBuckets; i++ {
+						if table.Count(i) != 0 {
+							t.Errorf("Bucket %d not empty after Reset", i)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestIsolatedGroups tests that groups of an isolated table can be written
+// concurrently; run with -race to check that no access crosses a group
+func TestIsolatedGroups(t *testing.T) {
+	for _, bits := range []uint{3, 8, 12, 16} {
+		const groups = 8
+		table := Isolated(NewTable(groups*IsolationGroup, 2, bits))
+
+		var wg sync.WaitGroup
+		for g := uint(0); g < groups; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fp := uint16(g%(1<<bits-1) + 1)
+				for round := 0; round < 100; round++ {
+					for i := g * IsolationGroup; i < (g+1)*IsolationGroup; i++ {
+						table.Insert(i, fp)
+						table.Contains(i, fp)
+						table.Remove(i, fp)
+					}
+				}
+				for i := g * IsolationGroup; i < (g+1)*IsolationGroup; i++ {
+					table.Insert(i, fp)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i := uint(0); i < groups*IsolationGroup; i++ {
+			fp := uint16((i/IsolationGroup)%(1<<bits-1) + 1)
+			if table.Count(i) != 1 || !table.Contains(i, fp) {
+				t.Errorf("%d bits: bucket %d lost its fingerprint", bits, i)
+			}
+		}
+	}
+}
+
+// TestContainsAtomic tests reading buckets while a writer modifies other
+// slots of them: fingerprints that stay in place must always be found.
+// Run with -race to check that reads and writes are atomic.
+func TestContainsAtomic(t *testing.T) {
+	for _, bits := range []uint{5, 8, 13, 16} {
+		for _, bucketSize := range []uint{2, 4, 16} {
+			t.Run(fmt.Sprintf("%dbits/Size%d", bits, bucketSize), func(t *testing.T) {
+				const numBuckets = 2 * IsolationGroup
+				table := Isolated(NewTable(numBuckets, bucketSize, bits))
+				stable := func(i uint) uint16 { return uint16(i%(1<<bits-2) + 1) }
+				for i := uint(0); i < numBuckets; i++ {
+					table.Insert(i, stable(i))
+				}
+
+				var wg sync.WaitGroup
+				stop := make(chan struct{})
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					moving := uint16(1<<bits - 1)
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						for i := uint(0); i < numBuckets; i++ {
+							for table.Insert(i, moving) {
+							}
+							for table.Remove(i, moving) {
+							}
+						}
+					}
+				}()
+
+				for round := 0; round < 200; round++ {
+					for i := uint(0); i < numBuckets; i++ {
+						if !table.ContainsAtomic(i, stable(i)) {
+							t.Errorf("Bucket %d: stable fingerprint missed during writes", i)
+						}
+					}
+				}
+				close(stop)
+				wg.Wait()
+			})
+		}
+	}
+}
+
+// TestIsolatedUnaligned tests isolating storage that is not word aligned
+func TestIsolatedUnaligned(t *testing.T) {
+	data := AlignedBytes(TableSize(IsolationGroup, 2, 3) + 1)[1:]
+	table := NewTableFrom(data, IsolationGroup, 2, 3)
+	table.Insert(5, 6)
+
+	isolated := Isolated(table)
+	if !isolated.ContainsAtomic(5, 6) || !isolated.Contains(5, 6) {
+		t.Error("Isolated table lost its contents")
+	}
+	isolated.Insert(7, 3)
+	if !isolated.ContainsAtomic(7, 3) || isolated.Count(7) != 1 {
+		t.Error("Isolated copy not writable")
+	}
+}
+
+// TestNewTableFrom tests wrapping existing storage without copying
+func TestNewTableFrom(t *testing.T) {
+	for _, bits := range []uint{8, 12, 16} {
+		src := NewTable(4, 8, bits)
+		src.Insert(2, 42)
+
+		data := append([]byte(nil), src.Bytes()...)
+		table := NewTableFrom(data, 4, 8, bits)
+
+		if table.NumBuckets() != 4 || table.BucketSize() != 8 || table.FingerprintBits() != bits {
+			t.Fatalf("%d bits: unexpected geometry %dx%d/%d", bits,
+				table.NumBuckets(), table.BucketSize(), table.FingerprintBits())
+		}
+		if !table.Contains(2, 42) {
+			t.Errorf("%d bits: fingerprint not visible through wrapped storage", bits)
+		}
+
+		table.Insert(1, 7)
+		if !NewTableFrom(data, 4, 8, bits).Contains(1, 7) {
+			t.Errorf("%d bits: Insert should write to the caller's storage", bits)
+		}
+	}
+}
+
+func modelContains(bucket []uint16, fp uint16) bool {
+	for _, v := range bucket {
+		if v == fp {
+			return true
+		}
+	}
+	return false
+}
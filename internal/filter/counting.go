@@ -0,0 +1,273 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// Counting is a cuckoo filter that counts how often each item was added.
+// Every fingerprint is stored once with a counter (bucket.CountingTable), so
+// repeated inserts of an item don't use up its buckets. Items whose
+// fingerprints collide share a counter, the counting analogue of a false
+// positive.
+//
+// A counter at MaxCount is saturated: the item was added at least MaxCount
+// times, but the exact number was lost, so the counter is never decremented
+// again. Exact counts range from 1 to MaxCount-1.
+type Counting struct {
+	table           *bucket.CountingTable
+	numBuckets      uint
+	bucketSize      uint
+	numItems        uint // Distinct fingerprints stored, including stashed ones
+	maxKicks        uint
+	hash            hash.HashInterface
+	victimCacheSize uint
+	stash           []countedVictim // Fingerprints evicted by relocations that ran out of kicks
+	rng             *rand.Rand
+	mu              sync.RWMutex
+}
+
+// countedVictim is a stashed fingerprint with its count
+type countedVictim struct {
+	victim
+	count uint
+}
+
+// countedSlot locates a counted fingerprint: slot pos of bucket index, or
+// stash entry entry if stashed
+type countedSlot struct {
+	index, pos uint
+	stashed    bool
+	entry      int
+}
+
+// NewCounting creates a counting filter with room for capacity distinct
+// items and counters of counterBits bits. cfg.LockStripes is ignored.
+func NewCounting(capacity uint, cfg Config, counterBits uint) *Counting {
+	numBuckets := nextPowerOf2((capacity + cfg.BucketSize - 1) / cfg.BucketSize)
+	if numBuckets == 0 {
+		numBuckets = 1
+	}
+
+	return &Counting{
+		table:           bucket.NewCountingTable(numBuckets, cfg.BucketSize, cfg.FingerprintBits, counterBits),
+		numBuckets:      numBuckets,
+		bucketSize:      cfg.BucketSize,
+		maxKicks:        cfg.MaxKicks,
+		hash:            hash.NewHashFunctionWithParams(cfg.HashStrategy, cfg.FingerprintBits, cfg.hashParams()),
+		victimCacheSize: cfg.VictimCacheSize,
+		stash:           make([]countedVictim, 0, cfg.VictimCacheSize),
+		rng:             rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// InsertN adds n occurrences of item. ok is false, and nothing is added, if
+// a new item doesn't fit. saturated reports that the item's counter reached
+// MaxCount and stopped counting.
+func (c *Counting) InsertN(item []byte, n uint) (ok, saturated bool) {
+	if n == 0 {
+		return true, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.drainStash()
+	maxCount := c.table.MaxCount()
+	i1, i2, fp := c.hash.GetIndices(item, c.numBuckets)
+
+	if s, found := c.find(i1, i2, fp); found {
+		count := c.countAt(s)
+		if count == maxCount {
+			return true, true
+		}
+		saturated = n >= maxCount-count
+		c.setCount(s, min(count+n, maxCount))
+		return true, saturated
+	}
+
+	count := min(n, maxCount)
+	saturated = n >= maxCount
+	if !c.table.Insert(i1, fp, count) && !c.table.Insert(i2, fp, count) {
+		// As in Filter.Insert, only relocate if the stash can take the leftover
+		if uint(len(c.stash)) == c.victimCacheSize {
+			return false, false
+		}
+		c.relocate(i1, i2, fp, count)
+	}
+	c.numItems++
+	return true, saturated
+}
+
+// relocate places fp and its count into bucket i1 or i2 by evicting resident
+// slots, with their counts, to their alternate buckets. The slot left over
+// after maxKicks is stashed; the caller must ensure the stash has room.
+func (c *Counting) relocate(i1, i2 uint, fp uint16, count uint) {
+	index := i1
+	if c.rng.IntN(2) == 1 {
+		index = i2
+	}
+
+	for range c.maxKicks {
+		pos := c.rng.UintN(c.bucketSize)
+		fp, count = c.table.Swap(index, pos, fp, count)
+		if fp == 0 {
+			return
+		}
+
+		index = c.hash.GetAltIndex(index, fp, c.numBuckets)
+		if c.table.Insert(index, fp, count) {
+			return
+		}
+	}
+
+	c.stash = append(c.stash, countedVictim{victim: victim{index: index, fp: fp}, count: count})
+}
+
+// find locates fp for buckets i1 and i2 in the table or the stash
+func (c *Counting) find(i1, i2 uint, fp uint16) (countedSlot, bool) {
+	for _, index := range [2]uint{i1, i2} {
+		if pos, ok := c.table.Find(index, fp); ok {
+			return countedSlot{index: index, pos: pos}, true
+		}
+	}
+	for j, v := range c.stash {
+		if v.matches(i1, i2, fp) {
+			return countedSlot{stashed: true, entry: j}, true
+		}
+	}
+	return countedSlot{}, false
+}
+
+func (c *Counting) countAt(s countedSlot) uint {
+	if s.stashed {
+		return c.stash[s.entry].count
+	}
+	_, count := c.table.Get(s.index, s.pos)
+	return count
+}
+
+// setCount replaces the count of s, emptying it when count is 0
+func (c *Counting) setCount(s countedSlot, count uint) {
+	switch {
+	case s.stashed && count == 0:
+		c.stash = append(c.stash[:s.entry], c.stash[s.entry+1:]...)
+	case s.stashed:
+		c.stash[s.entry].count = count
+	case count == 0:
+		c.table.Clear(s.index, s.pos)
+	default:
+		c.table.SetCount(s.index, s.pos, count)
+	}
+}
+
+// drainStash moves stashed slots back into the table wherever one of their
+// buckets has room. Callers must hold c.mu for writing.
+func (c *Counting) drainStash() {
+	kept := c.stash[:0]
+	for _, v := range c.stash {
+		if c.table.Insert(v.index, v.fp, v.count) ||
+			c.table.Insert(c.hash.GetAltIndex(v.index, v.fp, c.numBuckets), v.fp, v.count) {
+			continue
+		}
+		kept = append(kept, v)
+	}
+	c.stash = kept
+}
+
+// Lookup checks if an item might have been added
+func (c *Counting) Lookup(item []byte) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i1, i2, fp := c.hash.GetIndices(item, c.numBuckets)
+	if c.table.Contains(i1, fp) || c.table.Contains(i2, fp) {
+		return true
+	}
+	for _, v := range c.stash {
+		if v.matches(i1, i2, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count returns how many times item was added, 0 if it is not present.
+// A result of MaxCount means the counter saturated and the true count may be higher.
+func (c *Counting) Count(item []byte) uint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i1, i2, fp := c.hash.GetIndices(item, c.numBuckets)
+	if s, found := c.find(i1, i2, fp); found {
+		return c.countAt(s)
+	}
+	return 0
+}
+
+// Delete removes one occurrence of item, freeing its slot when the count
+// drops to 0. Saturated counters are left unchanged. Returns false if the
+// item is not present.
+func (c *Counting) Delete(item []byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i1, i2, fp := c.hash.GetIndices(item, c.numBuckets)
+	s, found := c.find(i1, i2, fp)
+	if !found {
+		return false
+	}
+
+	count := c.countAt(s)
+	if count == c.table.MaxCount() {
+		return true
+	}
+	c.setCount(s, count-1)
+	if count == 1 {
+		c.numItems--
+		c.drainStash()
+	}
+	return true
+}
+
+// Distinct returns the number of distinct fingerprints stored
+func (c *Counting) Distinct() uint {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.numItems
+}
+
+// Capacity returns the number of fingerprint slots
+func (c *Counting) Capacity() uint {
+	return c.numBuckets * c.bucketSize
+}
+
+// LoadFactor returns the fraction of slots in use
+func (c *Counting) LoadFactor() float64 {
+	return float64(c.Distinct()) / float64(c.Capacity())
+}
+
+// MaxCount returns the saturation value of the counters
+func (c *Counting) MaxCount() uint {
+	return c.table.MaxCount()
+}
+
+// MemoryUsage returns the size in bytes of the fingerprint and counter tables
+func (c *Counting) MemoryUsage() uint {
+	return c.table.Size()
+}
+
+// Reset removes all items
+func (c *Counting) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.table.Reset()
+	c.stash = c.stash[:0]
+	c.numItems = 0
+}
@@ -0,0 +1,120 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+func newCounting(capacity, counterBits uint) *Counting {
+	return NewCounting(capacity, Config{
+		BucketSize:      4,
+		FingerprintBits: 16,
+		MaxKicks:        500,
+		HashStrategy:    hash.HashStrategyXXHash,
+		BatchSize:       32,
+		VictimCacheSize: 4,
+	}, counterBits)
+}
+
+// TestCountingRelocation tests that counts survive relocations and the stash
+// when the filter is filled past capacity
+func TestCountingRelocation(t *testing.T) {
+	c := newCounting(1024, 8)
+
+	// Items with the same fingerprint and buckets share a counter
+	type slotKey struct {
+		index uint
+		fp    uint16
+	}
+	keyOf := func(item []byte) slotKey {
+		i1, i2, fp := c.hash.GetIndices(item, c.numBuckets)
+		return slotKey{min(i1, i2), fp}
+	}
+
+	type added struct {
+		item []byte
+		n    uint
+	}
+	var accepted []added
+	counts := map[slotKey]uint{}
+	for i := uint(0); i < 2*c.Capacity(); i++ {
+		item := []byte(fmt.Sprintf("counted-%d", i))
+		n := i%5 + 1
+		if ok, saturated := c.InsertN(item, n); ok {
+			accepted = append(accepted, added{item, n})
+			counts[keyOf(item)] += n
+			if saturated {
+				t.Fatalf("Count %d saturated a counter with maximum %d", counts[keyOf(item)], c.MaxCount())
+			}
+		}
+	}
+	if c.Distinct() != uint(len(counts)) {
+		t.Errorf("Distinct() = %d, want %d", c.Distinct(), len(counts))
+	}
+	t.Logf("Accepted %d items, load factor %.2f, %d stashed", len(accepted), c.LoadFactor(), len(c.stash))
+
+	for _, a := range accepted {
+		if got, want := c.Count(a.item), counts[keyOf(a.item)]; got != want {
+			t.Fatalf("Count(%q) = %d, want %d", a.item, got, want)
+		}
+	}
+
+	// Deleting every occurrence frees the slots
+	for _, a := range accepted {
+		for range a.n {
+			if !c.Delete(a.item) {
+				t.Fatalf("Delete failed for %q", a.item)
+			}
+		}
+	}
+	if c.Distinct() != 0 || len(c.stash) != 0 {
+		t.Errorf("Expected empty filter, %d items, %d stashed", c.Distinct(), len(c.stash))
+	}
+	for _, a := range accepted {
+		if c.Lookup(a.item) {
+			t.Fatalf("%q still present after deleting all occurrences", a.item)
+		}
+	}
+}
+
+// TestCountingSaturation tests that a full counter is reported and pinned
+func TestCountingSaturation(t *testing.T) {
+	c := newCounting(64, 2)
+	item := []byte("saturating")
+	if c.MaxCount() != 3 {
+		t.Fatalf("MaxCount() = %d, want 3", c.MaxCount())
+	}
+
+	if ok, saturated := c.InsertN(item, 2); !ok || saturated {
+		t.Fatalf("InsertN(2) = %v, %v", ok, saturated)
+	}
+	if ok, saturated := c.InsertN(item, 1); !ok || !saturated {
+		t.Fatalf("Reaching MaxCount should saturate, got %v, %v", ok, saturated)
+	}
+	if ok, saturated := c.InsertN(item, 1); !ok || !saturated {
+		t.Fatalf("Inserting into a saturated counter should report it, got %v, %v", ok, saturated)
+	}
+
+	for range 10 {
+		if !c.Delete(item) {
+			t.Fatal("Delete of a saturated item failed")
+		}
+	}
+	if c.Count(item) != c.MaxCount() || !c.Lookup(item) {
+		t.Errorf("Saturated counter changed to %d", c.Count(item))
+	}
+
+	// A new item saturates when n alone exceeds the counter
+	if ok, saturated := c.InsertN([]byte("large"), 100); !ok || !saturated || c.Count([]byte("large")) != 3 {
+		t.Errorf("InsertN(100) = %v, %v, count %d", ok, saturated, c.Count([]byte("large")))
+	}
+
+	c.Reset()
+	if c.Distinct() != 0 || c.Lookup(item) {
+		t.Error("Filter not empty after Reset")
+	}
+}
@@ -0,0 +1,60 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidFormat is returned when serialized filter data is malformed or truncated
+	ErrInvalidFormat = errors.New("invalid serialized filter data")
+
+	// ErrUnsupportedVersion is returned when serialized data uses an unknown format version
+	ErrUnsupportedVersion = errors.New("unsupported serialized filter version")
+
+	// ErrChecksumMismatch is returned when the checksum of serialized data does not match its contents
+	ErrChecksumMismatch = errors.New("serialized filter checksum mismatch")
+
+	// ErrIncompatible is matched by IncompatibleError via errors.Is
+	ErrIncompatible = errors.New("incompatible filter")
+
+	// ErrFull is returned when fingerprints cannot be added because the filter is full
+	ErrFull = errors.New("filter is full")
+
+	// ErrReadOnly is returned when modifying a filter that is opened read-only
+	ErrReadOnly = errors.New("filter is read-only")
+
+	// ErrInvalidGrowthFactor is returned when a filter is grown by a factor that is not a power of 2 of at least 2
+	ErrInvalidGrowthFactor = errors.New("growth factor must be a power of 2 of at least 2")
+
+	// ErrGrowthLimit is returned when growing would leave no fingerprint bits or too many buckets
+	ErrGrowthLimit = errors.New("filter cannot grow further")
+
+	// ErrKeyRequired is returned when serialized data hashed with SipHash is read without its key
+	ErrKeyRequired = errors.New("serialized filter uses SipHash and needs its key")
+
+	// ErrHashRequired is returned when serialized data hashed with a custom hash function is read without it
+	ErrHashRequired = errors.New("serialized filter uses a custom hash function and needs it")
+)
+
+// IncompatibleError is returned when serialized data is loaded into a filter
+// whose hash strategy or fingerprint size differs from the one that produced
+// it, or when filters with different layouts are merged.
+// Looking up items with a different hash configuration would silently give
+// wrong answers, so the data is rejected instead.
+type IncompatibleError struct {
+	Field string // Configuration field that differs
+	Want  string // Value configured on the receiving filter
+	Got   string // Value recorded in the serialized data or the merged filter
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("incompatible filter: %s is %s, filter uses %s", e.Field, e.Got, e.Want)
+}
+
+// Is reports whether target is ErrIncompatible
+func (e *IncompatibleError) Is(target error) bool {
+	return target == ErrIncompatible
+}
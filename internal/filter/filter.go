@@ -3,74 +3,255 @@
 package filter
 
 import (
+	"math"
 	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 
 	"github.com/shaia/simdcuckoofilter/internal/bucket"
 	"github.com/shaia/simdcuckoofilter/internal/hash"
 )
 
-// simdFilter is the platform-optimized filter implementation
-type simdFilter struct {
-	buckets    []*bucket.Bucket
-	numBuckets uint
-	numItems   uint
-	maxKicks   uint
-	bucketSize uint
-	hash       hash.HashInterface
-	batchSize  uint
-	rng        *rand.Rand // Per-filter RNG for thread-safe random operations
-	mu         sync.RWMutex
+// Filter is the platform-optimized filter implementation
+type Filter struct {
+	table           bucket.Table // Contiguous fingerprint storage for all buckets
+	numBuckets      uint
+	numItems        uint
+	maxKicks        uint
+	bucketSize      uint
+	fingerprintBits uint
+	hashStrategy    hash.HashStrategy
+	hash            hash.HashInterface
+	batchSize       uint
+	victimCacheSize uint
+	setSemantics    bool                // Insert skips items whose fingerprint is already present
+	growthBits      uint                // log2 of the factor the table was grown by; see Grow
+	hashLayout      hash.Layout         // Which hash bits become the fingerprint and the bucket index
+	seed            uint64              // Hash seed; see hash.Params
+	sipKey          [16]byte            // SipHash key, zero for other strategies
+	customHash      func([]byte) uint64 // Hash function of hash.HashStrategyCustom, nil for other strategies
+	stash           []victim            // Fingerprints evicted by relocations that ran out of kicks
+	rng             *rand.Rand          // Per-filter RNG for thread-safe random operations
+	counters        insertCounters      // Insert outcomes in single-lock mode; see stats.go
+	mu              sync.RWMutex
+
+	// Concurrent mode; see striped.go. stripes is nil otherwise.
+	stripes    []stripe
+	stripeMask uint
+	view       atomic.Pointer[tableView] // table and numBuckets, readable before any lock is held
+	stashMu    sync.Mutex
+	stashLen   atomic.Int32 // len(stash), for skipping the stash without stashMu
 }
 
-func New(capacity, bucketSize, fingerprintBits, maxKicks uint, hashStrategy hash.HashStrategy, batchSize uint) (*simdFilter, error) {
+// Config holds the settings used to build a filter
+type Config struct {
+	BucketSize      uint
+	FingerprintBits uint
+	MaxKicks        uint
+	HashStrategy    hash.HashStrategy
+	BatchSize       uint
+	VictimCacheSize uint                // Capacity of the victim stash, 1 to MaxVictimCacheSize
+	LockStripes     uint                // Bucket lock stripes for concurrent mode, up to MaxLockStripes; 0 uses one filter-wide lock
+	SetSemantics    bool                // Insert behaves like InsertUnique
+	GrowthBits      uint                // Index bits taken from fingerprints by Grow, less than FingerprintBits
+	HashLayout      hash.Layout         // How hashes split into fingerprint and index; zero uses hash.LayoutDisjoint
+	Seed            uint64              // Hash seed; see hash.Params
+	SipKey          [16]byte            // Key of hash.HashStrategySipHash, ignored by other strategies
+	CustomHash      func([]byte) uint64 // Hash function of hash.HashStrategyCustom, ignored by other strategies
+}
+
+// hashParams returns the hash settings of cfg
+func (cfg Config) hashParams() hash.Params {
+	return hash.Params{Layout: cfg.HashLayout, Seed: cfg.Seed, Key: cfg.SipKey, Func: cfg.CustomHash}
+}
+
+// InsertStatus is the outcome of an insert
+type InsertStatus int
+
+const (
+	// Inserted means the item's fingerprint was stored
+	Inserted InsertStatus = iota
+
+	// Duplicate means the fingerprint was already in one of the item's
+	// buckets or the stash, so nothing was stored
+	Duplicate
+
+	// Full means there was no room for the fingerprint
+	Full
+)
+
+// InsertResult describes how an insert went
+type InsertResult struct {
+	Status InsertStatus
+
+	// Kicks is the number of resident fingerprints moved to their alternate
+	// bucket to make room
+	Kicks uint
+
+	// Displaced reports that relocation ran out of kicks and a fingerprint
+	// was moved to the victim stash, a sign that the filter is nearly full
+	Displaced bool
+}
+
+// String returns the name of the status
+func (s InsertStatus) String() string {
+	switch s {
+	case Inserted:
+		return "inserted"
+	case Duplicate:
+		return "duplicate"
+	case Full:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+func New(capacity, bucketSize, fingerprintBits, maxKicks uint, hashStrategy hash.HashStrategy, batchSize uint) (*Filter, error) {
+	return NewWithConfig(capacity, Config{
+		BucketSize:      bucketSize,
+		FingerprintBits: fingerprintBits,
+		MaxKicks:        maxKicks,
+		HashStrategy:    hashStrategy,
+		BatchSize:       batchSize,
+		VictimCacheSize: DefaultVictimCacheSize,
+	})
+}
+
+// NewWithConfig creates a filter with room for capacity fingerprints
+func NewWithConfig(capacity uint, cfg Config) (*Filter, error) {
 	// Calculate number of buckets
-	numBuckets := nextPowerOf2((capacity + bucketSize - 1) / bucketSize)
+	numBuckets := nextPowerOf2((capacity + cfg.BucketSize - 1) / cfg.BucketSize)
 	if numBuckets == 0 {
 		numBuckets = 1
 	}
 
-	// Create buckets
-	buckets := make([]*bucket.Bucket, numBuckets)
-	for i := range buckets {
-		buckets[i] = bucket.NewBucket(bucketSize)
+	return newFilter(bucket.NewTable(numBuckets, cfg.BucketSize, cfg.FingerprintBits), cfg), nil
+}
+
+// newFilter creates a filter over an existing fingerprint table.
+// The number of buckets must be a power of 2. The bucket layout is taken
+// from the table, the remaining settings from cfg.
+func newFilter(table bucket.Table, cfg Config) *Filter {
+	if cfg.HashLayout == 0 {
+		cfg.HashLayout = hash.LayoutDisjoint
+	}
+	if cfg.HashStrategy != hash.HashStrategySipHash {
+		cfg.SipKey = [16]byte{}
+	}
+	if cfg.HashStrategy != hash.HashStrategyCustom {
+		cfg.CustomHash = nil
+	}
+	hasher := hash.NewHashFunctionWithParams(cfg.HashStrategy, table.FingerprintBits(), cfg.hashParams())
+
+	f := &Filter{
+		table:           table,
+		numBuckets:      table.NumBuckets(),
+		numItems:        0,
+		maxKicks:        cfg.MaxKicks,
+		bucketSize:      table.BucketSize(),
+		fingerprintBits: table.FingerprintBits(),
+		hashStrategy:    cfg.HashStrategy,
+		hash:            hash.Grown(hasher, table.FingerprintBits(), cfg.GrowthBits),
+		batchSize:       cfg.BatchSize,
+		victimCacheSize: cfg.VictimCacheSize,
+		setSemantics:    cfg.SetSemantics,
+		growthBits:      cfg.GrowthBits,
+		hashLayout:      cfg.HashLayout,
+		seed:            cfg.Seed,
+		sipKey:          cfg.SipKey,
+		customHash:      cfg.CustomHash,
+		stash:           make([]victim, 0, cfg.VictimCacheSize),
+		rng:             rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+
+	if cfg.LockStripes > 0 {
+		n := nextPowerOf2(cfg.LockStripes)
+		f.stripes = make([]stripe, n)
+		f.stripeMask = n - 1
+		isolated := bucket.Isolated(table)
+		f.table = isolated
+		f.view.Store(&tableView{table: isolated, numBuckets: f.numBuckets})
 	}
+	return f
+}
+
+// Insert adds an item. With set semantics, an item whose fingerprint is
+// already present counts as inserted without using another slot.
+func (f *Filter) Insert(item []byte) bool {
+	return f.insertItem(item, f.setSemantics).Status != Full
+}
+
+// InsertUnique adds an item unless its fingerprint is already in one of its
+// buckets, checked with the SIMD Contains kernels, and reports the outcome
+func (f *Filter) InsertUnique(item []byte) InsertStatus {
+	return f.insertItem(item, true).Status
+}
 
-	return &simdFilter{
-		buckets:    buckets,
-		numBuckets: numBuckets,
-		numItems:   0,
-		maxKicks:   maxKicks,
-		bucketSize: bucketSize,
-		hash:       hash.NewHashFunction(hashStrategy, fingerprintBits),
-		batchSize:  batchSize,
-		rng:        rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
-	}, nil
+// InsertEx is Insert reporting the outcome, the relocations it took and
+// whether a fingerprint had to be stashed
+func (f *Filter) InsertEx(item []byte) InsertResult {
+	return f.insertItem(item, f.setSemantics)
 }
 
-func (f *simdFilter) Insert(item []byte) bool {
+func (f *Filter) insertItem(item []byte, unique bool) InsertResult {
+	if f.stripes != nil {
+		return f.insertStriped(f.itemIndices(item), unique)
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	i1, i2, fp := f.hash.GetIndices(item, f.numBuckets)
+	return f.insert(i1, i2, fp, unique)
+}
+
+// insert stores fp in bucket i1 or i2, relocating resident fingerprints if
+// both are full. If unique is set, fp is not stored again if present.
+// The outcome is recorded for Stats. Callers must hold f.mu for writing.
+func (f *Filter) insert(i1, i2 uint, fp uint16, unique bool) InsertResult {
+	r := f.insertSlot(i1, i2, fp, unique)
+	f.counters.record(r)
+	return r
+}
+
+// insertSlot is insert without recording the outcome
+func (f *Filter) insertSlot(i1, i2 uint, fp uint16, unique bool) InsertResult {
+	f.drainStash()
+
+	if unique && f.contains(i1, i2, fp) {
+		return InsertResult{Status: Duplicate}
+	}
 
 	// Try first bucket
-	if f.buckets[i1].Insert(fp) {
+	if f.table.Insert(i1, fp) {
 		f.numItems++
-		return true
+		return InsertResult{Status: Inserted}
 	}
 
 	// Try second bucket
-	if f.buckets[i2].Insert(fp) {
+	if f.table.Insert(i2, fp) {
 		f.numItems++
-		return true
+		return InsertResult{Status: Inserted}
 	}
 
-	// Both full, need to relocate
-	return f.relocate(i1, i2, fp)
+	// Both full. A relocation that runs out of kicks leaves one fingerprint
+	// homeless, so only start one if the stash can take it.
+	if uint(len(f.stash)) == f.victimCacheSize {
+		return InsertResult{Status: Full}
+	}
+
+	kicks, displaced := f.relocate(i1, i2, fp)
+	f.numItems++
+	return InsertResult{Status: Inserted, Kicks: kicks, Displaced: displaced}
 }
 
-func (f *simdFilter) relocate(i1, i2 uint, fp uint16) bool {
+// relocate places fp into bucket i1 or i2 by evicting resident fingerprints
+// to their alternate buckets. If no free slot is found within maxKicks, the
+// fingerprint left over is moved to the stash, so no item is ever lost.
+// The caller must ensure the stash has room. It returns the number of
+// fingerprints evicted and whether one was stashed.
+func (f *Filter) relocate(i1, i2 uint, fp uint16) (kicks uint, displaced bool) {
 	// Start from random bucket
 	index := i1
 	if f.rng.IntN(2) == 1 {
@@ -84,11 +265,10 @@ func (f *simdFilter) relocate(i1, i2 uint, fp uint16) bool {
 		pos := uint(f.rng.IntN(int(f.bucketSize)))
 
 		// Swap the fingerprint at the random position
-		oldFp := f.buckets[index].Swap(pos, currentFp)
+		oldFp := f.table.Swap(index, pos, currentFp)
 		if oldFp == 0 {
 			// Found an empty slot
-			f.numItems++
-			return true
+			return i, false
 		}
 
 		// Continue with the evicted fingerprint
@@ -98,13 +278,13 @@ func (f *simdFilter) relocate(i1, i2 uint, fp uint16) bool {
 		index = f.hash.GetAltIndex(index, currentFp, f.numBuckets)
 
 		// Try to insert the evicted fingerprint into its alternative bucket
-		if f.buckets[index].Insert(currentFp) {
-			f.numItems++
-			return true
+		if f.table.Insert(index, currentFp) {
+			return i + 1, false
 		}
 	}
 
-	return false
+	f.stash = append(f.stash, victim{index: index, fp: currentFp})
+	return f.maxKicks, true
 }
 
 // Lookup is implemented in platform-specific files:
@@ -113,32 +293,44 @@ func (f *simdFilter) relocate(i1, i2 uint, fp uint16) bool {
 
 // LookupBatch is implemented in platform-specific files for optimized batch processing
 
-func (f *simdFilter) Delete(item []byte) bool {
+func (f *Filter) Delete(item []byte) bool {
+	if f.stripes != nil {
+		return f.deleteStriped(f.itemIndices(item))
+	}
+
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
 	i1, i2, fp := f.hash.GetIndices(item, f.numBuckets)
+	return f.delete(i1, i2, fp)
+}
 
-	if f.buckets[i1].Remove(fp) {
-		f.numItems--
-		return true
+// delete removes fp from bucket i1 or i2 or the stash.
+// Callers must hold f.mu for writing.
+func (f *Filter) delete(i1, i2 uint, fp uint16) bool {
+	if !f.table.Remove(i1, fp) && !f.table.Remove(i2, fp) && !f.stashRemove(i1, i2, fp) {
+		return false
 	}
 
-	if f.buckets[i2].Remove(fp) {
-		f.numItems--
-		return true
-	}
+	f.numItems--
+	f.drainStash()
+	return true
+}
 
-	return false
+// contains reports whether fp is stored for buckets i1 and i2.
+// Callers must hold f.mu.
+func (f *Filter) contains(i1, i2 uint, fp uint16) bool {
+	return f.table.Contains(i1, fp) || f.table.Contains(i2, fp) ||
+		stashContains(f.stash, i1, i2, fp)
 }
 
-func (f *simdFilter) Count() uint {
+func (f *Filter) Count() uint {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-	return f.numItems
+	return f.count()
 }
 
-func (f *simdFilter) LoadFactor() float64 {
+func (f *Filter) LoadFactor() float64 {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
@@ -147,25 +339,106 @@ func (f *simdFilter) LoadFactor() float64 {
 		return 0
 	}
 
-	return float64(f.numItems) / float64(totalSlots)
+	return float64(f.count()) / float64(totalSlots)
 }
 
-func (f *simdFilter) Capacity() uint {
+func (f *Filter) Capacity() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	return f.numBuckets * f.bucketSize
 }
 
-func (f *simdFilter) Reset() {
-	f.mu.Lock()
-	defer f.mu.Unlock()
+// BucketSize returns the number of fingerprints per bucket
+func (f *Filter) BucketSize() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.bucketSize
+}
 
-	for _, b := range f.buckets {
-		b.Reset()
+// FingerprintBits returns the number of bits per fingerprint
+func (f *Filter) FingerprintBits() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.fingerprintBits
+}
+
+// HashStrategy returns the hash function used to derive fingerprints and indices
+func (f *Filter) HashStrategy() hash.HashStrategy {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.hashStrategy
+}
+
+// HashLayout returns how item hashes are split into fingerprints and bucket
+// indices. Filters restored from data written before format version 3 use
+// hash.LayoutLowBits.
+func (f *Filter) HashLayout() hash.Layout {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.hashLayout
+}
+
+// HashParams returns the layout, seed, SipHash key and custom hash function
+// items are hashed with
+func (f *Filter) HashParams() hash.Params {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return hash.Params{Layout: f.hashLayout, Seed: f.seed, Key: f.sipKey, Func: f.customHash}
+}
+
+// GrowthFactor returns how many times the filter has been enlarged by Grow,
+// as a multiple of its original bucket count
+func (f *Filter) GrowthFactor() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return 1 << f.growthBits
+}
+
+// MaxKicks returns the number of relocations an insert attempts before stashing
+func (f *Filter) MaxKicks() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.maxKicks
+}
+
+// FalsePositiveRate returns the predicted worst-case false positive rate.
+// Each doubling by Grow takes one bit from the fingerprint size.
+func (f *Filter) FalsePositiveRate() float64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return FalsePositiveBound(f.bucketSize, f.fingerprintBits-f.growthBits)
+}
+
+// FalsePositiveBound returns the standard upper bound on the false positive
+// rate of a cuckoo filter, 2b/2^f: a lookup compares its fingerprint against
+// the 2b slots of two buckets, each matching with probability 1/2^f
+func FalsePositiveBound(bucketSize, fingerprintBits uint) float64 {
+	return min(2*float64(bucketSize)/math.Exp2(float64(fingerprintBits)), 1)
+}
+
+// MemoryUsage returns the size in bytes of the fingerprint table
+func (f *Filter) MemoryUsage() uint {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return uint(len(f.table.Bytes()))
+}
+
+func (f *Filter) Reset() {
+	f.lockAll()
+	defer f.unlockAll()
+
+	f.table.Reset()
+	f.stash = f.stash[:0]
+	f.stashLen.Store(0)
+	f.setCount(0)
+	f.counters.reset()
+	for i := range f.stripes {
+		f.stripes[i].counters.reset()
 	}
-	f.numItems = 0
 }
 
 // Batch operations
-func (f *simdFilter) InsertBatch(items [][]byte) []bool {
+func (f *Filter) InsertBatch(items [][]byte) []bool {
 	results := make([]bool, len(items))
 	for i, item := range items {
 		results[i] = f.Insert(item)
@@ -173,7 +446,16 @@ func (f *simdFilter) InsertBatch(items [][]byte) []bool {
 	return results
 }
 
-func (f *simdFilter) DeleteBatch(items [][]byte) []bool {
+// InsertBatchEx is InsertBatch reporting an InsertResult per item
+func (f *Filter) InsertBatchEx(items [][]byte) []InsertResult {
+	results := make([]InsertResult, len(items))
+	for i, item := range items {
+		results[i] = f.InsertEx(item)
+	}
+	return results
+}
+
+func (f *Filter) DeleteBatch(items [][]byte) []bool {
 	results := make([]bool, len(items))
 	for i, item := range items {
 		results[i] = f.Delete(item)
@@ -181,6 +463,6 @@ func (f *simdFilter) DeleteBatch(items [][]byte) []bool {
 	return results
 }
 
-func (f *simdFilter) OptimalBatchSize() int {
+func (f *Filter) OptimalBatchSize() int {
 	return int(f.batchSize)
 }
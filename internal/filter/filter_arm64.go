@@ -4,18 +4,27 @@
 package filter
 
 // Lookup uses scalar fallback (NEON TODO)
-func (f *simdFilter) Lookup(item []byte) bool {
+func (f *Filter) Lookup(item []byte) bool {
+	if f.stripes != nil {
+		return f.lookupStriped(f.itemIndices(item))
+	}
+
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
 	i1, i2, fp := f.hash.GetIndices(item, f.numBuckets)
 
 	// Use NEON-optimized lookup through bucket's Contains method
-	return f.buckets[i1].Contains(fp) || f.buckets[i2].Contains(fp)
+	return f.table.Contains(i1, fp) || f.table.Contains(i2, fp) ||
+		stashContains(f.stash, i1, i2, fp)
 }
 
 // LookupBatch uses scalar fallback with batch hashing (NEON TODO)
-func (f *simdFilter) LookupBatch(items [][]byte) []bool {
+func (f *Filter) LookupBatch(items [][]byte) []bool {
+	if f.stripes != nil {
+		return f.lookupBatchStriped(items)
+	}
+
 	results := make([]bool, len(items))
 
 	f.mu.RLock()
@@ -26,7 +35,8 @@ func (f *simdFilter) LookupBatch(items [][]byte) []bool {
 
 	// Batch process lookups with NEON optimizations
 	for i, hr := range hashResults {
-		results[i] = f.buckets[hr.I1].Contains(hr.Fp) || f.buckets[hr.I2].Contains(hr.Fp)
+		results[i] = f.table.Contains(hr.I1, hr.Fp) || f.table.Contains(hr.I2, hr.Fp) ||
+			stashContains(f.stash, hr.I1, hr.I2, hr.Fp)
 	}
 
 	return results
@@ -160,8 +160,8 @@ func TestFilterBucketDistribution(t *testing.T) {
 
 	// Count non-empty buckets
 	nonEmptyBuckets := 0
-	for _, b := range f.buckets {
-		if b.Count() > 0 {
+	for i := uint(0); i < f.numBuckets; i++ {
+		if f.table.Count(i) > 0 {
 			nonEmptyBuckets++
 		}
 	}
@@ -505,7 +505,7 @@ func TestFilterRNGInitialization(t *testing.T) {
 // This test verifies that per-filter RNG eliminates the global mutex contention
 func TestFilterConcurrentMultipleFilters(t *testing.T) {
 	numFilters := 10
-	filters := make([]*simdFilter, numFilters)
+	filters := make([]*Filter, numFilters)
 
 	// Create multiple filters
 	for i := 0; i < numFilters; i++ {
@@ -522,7 +522,7 @@ func TestFilterConcurrentMultipleFilters(t *testing.T) {
 	// Concurrently insert into all filters
 	for filterID, filter := range filters {
 		wg.Add(1)
-		go func(fid int, f *simdFilter) {
+		go func(fid int, f *Filter) {
 			defer wg.Done()
 			for i := 0; i < itemsPerFilter; i++ {
 				item := []byte(fmt.Sprintf("filter-%d-item-%d", fid, i))
@@ -0,0 +1,76 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// Grow returns a copy of f with factor times as many buckets, holding the
+// same items. factor must be a power of 2 of at least 2. f is not modified.
+//
+// The original items aren't needed: the grown table is split into factor
+// segments of f's size, and each fingerprint moves from its bucket in f to
+// the same bucket of the segment selected by its top log2(factor) bits (see
+// hash.Grown). Lookups and inserts find items the same way. Fingerprints in
+// a segment share those bits, so each doubling costs one bit of fingerprint:
+// at the same load factor, the false positive rate doubles. A filter can
+// grow until one fingerprint bit is left.
+func (f *Filter) Grow(factor uint) (*Filter, error) {
+	if factor < 2 || factor&(factor-1) != 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidGrowthFactor, factor)
+	}
+
+	f.mu.RLock()
+	cfg := Config{
+		BucketSize:      f.bucketSize,
+		FingerprintBits: f.fingerprintBits,
+		MaxKicks:        f.maxKicks,
+		HashStrategy:    f.hashStrategy,
+		BatchSize:       f.batchSize,
+		VictimCacheSize: f.victimCacheSize,
+		LockStripes:     uint(len(f.stripes)),
+		SetSemantics:    f.setSemantics,
+		GrowthBits:      f.growthBits + uint(bits.TrailingZeros(factor)),
+		HashLayout:      f.hashLayout,
+		Seed:            f.seed,
+		SipKey:          f.sipKey,
+		CustomHash:      f.customHash,
+	}
+	f.mu.RUnlock()
+
+	if cfg.GrowthBits >= cfg.FingerprintBits {
+		return nil, fmt.Errorf("%w: growing by %d leaves no fingerprint bits", ErrGrowthLimit, factor)
+	}
+
+	layout, entries := f.fingerprints()
+	if layout.numBuckets > maxBuckets/factor {
+		return nil, fmt.Errorf("%w: %d buckets", ErrGrowthLimit, layout.numBuckets*factor)
+	}
+
+	// A bucket of the grown table only receives fingerprints from one bucket
+	// of f, so they all fit. Stashed ones go to the stash if both of their
+	// buckets are still full.
+	base := layout.numBuckets >> layout.growthBits
+	numBuckets := layout.numBuckets * factor
+	// Fill g.table rather than the table passed to newFilter: with lock
+	// stripes, newFilter may have replaced it with an isolated copy.
+	g := newFilter(bucket.NewTable(numBuckets, cfg.BucketSize, cfg.FingerprintBits), cfg)
+	table := g.table
+	var stash []victim
+	for _, v := range entries {
+		i := hash.GrownIndex(v.index&(base-1), v.fp, base, cfg.FingerprintBits, cfg.GrowthBits)
+		if !table.Insert(i, v.fp) && !table.Insert(g.hash.GetAltIndex(i, v.fp, numBuckets), v.fp) {
+			stash = append(stash, victim{index: i, fp: v.fp})
+		}
+	}
+
+	g.stash = append(g.stash, stash...)
+	g.stashLen.Store(int32(len(stash)))
+	g.setCount(uint(len(entries)))
+	return g, nil
+}
@@ -0,0 +1,218 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// TestGrow tests that a grown filter keeps every item and takes new ones
+func TestGrow(t *testing.T) {
+	strategies := []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash}
+
+	for _, strategy := range strategies {
+		for _, stripes := range []uint{0, 4} {
+			t.Run(fmt.Sprintf("%s/Stripes%d", strategy, stripes), func(t *testing.T) {
+				f, _ := NewWithConfig(4096, Config{
+					BucketSize:      4,
+					FingerprintBits: 16,
+					MaxKicks:        500,
+					HashStrategy:    strategy,
+					BatchSize:       32,
+					VictimCacheSize: 4,
+					LockStripes:     stripes,
+				})
+				old := insertItems(t, f, "old", 1000)
+				before, _ := f.MarshalBinary()
+
+				g, err := f.Grow(4)
+				if err != nil {
+					t.Fatalf("Grow failed: %v", err)
+				}
+				if g.Capacity() != 4*f.Capacity() || g.GrowthFactor() != 4 || g.Count() != 1000 {
+					t.Fatalf("Grown filter has capacity %d, growth factor %d, count %d",
+						g.Capacity(), g.GrowthFactor(), g.Count())
+				}
+				if after, _ := f.MarshalBinary(); !bytes.Equal(before, after) {
+					t.Error("Grow modified the source filter")
+				}
+				for _, item := range old {
+					if !g.Lookup(item) {
+						t.Fatalf("False negative for %q after Grow", item)
+					}
+				}
+				if found := g.LookupBatch(old); len(found) != len(old) {
+					t.Fatalf("LookupBatch returned %d results", len(found))
+				}
+
+				added := insertItems(t, g, "new", 2000)
+				for _, item := range append(old, added...) {
+					if !g.Delete(item) {
+						t.Fatalf("Delete failed for %q", item)
+					}
+				}
+				if g.Count() != 0 {
+					t.Errorf("Count() = %d after deleting everything", g.Count())
+				}
+			})
+		}
+	}
+}
+
+// TestGrowStripedPacked tests Grow with lock stripes on tiny bit-packed
+// tables, which newFilter stores in an isolated copy
+func TestGrowStripedPacked(t *testing.T) {
+	for _, bucketSize := range []uint{1, 2, 3} {
+		for _, bits := range []uint{5, 7, 9, 12} {
+			for _, capacity := range []uint{4, 16, 64} {
+				t.Run(fmt.Sprintf("Bucket%d/Bits%d/Capacity%d", bucketSize, bits, capacity), func(t *testing.T) {
+					f, err := NewWithConfig(capacity, Config{
+						BucketSize:      bucketSize,
+						FingerprintBits: bits,
+						MaxKicks:        50,
+						HashStrategy:    hash.HashStrategyXXHash,
+						BatchSize:       32,
+						VictimCacheSize: 4,
+						LockStripes:     4,
+					})
+					if err != nil {
+						t.Fatalf("NewWithConfig failed: %v", err)
+					}
+					var items [][]byte
+					for i := uint(0); i < capacity; i++ {
+						item := []byte(fmt.Sprintf("packed-%d", i))
+						if f.Insert(item) {
+							items = append(items, item)
+						}
+					}
+
+					g, err := f.Grow(2)
+					if err != nil {
+						t.Fatalf("Grow failed: %v", err)
+					}
+					if g.Count() != uint(len(items)) {
+						t.Errorf("Count() = %d after Grow, want %d", g.Count(), len(items))
+					}
+					for _, item := range items {
+						if !g.Lookup(item) {
+							t.Errorf("False negative for %q after Grow", item)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestGrowStash tests that stashed fingerprints are kept by Grow
+func TestGrowStash(t *testing.T) {
+	f := mustNew(t, 256, 4, 16, hash.HashStrategyXXHash)
+	accepted := fillPastCapacity(f, "stash")
+	if len(f.stash) == 0 {
+		t.Fatal("Expected stashed fingerprints after overfilling")
+	}
+
+	g, err := f.Grow(2)
+	if err != nil {
+		t.Fatalf("Grow failed: %v", err)
+	}
+	if g.Count() != f.Count() {
+		t.Errorf("Count() = %d, want %d", g.Count(), f.Count())
+	}
+	for _, item := range accepted {
+		if !g.Lookup(item) {
+			t.Fatalf("False negative for %q after Grow", item)
+		}
+	}
+}
+
+// TestGrowRepeated tests growing a filter several times as it fills
+func TestGrowRepeated(t *testing.T) {
+	f := mustNew(t, 512, 4, 16, hash.HashStrategyCRC32)
+	var items [][]byte
+	fpr := f.FalsePositiveRate()
+
+	for round := range 4 {
+		items = append(items, insertItems(t, f, fmt.Sprintf("round%d", round), int(f.Capacity())/4)...)
+		g, err := f.Grow(2)
+		if err != nil {
+			t.Fatalf("Grow %d failed: %v", round, err)
+		}
+		f = g
+
+		if f.GrowthFactor() != 2<<round {
+			t.Errorf("GrowthFactor() = %d, want %d", f.GrowthFactor(), 2<<round)
+		}
+		fpr *= 2
+		if got := f.FalsePositiveRate(); got != fpr {
+			t.Errorf("FalsePositiveRate() = %v after %d doublings, want %v", got, round+1, fpr)
+		}
+		for _, item := range items {
+			if !f.Lookup(item) {
+				t.Fatalf("False negative for %q after %d doublings", item, round+1)
+			}
+		}
+	}
+}
+
+// TestGrowSerialization tests that grown filters round-trip and are only
+// loaded into filters with the same growth factor
+func TestGrowSerialization(t *testing.T) {
+	f := mustNew(t, 4096, 4, 16, hash.HashStrategyXXHash)
+	items := insertItems(t, f, "serial", 1000)
+	g, _ := f.Grow(2)
+
+	data, _ := g.MarshalBinary()
+	if data[4] != 3 || data[40] != 1 {
+		t.Errorf("Grown filter written as version %d with %d growth bits", data[4], data[40])
+	}
+
+	decoded, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded.GrowthFactor() != 2 {
+		t.Errorf("Decoded growth factor %d, want 2", decoded.GrowthFactor())
+	}
+	for _, item := range items {
+		if !decoded.Lookup(item) {
+			t.Fatalf("False negative for %q after decoding", item)
+		}
+	}
+
+	// Same bucket count, but items are placed differently
+	same := mustNew(t, 8192, 4, 16, hash.HashStrategyXXHash)
+	var incompatible *IncompatibleError
+	if _, err := same.ReadFrom(bytes.NewReader(data)); !errors.As(err, &incompatible) || incompatible.Field != "growth factor" {
+		t.Errorf("Expected growth factor IncompatibleError, got %v", err)
+	}
+	if err := same.Merge(g); !errors.As(err, &incompatible) || incompatible.Field != "growth factor" {
+		t.Errorf("Expected growth factor IncompatibleError from Merge, got %v", err)
+	}
+	grown, _ := mustNew(t, 4096, 4, 16, hash.HashStrategyXXHash).Grow(2)
+	if _, err := grown.ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Errorf("ReadFrom into a filter with the same growth failed: %v", err)
+	}
+}
+
+// TestGrowInvalid tests that bad growth factors and exhausted fingerprints are rejected
+func TestGrowInvalid(t *testing.T) {
+	f := mustNew(t, 1024, 4, 8, hash.HashStrategyXXHash)
+
+	for _, factor := range []uint{0, 1, 3, 6} {
+		if _, err := f.Grow(factor); !errors.Is(err, ErrInvalidGrowthFactor) {
+			t.Errorf("Grow(%d): expected ErrInvalidGrowthFactor, got %v", factor, err)
+		}
+	}
+	if _, err := f.Grow(256); !errors.Is(err, ErrGrowthLimit) {
+		t.Errorf("Grow(256) with 8-bit fingerprints: expected ErrGrowthLimit, got %v", err)
+	}
+	if _, err := f.Grow(128); err != nil {
+		t.Errorf("Grow(128) with 8-bit fingerprints failed: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+//go:build amd64 || arm64
+
+package filter
+
+import "github.com/shaia/simdcuckoofilter/internal/hash"
+
+// RouteBuckets is the bucket count for hashing items ahead of time, as a
+// sharded filter does to route them. It is the largest power of 2 that every
+// hash strategy supports (CRC32C hashes are 32 bits).
+const RouteBuckets = 1 << 31
+
+// IndexBuckets is the bucket count for hashing items ahead of time without
+// losing index bits: it is the largest table a filter can have, so results
+// hashed with it address every bucket of any filter.
+const IndexBuckets = maxBuckets
+
+// Hashed operations
+//
+// The *Hashed methods take the result of hashing an item instead of the item
+// itself, so items hashed once by the caller are not hashed again. Results
+// must come from a hash with the filter's strategy and fingerprint size.
+//
+// Every hash strategy computes i1 as hash mod numBuckets, so for a power-of-2
+// table the filter's own i1 is I1 mod numBuckets, in the segment given by Fp
+// for grown filters. i2 is derived from it and Fp with GetAltIndex.
+//
+// This holds as long as the result was hashed with at least as many buckets
+// as the table has. Results hashed with RouteBuckets buckets, as a sharded
+// filter routes items, are only valid for tables of at most RouteBuckets
+// buckets. Results hashed with IndexBuckets, as HashValue and TypedFilter
+// produce, are valid for tables of any size.
+
+// HashValue returns the result of hashing an item whose 64-bit hash is h,
+// for the *Hashed methods. h is split with the filter's layout, as every hash
+// strategy splits the hash of an item, so with hash.HashStrategyCustom,
+// HashValue(fn(item)) is the result for item.
+//
+// I1 is split with IndexBuckets buckets, so it keeps the index bits of every
+// table a filter can have. I2 is left zero, since the *Hashed methods derive
+// it from I1 and Fp.
+func (f *Filter) HashValue(h uint64) hash.HashResult {
+	i1, fp := f.hashLayout.Split(h, f.fingerprintBits, IndexBuckets)
+	return hash.HashResult{I1: i1, Fp: fp}
+}
+
+// indices returns the buckets of hr for a table of nb buckets
+func (f *Filter) indices(hr hash.HashResult, nb uint) (i1, i2 uint) {
+	base := nb >> f.growthBits
+	i1 = hash.GrownIndex(hr.I1&(base-1), hr.Fp, base, f.fingerprintBits, f.growthBits)
+	return i1, f.hash.GetAltIndex(i1, hr.Fp, nb)
+}
+
+// hashedIndices returns the indexFunc of hr
+func (f *Filter) hashedIndices(hr hash.HashResult) indexFunc {
+	return func(nb uint) (uint, uint, uint16) {
+		i1, i2 := f.indices(hr, nb)
+		return i1, i2, hr.Fp
+	}
+}
+
+// InsertHashed is Insert for an item hashed with RouteBuckets buckets
+func (f *Filter) InsertHashed(hr hash.HashResult) bool {
+	if f.stripes != nil {
+		return f.insertStriped(f.hashedIndices(hr), f.setSemantics).Status != Full
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i1, i2 := f.indices(hr, f.numBuckets)
+	return f.insert(i1, i2, hr.Fp, f.setSemantics).Status != Full
+}
+
+// LookupHashed is Lookup for an item hashed with RouteBuckets buckets
+func (f *Filter) LookupHashed(hr hash.HashResult) bool {
+	if f.stripes != nil {
+		return f.lookupStriped(f.hashedIndices(hr))
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	i1, i2 := f.indices(hr, f.numBuckets)
+	return f.contains(i1, i2, hr.Fp)
+}
+
+// DeleteHashed is Delete for an item hashed with RouteBuckets buckets
+func (f *Filter) DeleteHashed(hr hash.HashResult) bool {
+	if f.stripes != nil {
+		return f.deleteStriped(f.hashedIndices(hr))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i1, i2 := f.indices(hr, f.numBuckets)
+	return f.delete(i1, i2, hr.Fp)
+}
+
+// InsertBatchHashed inserts items hashed with RouteBuckets buckets under a
+// single lock acquisition
+func (f *Filter) InsertBatchHashed(hrs []hash.HashResult) []bool {
+	results := make([]bool, len(hrs))
+	if f.stripes != nil {
+		for i, hr := range hrs {
+			results[i] = f.insertStriped(f.hashedIndices(hr), f.setSemantics).Status != Full
+		}
+		return results
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, hr := range hrs {
+		i1, i2 := f.indices(hr, f.numBuckets)
+		results[i] = f.insert(i1, i2, hr.Fp, f.setSemantics).Status != Full
+	}
+	return results
+}
+
+// LookupBatchHashed checks items hashed with RouteBuckets buckets under a
+// single lock acquisition
+func (f *Filter) LookupBatchHashed(hrs []hash.HashResult) []bool {
+	results := make([]bool, len(hrs))
+	if f.stripes != nil {
+		for i, hr := range hrs {
+			results[i] = f.lookupStriped(f.hashedIndices(hr))
+		}
+		return results
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for i, hr := range hrs {
+		i1, i2 := f.indices(hr, f.numBuckets)
+		results[i] = f.contains(i1, i2, hr.Fp)
+	}
+	return results
+}
+
+// DeleteBatchHashed deletes items hashed with RouteBuckets buckets under a
+// single lock acquisition
+func (f *Filter) DeleteBatchHashed(hrs []hash.HashResult) []bool {
+	results := make([]bool, len(hrs))
+	if f.stripes != nil {
+		for i, hr := range hrs {
+			results[i] = f.deleteStriped(f.hashedIndices(hr))
+		}
+		return results
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, hr := range hrs {
+		i1, i2 := f.indices(hr, f.numBuckets)
+		results[i] = f.delete(i1, i2, hr.Fp)
+	}
+	return results
+}
@@ -0,0 +1,162 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// TestHashedOperations tests that hashed operations place items exactly
+// where the item operations look for them, for every strategy and mode
+func TestHashedOperations(t *testing.T) {
+	strategies := []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash}
+
+	for _, strategy := range strategies {
+		for _, stripes := range []uint{0, 8} {
+			t.Run(fmt.Sprintf("%s/Stripes%d", strategy, stripes), func(t *testing.T) {
+				f, err := NewWithConfig(4096, Config{
+					BucketSize:      4,
+					FingerprintBits: 12,
+					MaxKicks:        500,
+					HashStrategy:    strategy,
+					BatchSize:       32,
+					VictimCacheSize: 4,
+					LockStripes:     stripes,
+				})
+				if err != nil {
+					t.Fatalf("NewWithConfig failed: %v", err)
+				}
+				router := hash.NewHashFunction(strategy, 12)
+
+				items := make([][]byte, 2000)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("hashed-%d", i))
+				}
+				hrs := router.GetIndicesBatch(items, RouteBuckets)
+
+				// Hashed inserts are found by item lookups
+				for i, ok := range f.InsertBatchHashed(hrs[:1000]) {
+					if !ok {
+						t.Fatalf("InsertBatchHashed failed for item %d", i)
+					}
+				}
+				for i, item := range items[:1000] {
+					if !f.Lookup(item) {
+						t.Fatalf("Lookup missed hashed insert %d", i)
+					}
+				}
+
+				// Item inserts are found by hashed lookups
+				for i, item := range items[1000:] {
+					if !f.Insert(item) {
+						t.Fatalf("Insert failed for item %d", 1000+i)
+					}
+				}
+				for i, found := range f.LookupBatchHashed(hrs) {
+					if !found || !f.LookupHashed(hrs[i]) {
+						t.Fatalf("Hashed lookup missed item %d", i)
+					}
+				}
+
+				for i, ok := range f.DeleteBatchHashed(hrs[:500]) {
+					if !ok {
+						t.Fatalf("DeleteBatchHashed failed for item %d", i)
+					}
+				}
+				for i := 500; i < len(items); i++ {
+					if !f.DeleteHashed(hrs[i]) {
+						t.Fatalf("DeleteHashed failed for item %d", i)
+					}
+				}
+				if f.Count() != 0 {
+					t.Errorf("Count() = %d after deleting everything", f.Count())
+				}
+				if !f.InsertHashed(hrs[0]) || !f.Delete(items[0]) {
+					t.Error("Delete missed a hashed insert")
+				}
+			})
+		}
+	}
+}
+
+// TestHashValue tests that items given by their 64-bit hash are placed as
+// the custom hash strategy places the items, including in grown filters
+func TestHashValue(t *testing.T) {
+	fn := func(item []byte) uint64 { return hash.SipHash64([16]byte{3}, item) }
+
+	for _, stripes := range []uint{0, 8} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			f, err := NewWithConfig(4096, Config{
+				BucketSize:      4,
+				FingerprintBits: 12,
+				MaxKicks:        500,
+				HashStrategy:    hash.HashStrategyCustom,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+				LockStripes:     stripes,
+				CustomHash:      fn,
+			})
+			if err != nil {
+				t.Fatalf("NewWithConfig failed: %v", err)
+			}
+
+			items := make([][]byte, 2000)
+			for i := range items {
+				items[i] = []byte(fmt.Sprintf("value-%d", i))
+			}
+			for i, item := range items[:1000] {
+				if !f.InsertHashed(f.HashValue(fn(item))) {
+					t.Fatalf("Insert of hash value %d failed", i)
+				}
+			}
+			for i, ok := range f.InsertBatch(items[1000:]) {
+				if !ok {
+					t.Fatalf("InsertBatch failed for item %d", 1000+i)
+				}
+			}
+
+			g, err := f.Grow(2)
+			if err != nil {
+				t.Fatalf("Grow failed: %v", err)
+			}
+			for _, filter := range []*Filter{f, g} {
+				for i, item := range items {
+					if !filter.Lookup(item) || !filter.LookupHashed(filter.HashValue(fn(item))) {
+						t.Fatalf("Growth factor %d: item %d not found", filter.GrowthFactor(), i)
+					}
+				}
+			}
+			for i, item := range items {
+				if !g.DeleteHashed(g.HashValue(fn(item))) {
+					t.Fatalf("Delete of hash value %d failed", i)
+				}
+			}
+			if g.Count() != 0 {
+				t.Errorf("Count() = %d after deleting everything", g.Count())
+			}
+		})
+	}
+}
+
+// TestHashValueLargeTables tests that HashValue keeps the index bits of
+// tables larger than RouteBuckets, which are too large to create here
+func TestHashValueLargeTables(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, layout := range []hash.Layout{hash.LayoutLowBits, hash.LayoutDisjoint} {
+		f := &Filter{fingerprintBits: 12, hashLayout: layout}
+		for _, nb := range []uint{RouteBuckets, RouteBuckets << 1, 1 << 40, maxBuckets} {
+			for range 1000 {
+				h := rng.Uint64()
+				i1, fp := layout.Split(h, f.fingerprintBits, nb)
+				if hr := f.HashValue(h); hr.I1&(nb-1) != i1 || hr.Fp != fp {
+					t.Fatalf("%v, %d buckets, hash %#x: HashValue gives bucket %d, fingerprint %d; want %d, %d",
+						layout, nb, h, hr.I1&(nb-1), hr.Fp, i1, fp)
+				}
+			}
+		}
+	}
+}
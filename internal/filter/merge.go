@@ -0,0 +1,132 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"fmt"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// Merge adds every fingerprint stored in other to f, so that f holds the
+// items of both filters. Fingerprints are reinserted from their buckets,
+// relocating with GetAltIndex as inserts do, without the original items.
+// With set semantics, fingerprints already present in f are skipped.
+//
+// The filters must have the same number of buckets, bucket size,
+// fingerprint size and hash configuration, including seed, SipHash key and
+// custom hash function (compared by their key checks), or Merge returns an
+// *IncompatibleError.
+// If f runs out of room, Merge returns an error matching ErrFull and f is
+// left unchanged.
+func (f *Filter) Merge(other *Filter) error {
+	// Copy the fingerprints out first, so the two filters are never locked
+	// together and concurrent merges in both directions can't deadlock
+	layout, entries := other.fingerprints()
+
+	f.lockAll()
+	defer f.unlockAll()
+
+	if err := f.checkLayout(layout); err != nil {
+		return err
+	}
+
+	// Insert into a copy, which replaces the table only if everything fits
+	data := bucket.AlignedBytes(uint(len(f.table.Bytes())))
+	copy(data, f.table.Bytes())
+	merged := &Filter{
+		table:           bucket.NewTableFrom(data, f.numBuckets, f.bucketSize, f.fingerprintBits),
+		numBuckets:      f.numBuckets,
+		numItems:        f.count(),
+		maxKicks:        f.maxKicks,
+		bucketSize:      f.bucketSize,
+		hash:            f.hash,
+		victimCacheSize: f.victimCacheSize,
+		stash:           append(make([]victim, 0, f.victimCacheSize), f.stash...),
+		rng:             f.rng,
+	}
+	for n, v := range entries {
+		i2 := f.hash.GetAltIndex(v.index, v.fp, f.numBuckets)
+		if merged.insert(v.index, i2, v.fp, f.setSemantics).Status == Full {
+			return fmt.Errorf("%w: merged %d of %d fingerprints", ErrFull, n, len(entries))
+		}
+	}
+
+	f.table = merged.table
+	if f.stripes != nil {
+		isolated := bucket.Isolated(merged.table)
+		f.table = isolated
+		f.view.Store(&tableView{table: isolated, numBuckets: f.numBuckets})
+	}
+	f.stash = merged.stash
+	f.stashLen.Store(int32(len(merged.stash)))
+	f.setCount(merged.numItems)
+	return nil
+}
+
+// tableLayout is the configuration that determines where a fingerprint is stored
+type tableLayout struct {
+	numBuckets      uint
+	bucketSize      uint
+	fingerprintBits uint
+	growthBits      uint
+	hashStrategy    hash.HashStrategy
+	hashLayout      hash.Layout
+	seed            uint64
+	keyCheck        uint64
+}
+
+// fingerprints returns the layout of f and every fingerprint stored in it,
+// with the bucket holding it, stashed ones last
+func (f *Filter) fingerprints() (tableLayout, []victim) {
+	f.rlockAll()
+	defer f.runlockAll()
+
+	layout := tableLayout{f.numBuckets, f.bucketSize, f.fingerprintBits, f.growthBits, f.hashStrategy, f.hashLayout, f.seed, f.keyCheck()}
+	entries := make([]victim, 0, f.count())
+	for i := uint(0); i < f.numBuckets; i++ {
+		for pos := uint(0); pos < f.bucketSize; pos++ {
+			if fp := f.table.Get(i, pos); fp != 0 {
+				entries = append(entries, victim{index: i, fp: fp})
+			}
+		}
+	}
+
+	if f.stripes != nil {
+		f.stashMu.Lock()
+		defer f.stashMu.Unlock()
+	}
+	return layout, append(entries, f.stash...)
+}
+
+// checkLayout verifies that fingerprints stored with layout can be moved to f.
+// Callers must hold f.mu.
+func (f *Filter) checkLayout(layout tableLayout) error {
+	for _, field := range []struct {
+		name      string
+		want, got uint
+	}{
+		{"bucket count", f.numBuckets, layout.numBuckets},
+		{"bucket size", f.bucketSize, layout.bucketSize},
+		{"fingerprint bits", f.fingerprintBits, layout.fingerprintBits},
+		{"growth factor", 1 << f.growthBits, 1 << layout.growthBits},
+	} {
+		if field.want != field.got {
+			return &IncompatibleError{Field: field.name, Want: fmt.Sprint(field.want), Got: fmt.Sprint(field.got)}
+		}
+	}
+	if layout.hashStrategy != f.hashStrategy {
+		return &IncompatibleError{Field: "hash strategy", Want: f.hashStrategy.String(), Got: layout.hashStrategy.String()}
+	}
+	if layout.hashLayout != f.hashLayout {
+		return &IncompatibleError{Field: "hash layout", Want: f.hashLayout.String(), Got: layout.hashLayout.String()}
+	}
+	if layout.seed != f.seed {
+		return &IncompatibleError{Field: "seed", Want: fmt.Sprintf("%#x", f.seed), Got: fmt.Sprintf("%#x", layout.seed)}
+	}
+	if check := f.keyCheck(); layout.keyCheck != check {
+		return keyMismatch(f.hashStrategy, check, layout.keyCheck)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// insertItems inserts n items named after prefix and returns them
+func insertItems(t *testing.T, f *Filter, prefix string, n int) [][]byte {
+	t.Helper()
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("%s-%d", prefix, i))
+		if !f.Insert(items[i]) {
+			t.Fatalf("Insert failed for %q", items[i])
+		}
+	}
+	return items
+}
+
+// TestMerge tests that a merged filter finds the items of both filters
+func TestMerge(t *testing.T) {
+	strategies := []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash}
+
+	for _, strategy := range strategies {
+		for _, bits := range []uint{8, 12, 16} {
+			for _, stripes := range []uint{0, 4} {
+				t.Run(fmt.Sprintf("%s/%dbits/Stripes%d", strategy, bits, stripes), func(t *testing.T) {
+					cfg := Config{
+						BucketSize:      4,
+						FingerprintBits: bits,
+						MaxKicks:        500,
+						HashStrategy:    strategy,
+						BatchSize:       32,
+						VictimCacheSize: 4,
+					}
+					other, _ := NewWithConfig(4096, cfg)
+					cfg.LockStripes = stripes
+					f, _ := NewWithConfig(4096, cfg)
+
+					own := insertItems(t, f, "own", 1000)
+					merged := insertItems(t, other, "other", 1000)
+					before, _ := other.MarshalBinary()
+
+					if err := f.Merge(other); err != nil {
+						t.Fatalf("Merge failed: %v", err)
+					}
+					if f.Count() != 2000 {
+						t.Errorf("Count() = %d, want 2000", f.Count())
+					}
+					for _, item := range append(own, merged...) {
+						if !f.Lookup(item) {
+							t.Fatalf("False negative for %q after Merge", item)
+						}
+					}
+					for _, item := range merged {
+						if !f.Delete(item) {
+							t.Fatalf("Delete failed for merged item %q", item)
+						}
+					}
+					if f.Count() != 1000 {
+						t.Errorf("Count() = %d after deleting merged items, want 1000", f.Count())
+					}
+
+					if after, _ := other.MarshalBinary(); !bytes.Equal(before, after) {
+						t.Error("Merge modified the source filter")
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestMergeStash tests that stashed fingerprints of both filters survive a merge
+func TestMergeStash(t *testing.T) {
+	f := mustNew(t, 256, 4, 12, hash.HashStrategyXXHash)
+	other := mustNew(t, 256, 4, 12, hash.HashStrategyXXHash)
+
+	accepted := fillPastCapacity(other, "stash")
+	if len(other.stash) == 0 {
+		t.Fatal("Expected stashed fingerprints after overfilling")
+	}
+	if err := f.Merge(other); err != nil {
+		t.Fatalf("Merge into an empty filter failed: %v", err)
+	}
+	if f.Count() != other.Count() {
+		t.Errorf("Count() = %d, want %d", f.Count(), other.Count())
+	}
+	for _, item := range accepted {
+		if !f.Lookup(item) {
+			t.Fatalf("False negative for %q after Merge", item)
+		}
+	}
+}
+
+// TestMergeFull tests that a merge that doesn't fit leaves the destination unchanged
+func TestMergeFull(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			cfg := Config{
+				BucketSize:      4,
+				FingerprintBits: 16,
+				MaxKicks:        100,
+				HashStrategy:    hash.HashStrategyXXHash,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+			}
+			other, _ := NewWithConfig(1024, cfg)
+			cfg.LockStripes = stripes
+			f, _ := NewWithConfig(1024, cfg)
+
+			fillPastCapacity(f, "dest")
+			fillPastCapacity(other, "src")
+			before, _ := f.MarshalBinary()
+			count := f.Count()
+
+			err := f.Merge(other)
+			if !errors.Is(err, ErrFull) {
+				t.Fatalf("Expected ErrFull, got %v", err)
+			}
+			if after, _ := f.MarshalBinary(); !bytes.Equal(before, after) || f.Count() != count {
+				t.Error("Failed Merge modified the destination")
+			}
+		})
+	}
+}
+
+// TestMergeIncompatible tests that filters with different layouts are rejected
+func TestMergeIncompatible(t *testing.T) {
+	f := mustNew(t, 1024, 4, 12, hash.HashStrategyXXHash)
+
+	for _, tt := range []struct {
+		field string
+		other *Filter
+	}{
+		{"bucket count", mustNew(t, 2048, 4, 12, hash.HashStrategyXXHash)},
+		{"bucket size", mustNew(t, 2048, 8, 12, hash.HashStrategyXXHash)},
+		{"fingerprint bits", mustNew(t, 1024, 4, 16, hash.HashStrategyXXHash)},
+		{"hash strategy", mustNew(t, 1024, 4, 12, hash.HashStrategyCRC32)},
+	} {
+		var incompatible *IncompatibleError
+		err := f.Merge(tt.other)
+		if !errors.As(err, &incompatible) || !errors.Is(err, ErrIncompatible) {
+			t.Fatalf("%s: expected *IncompatibleError, got %v", tt.field, err)
+		}
+		if incompatible.Field != tt.field {
+			t.Errorf("Field = %q, want %q", incompatible.Field, tt.field)
+		}
+	}
+}
+
+// TestMergeSetSemantics tests that set semantics skip fingerprints already present
+func TestMergeSetSemantics(t *testing.T) {
+	for _, set := range []bool{false, true} {
+		f, _ := NewWithConfig(4096, Config{
+			BucketSize:      4,
+			FingerprintBits: 16,
+			MaxKicks:        500,
+			HashStrategy:    hash.HashStrategyXXHash,
+			BatchSize:       32,
+			VictimCacheSize: 4,
+			SetSemantics:    set,
+		})
+		insertItems(t, f, "self", 500)
+		count := f.Count()
+
+		if err := f.Merge(f); err != nil {
+			t.Fatalf("Merging a filter into itself failed: %v", err)
+		}
+		want := 2 * count
+		if set {
+			want = count
+		}
+		if f.Count() != want {
+			t.Errorf("SetSemantics %v: Count() = %d, want %d", set, f.Count(), want)
+		}
+	}
+}
+
+// TestMergeConcurrent tests merges in both directions while writers run.
+// Run with -race to check the locking.
+func TestMergeConcurrent(t *testing.T) {
+	a := newStriped(t, 8192, 4, 16, 8)
+	b := mustNew(t, 8192, 4, 16, hash.HashStrategyXXHash)
+
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*Filter{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 3 {
+				pair[0].Merge(pair[1])
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				pair[0].Insert([]byte(fmt.Sprintf("%p-%d", pair[0], i)))
+			}
+		}()
+	}
+	wg.Wait()
+}
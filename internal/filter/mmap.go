@@ -0,0 +1,189 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sync"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// mappedFilter is a read-only filter that serves lookups directly from a
+// serialized filter file mapped into memory. The fingerprint table is used in
+// place: the serialized table is the raw bucket.Table storage, and the 64-byte
+// header keeps it aligned.
+// Processes mapping the same file share one copy of the pages.
+type mappedFilter struct {
+	data            []byte       // Whole mapping, released by Close
+	table           bucket.Table // Fingerprint table inside data
+	numBuckets      uint
+	bucketSize      uint
+	fingerprintBits uint
+	numItems        uint
+	stash           []victim // Victim stash, copied out of the mapping
+	hash            hash.HashInterface
+	mu              sync.RWMutex
+	closed          bool
+}
+
+// OpenMmap maps a file produced by WriteTo or MarshalBinary and returns a
+// read-only filter over it. The file is validated, including its checksum,
+// before the filter is returned. The mapping is released by Close. Files
+// hashed with SipHash or a custom hash function fail with ErrKeyRequired or
+// ErrHashRequired; they are opened with OpenMmapWith.
+func OpenMmap(path string) (*mappedFilter, error) {
+	return openMmap(path, nil)
+}
+
+// OpenMmapWith is OpenMmap for files hashed with SipHash or a custom hash
+// function, as ReadWith is for Read
+func OpenMmapWith(path string, params hash.Params) (*mappedFilter, error) {
+	return openMmap(path, &params)
+}
+
+// openMmap implements OpenMmap and OpenMmapWith
+func openMmap(path string, params *hash.Params) (*mappedFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size < headerSize+checksumSize {
+		return nil, fmt.Errorf("%w: file is %d bytes", ErrInvalidFormat, size)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("%w: file is too large to map", ErrInvalidFormat)
+	}
+
+	data, err := mapFile(file, int(size))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := newMappedFilter(data, params)
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	return f, nil
+}
+
+// newMappedFilter validates data and wraps its fingerprint table
+func newMappedFilter(data []byte, params *hash.Params) (*mappedFilter, error) {
+	if err := checkSize(data); err != nil {
+		return nil, err
+	}
+	h, _ := decodeHeader(data)
+	cfg, err := h.paramsConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	body := len(data) - checksumSize
+	if crc32.Checksum(data[:body], crcTable) != binary.LittleEndian.Uint32(data[body:]) {
+		return nil, ErrChecksumMismatch
+	}
+
+	tableEnd := headerSize + int(h.tableSize())
+	stash, err := decodeStash(data[tableEnd:body], h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mappedFilter{
+		data:            data,
+		table:           bucket.NewTableFrom(data[headerSize:tableEnd], h.numBuckets, h.bucketSize, h.fingerprintBits),
+		numBuckets:      h.numBuckets,
+		bucketSize:      h.bucketSize,
+		fingerprintBits: h.fingerprintBits,
+		numItems:        h.numItems,
+		stash:           stash,
+		hash:            hash.Grown(hash.NewHashFunctionWithParams(h.hashStrategy, h.fingerprintBits, cfg.hashParams()), h.fingerprintBits, h.growthBits),
+	}, nil
+}
+
+// Lookup checks whether item might be in the filter using the SIMD bucket
+// comparison on the mapped pages. It returns false once the filter is closed.
+func (f *mappedFilter) Lookup(item []byte) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return false
+	}
+
+	i1, i2, fp := f.hash.GetIndices(item, f.numBuckets)
+	return f.table.Contains(i1, fp) || f.table.Contains(i2, fp) ||
+		stashContains(f.stash, i1, i2, fp)
+}
+
+// LookupBatch checks multiple items using batch hashing
+func (f *mappedFilter) LookupBatch(items [][]byte) []bool {
+	results := make([]bool, len(items))
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.closed {
+		return results
+	}
+
+	for i, hr := range f.hash.GetIndicesBatch(items, f.numBuckets) {
+		results[i] = f.table.Contains(hr.I1, hr.Fp) || f.table.Contains(hr.I2, hr.Fp) ||
+			stashContains(f.stash, hr.I1, hr.I2, hr.Fp)
+	}
+	return results
+}
+
+// Insert always fails with ErrReadOnly
+func (f *mappedFilter) Insert(item []byte) error {
+	return ErrReadOnly
+}
+
+// Delete always fails with ErrReadOnly
+func (f *mappedFilter) Delete(item []byte) error {
+	return ErrReadOnly
+}
+
+func (f *mappedFilter) Count() uint {
+	return f.numItems
+}
+
+func (f *mappedFilter) LoadFactor() float64 {
+	return float64(f.numItems) / float64(f.numBuckets*f.bucketSize)
+}
+
+func (f *mappedFilter) Capacity() uint {
+	return f.numBuckets * f.bucketSize
+}
+
+// MemoryUsage returns the size in bytes of the mapped fingerprint table
+func (f *mappedFilter) MemoryUsage() uint {
+	return bucket.TableSize(f.numBuckets, f.bucketSize, f.fingerprintBits)
+}
+
+// Close releases the mapping. It waits for in-flight lookups and is safe to
+// call more than once.
+func (f *mappedFilter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	data := f.data
+	f.data, f.table = nil, nil
+	return unmapFile(data)
+}
@@ -0,0 +1,23 @@
+//go:build (amd64 || arm64) && !unix
+
+package filter
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the file into memory on platforms without mmap support.
+// Lookups behave the same, but the data is not shared between processes.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// unmapFile releases data read by mapFile
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// writeFilterFile saves f to a file in a temporary directory
+func writeFilterFile(t *testing.T, f *Filter) string {
+	t.Helper()
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "filter.cf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return path
+}
+
+// TestOpenMmapLookup tests that a mapped filter answers like the filter it was saved from
+func TestOpenMmapLookup(t *testing.T) {
+	for _, bits := range []uint{8, 12, 16} {
+		for _, bucketSize := range []uint{2, 4, 8, 16, 32, 64} {
+			t.Run(fmt.Sprintf("%dbits/BucketSize%d", bits, bucketSize), func(t *testing.T) {
+				f, _ := New(20000, bucketSize, bits, 500, hash.HashStrategyXXHash, 32)
+				items := make([][]byte, 10000)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("item-%d", i))
+					f.Insert(items[i])
+				}
+
+				m, err := OpenMmap(writeFilterFile(t, f))
+				if err != nil {
+					t.Fatalf("OpenMmap failed: %v", err)
+				}
+				defer m.Close()
+
+				if m.Count() != f.Count() || m.Capacity() != f.Capacity() || m.MemoryUsage() != f.MemoryUsage() {
+					t.Errorf("Mapped filter reports count %d capacity %d memory %d, want %d, %d and %d",
+						m.Count(), m.Capacity(), m.MemoryUsage(), f.Count(), f.Capacity(), f.MemoryUsage())
+				}
+
+				misses := make([][]byte, 10000)
+				for i := range misses {
+					misses[i] = []byte(fmt.Sprintf("miss-%d", i))
+				}
+
+				for _, set := range [][][]byte{items, misses} {
+					batch := m.LookupBatch(set)
+					for i, item := range set {
+						want := f.Lookup(item)
+						if got := m.Lookup(item); got != want {
+							t.Fatalf("Lookup(%q) = %v, want %v", item, got, want)
+						}
+						if batch[i] != want {
+							t.Fatalf("LookupBatch(%q) = %v, want %v", item, batch[i], want)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestOpenMmapReadOnly tests that modifications are rejected
+func TestOpenMmapReadOnly(t *testing.T) {
+	f := mustNew(t, 1000, 4, 8, hash.HashStrategyFNV)
+	f.Insert([]byte("item"))
+
+	m, err := OpenMmap(writeFilterFile(t, f))
+	if err != nil {
+		t.Fatalf("OpenMmap failed: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Insert([]byte("other")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Insert: expected ErrReadOnly, got %v", err)
+	}
+	if err := m.Delete([]byte("item")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete: expected ErrReadOnly, got %v", err)
+	}
+	if !m.Lookup([]byte("item")) || m.Count() != 1 {
+		t.Error("Filter contents changed after rejected modifications")
+	}
+}
+
+// TestOpenMmapInvalidFile tests that damaged files are rejected before use
+func TestOpenMmapInvalidFile(t *testing.T) {
+	f := mustNew(t, 1000, 4, 8, hash.HashStrategyCRC32)
+	for i := 0; i < 100; i++ {
+		f.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	data, _ := f.MarshalBinary()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidFormat},
+		{"truncated", data[:len(data)-2], ErrInvalidFormat},
+		{"flipped fingerprint", func() []byte {
+			b := append([]byte(nil), data...)
+			b[headerSize+5] ^= 0x10
+			return b
+		}(), ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			os.WriteFile(path, tt.data, 0o644)
+			if _, err := OpenMmap(path); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := OpenMmap(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+}
+
+// TestOpenMmapClose tests that Close is idempotent and lookups stop afterwards
+func TestOpenMmapClose(t *testing.T) {
+	f := mustNew(t, 1000, 4, 8, hash.HashStrategyXXHash)
+	f.Insert([]byte("item"))
+
+	m, err := OpenMmap(writeFilterFile(t, f))
+	if err != nil {
+		t.Fatalf("OpenMmap failed: %v", err)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Errorf("Second Close failed: %v", err)
+	}
+
+	if m.Lookup([]byte("item")) {
+		t.Error("Lookup should report false after Close")
+	}
+	if m.LookupBatch([][]byte{[]byte("item")})[0] {
+		t.Error("LookupBatch should report false after Close")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build (amd64 || arm64) && unix
+
+package filter
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of file read-only and shared between processes
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapFile releases a mapping created by mapFile
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
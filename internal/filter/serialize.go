@@ -0,0 +1,607 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// Serialized filter layout (all integers little-endian):
+//
+//	offset  size  field
+//	0       4     magic "SCFL"
+//	4       1     format version
+//	5       1     hash strategy
+//	6       1     fingerprint bits
+//	7       1     number of stash entries (s)
+//	8       4     bucket size
+//	12      4     max kicks
+//	16      4     batch size
+//	20      4     victim cache size
+//	24      8     number of buckets
+//	32      8     number of items, including stashed ones
+//	40      1     growth bits (version 2; zero in version 1)
+//	41      1     hash layout (version 3; version 1 and 2 imply hash.LayoutLowBits)
+//	42      6     reserved (zero)
+//	48      8     hash seed (version 4; zero before)
+//	56      8     key check (version 4; zero unless SipHash or custom hash)
+//	64      n     fingerprint table (see below)
+//	64+n    10*s  stash entries: bucket index (8 bytes), fingerprint (2 bytes)
+//	...     4     CRC-32C of all preceding bytes
+//
+// The fingerprint table is the raw storage of bucket.Table: uint16 values for
+// 16-bit fingerprints, bytes for 8-bit fingerprints, and a little-endian bit
+// string padded by 8 bytes for any other size. Its length n is
+// bucket.TableSize(buckets, bucket size, fingerprint bits). The header is
+// padded to 64 bytes so the table starts on a cache-line boundary, which lets
+// OpenMmap use it in place.
+//
+// The SipHash key itself is never written, only the SipHash of an empty
+// message under it, so readers can tell a wrong key from a right one
+// without the key being exposed. Likewise, filters using a custom hash
+// function record its hash of an empty item. Data hashed with SipHash or a
+// custom hash must be read with the key or function.
+//
+// Filters are written with the oldest version that can describe them.
+// Version 3 was introduced with hash.LayoutDisjoint, which new filters use,
+// so only filters restored from older data are still written as version 1
+// or 2 and stay readable by older readers. Version 4 is used for seeded,
+// SipHash and custom hash filters.
+const (
+	formatMagic   = "SCFL"
+	formatVersion = 4
+	headerSize    = 64
+	checksumSize  = 4
+	maxBucketSize = 64
+
+	// maxBuckets is the largest bucket count whose table size fits in a uint64
+	maxBuckets = maxPowerOf2 / (16 * maxBucketSize)
+
+	// stashEntrySize is the serialized size of one victim stash entry
+	stashEntrySize = 10
+
+	// streamChunkSize is the buffer size used by WriteTo and ReadFrom
+	streamChunkSize = 64 << 10
+)
+
+// crcTable is the CRC-32C table used to checksum serialized filters
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// header holds the configuration recorded in a serialized filter
+type header struct {
+	hashStrategy    hash.HashStrategy
+	fingerprintBits uint
+	bucketSize      uint
+	maxKicks        uint
+	batchSize       uint
+	numBuckets      uint
+	numItems        uint
+	victimCacheSize uint
+	stashLen        uint
+	growthBits      uint
+	hashLayout      hash.Layout
+	seed            uint64
+	keyCheck        uint64 // See (*Filter).keyCheck
+}
+
+// header returns the serialization header describing f.
+// Callers must hold f.mu.
+func (f *Filter) header() header {
+	return header{
+		hashStrategy:    f.hashStrategy,
+		fingerprintBits: f.fingerprintBits,
+		bucketSize:      f.bucketSize,
+		maxKicks:        f.maxKicks,
+		batchSize:       f.batchSize,
+		numBuckets:      f.numBuckets,
+		numItems:        f.count(),
+		victimCacheSize: f.victimCacheSize,
+		stashLen:        uint(len(f.stash)),
+		growthBits:      f.growthBits,
+		hashLayout:      f.hashLayout,
+		seed:            f.seed,
+		keyCheck:        f.keyCheck(),
+	}
+}
+
+// config returns the filter settings recorded in h
+func (h header) config() Config {
+	return Config{
+		BucketSize:      h.bucketSize,
+		FingerprintBits: h.fingerprintBits,
+		MaxKicks:        h.maxKicks,
+		HashStrategy:    h.hashStrategy,
+		BatchSize:       h.batchSize,
+		VictimCacheSize: h.victimCacheSize,
+		GrowthBits:      h.growthBits,
+		HashLayout:      h.hashLayout,
+		Seed:            h.seed,
+	}
+}
+
+// paramsConfig returns the filter settings recorded in h with the SipHash
+// key or custom hash function from params, which aren't recorded. params may
+// be nil for other strategies; its layout and seed are ignored.
+func (h header) paramsConfig(params *hash.Params) (Config, error) {
+	cfg := h.config()
+	switch h.hashStrategy {
+	case hash.HashStrategySipHash:
+		if params == nil {
+			return Config{}, ErrKeyRequired
+		}
+		cfg.SipKey = params.Key
+	case hash.HashStrategyCustom:
+		if params == nil || params.Func == nil {
+			return Config{}, ErrHashRequired
+		}
+		cfg.CustomHash = params.Func
+	default:
+		return cfg, nil
+	}
+	if check := keyCheck(h.hashStrategy, cfg.hashParams()); check != h.keyCheck {
+		return Config{}, keyMismatch(h.hashStrategy, check, h.keyCheck)
+	}
+	return cfg, nil
+}
+
+// keyCheck identifies the SipHash key or custom hash function of f without
+// revealing the key. Callers must hold f.mu.
+func (f *Filter) keyCheck() uint64 {
+	return keyCheck(f.hashStrategy, hash.Params{Key: f.sipKey, Func: f.customHash})
+}
+
+// keyCheck returns the SipHash of an empty message under the key of params,
+// the custom hash of an empty item, or zero for other strategies
+func keyCheck(strategy hash.HashStrategy, params hash.Params) uint64 {
+	switch strategy {
+	case hash.HashStrategySipHash:
+		return hash.SipHash64(params.Key, nil)
+	case hash.HashStrategyCustom:
+		return params.Func([]byte{})
+	default:
+		return 0
+	}
+}
+
+// keyMismatch reports that the key checks want and got of a strategy differ
+func keyMismatch(strategy hash.HashStrategy, want, got uint64) *IncompatibleError {
+	field := "SipHash key"
+	if strategy == hash.HashStrategyCustom {
+		field = "custom hash"
+	}
+	return &IncompatibleError{Field: field, Want: fmt.Sprintf("%#016x", want), Got: fmt.Sprintf("%#016x", got)}
+}
+
+// version returns the format version used to encode h
+func (h header) version() byte {
+	if h.seed != 0 || h.hashStrategy == hash.HashStrategySipHash || h.hashStrategy == hash.HashStrategyCustom {
+		return 4
+	}
+	if h.hashLayout != hash.LayoutLowBits {
+		return 3
+	}
+	if h.growthBits > 0 {
+		return 2
+	}
+	return 1
+}
+
+// encode writes h into the first headerSize bytes of buf
+func (h header) encode(buf []byte) {
+	clear(buf[:headerSize])
+	copy(buf[0:4], formatMagic)
+	buf[4] = h.version()
+	buf[5] = byte(h.hashStrategy)
+	buf[6] = byte(h.fingerprintBits)
+	buf[7] = byte(h.stashLen)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(h.bucketSize))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(h.maxKicks))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(h.batchSize))
+	binary.LittleEndian.PutUint32(buf[20:], uint32(h.victimCacheSize))
+	binary.LittleEndian.PutUint64(buf[24:], uint64(h.numBuckets))
+	binary.LittleEndian.PutUint64(buf[32:], uint64(h.numItems))
+	buf[40] = byte(h.growthBits)
+	if h.hashLayout != hash.LayoutLowBits {
+		buf[41] = byte(h.hashLayout)
+	}
+	binary.LittleEndian.PutUint64(buf[48:], h.seed)
+	binary.LittleEndian.PutUint64(buf[56:], h.keyCheck)
+}
+
+// decodeHeader parses and validates the header at the start of buf
+func decodeHeader(buf []byte) (header, error) {
+	if len(buf) < headerSize {
+		return header{}, fmt.Errorf("%w: header truncated", ErrInvalidFormat)
+	}
+	if string(buf[0:4]) != formatMagic {
+		return header{}, fmt.Errorf("%w: bad magic", ErrInvalidFormat)
+	}
+	if buf[4] < 1 || buf[4] > formatVersion {
+		return header{}, fmt.Errorf("%w: version %d", ErrUnsupportedVersion, buf[4])
+	}
+
+	h := header{
+		hashStrategy:    hash.HashStrategy(buf[5]),
+		fingerprintBits: uint(buf[6]),
+		stashLen:        uint(buf[7]),
+		bucketSize:      uint(binary.LittleEndian.Uint32(buf[8:])),
+		maxKicks:        uint(binary.LittleEndian.Uint32(buf[12:])),
+		batchSize:       uint(binary.LittleEndian.Uint32(buf[16:])),
+		victimCacheSize: uint(binary.LittleEndian.Uint32(buf[20:])),
+		numBuckets:      uint(binary.LittleEndian.Uint64(buf[24:])),
+		numItems:        uint(binary.LittleEndian.Uint64(buf[32:])),
+		growthBits:      uint(buf[40]),
+		hashLayout:      hash.LayoutLowBits,
+	}
+	if buf[4] >= 3 {
+		h.hashLayout = hash.Layout(buf[41])
+	}
+	if buf[4] >= 4 {
+		h.seed = binary.LittleEndian.Uint64(buf[48:])
+		h.keyCheck = binary.LittleEndian.Uint64(buf[56:])
+	}
+
+	switch {
+	case h.hashStrategy.String() == "Unknown" || h.hashStrategy >= hash.HashStrategySipHash && buf[4] < 4:
+		return header{}, fmt.Errorf("%w: unknown hash strategy %d", ErrInvalidFormat, buf[5])
+	case !h.hashLayout.Valid():
+		return header{}, fmt.Errorf("%w: hash layout %d", ErrInvalidFormat, buf[41])
+	case h.fingerprintBits < 1 || h.fingerprintBits > 16:
+		return header{}, fmt.Errorf("%w: fingerprint bits %d", ErrInvalidFormat, h.fingerprintBits)
+	case h.bucketSize == 0 || h.bucketSize > maxBucketSize:
+		return header{}, fmt.Errorf("%w: bucket size %d", ErrInvalidFormat, h.bucketSize)
+	case h.numBuckets == 0 || h.numBuckets&(h.numBuckets-1) != 0 || h.numBuckets > maxBuckets:
+		return header{}, fmt.Errorf("%w: bucket count %d", ErrInvalidFormat, h.numBuckets)
+	case h.growthBits >= h.fingerprintBits || h.numBuckets>>h.growthBits == 0 || h.version() > buf[4]:
+		return header{}, fmt.Errorf("%w: growth bits %d", ErrInvalidFormat, h.growthBits)
+	case h.victimCacheSize == 0 || h.victimCacheSize > MaxVictimCacheSize:
+		return header{}, fmt.Errorf("%w: victim cache size %d", ErrInvalidFormat, h.victimCacheSize)
+	case h.stashLen > h.victimCacheSize:
+		return header{}, fmt.Errorf("%w: %d stash entries exceed victim cache size", ErrInvalidFormat, h.stashLen)
+	case h.numItems > h.numBuckets*h.bucketSize+h.stashLen:
+		return header{}, fmt.Errorf("%w: item count %d exceeds capacity", ErrInvalidFormat, h.numItems)
+	}
+	return h, nil
+}
+
+// tableSize returns the size in bytes of the serialized fingerprint table
+func (h header) tableSize() uint {
+	return bucket.TableSize(h.numBuckets, h.bucketSize, h.fingerprintBits)
+}
+
+// bodySize returns the size in bytes of the data between header and checksum
+func (h header) bodySize() uint {
+	return h.tableSize() + h.stashLen*stashEntrySize
+}
+
+// checkCompatible verifies that data described by h can be loaded into f.
+// Callers must hold f.mu.
+func (f *Filter) checkCompatible(h header) error {
+	if h.hashStrategy != f.hashStrategy {
+		return &IncompatibleError{Field: "hash strategy", Want: f.hashStrategy.String(), Got: h.hashStrategy.String()}
+	}
+	if h.fingerprintBits != f.fingerprintBits {
+		return &IncompatibleError{
+			Field: "fingerprint bits",
+			Want:  fmt.Sprint(f.fingerprintBits),
+			Got:   fmt.Sprint(h.fingerprintBits),
+		}
+	}
+	if h.growthBits != f.growthBits {
+		return &IncompatibleError{Field: "growth factor", Want: fmt.Sprint(1 << f.growthBits), Got: fmt.Sprint(1 << h.growthBits)}
+	}
+	if h.hashLayout != f.hashLayout {
+		return &IncompatibleError{Field: "hash layout", Want: f.hashLayout.String(), Got: h.hashLayout.String()}
+	}
+	if h.seed != f.seed {
+		return &IncompatibleError{Field: "seed", Want: fmt.Sprintf("%#x", f.seed), Got: fmt.Sprintf("%#x", h.seed)}
+	}
+	if check := f.keyCheck(); h.keyCheck != check {
+		return keyMismatch(f.hashStrategy, check, h.keyCheck)
+	}
+	return nil
+}
+
+// WriteTo implements io.WriterTo.
+// The table is streamed to w in fixed-size chunks under the read lock, so
+// no copy of it is materialized. The output is identical to
+// MarshalBinary and ends with a CRC-32C trailer that detects truncation.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	f.rlockAll()
+	defer f.runlockAll()
+
+	cw := &checksumWriter{w: w}
+
+	var hdr [headerSize]byte
+	f.header().encode(hdr[:])
+	if _, err := cw.Write(hdr[:]); err != nil {
+		return cw.n, err
+	}
+
+	for data := f.table.Bytes(); len(data) > 0; {
+		n := min(len(data), streamChunkSize)
+		if _, err := cw.Write(data[:n]); err != nil {
+			return cw.n, err
+		}
+		data = data[n:]
+	}
+
+	stash := make([]byte, 0, len(f.stash)*stashEntrySize)
+	for _, v := range f.stash {
+		stash = binary.LittleEndian.AppendUint64(stash, uint64(v.index))
+		stash = binary.LittleEndian.AppendUint16(stash, v.fp)
+	}
+	if _, err := cw.Write(stash); err != nil {
+		return cw.n, err
+	}
+
+	var trailer [checksumSize]byte
+	binary.LittleEndian.PutUint32(trailer[:], cw.crc)
+	n, err := w.Write(trailer[:])
+	return cw.n + int64(n), err
+}
+
+// ReadFrom implements io.ReaderFrom.
+// It reads exactly one serialized filter from r and replaces the contents of
+// f with it. Data produced with a different hash strategy or fingerprint size
+// is rejected with an *IncompatibleError. The filter is only modified once the
+// whole table has been read and its checksum verified.
+func (f *Filter) ReadFrom(r io.Reader) (int64, error) {
+	cr := &checksumReader{r: r}
+
+	h, err := readHeader(cr)
+	if err != nil {
+		return cr.n, err
+	}
+
+	f.mu.RLock()
+	err = f.checkCompatible(h)
+	f.mu.RUnlock()
+	if err != nil {
+		return cr.n, err
+	}
+
+	table, stash, err := readBody(cr, h)
+	if err != nil {
+		return cr.n, err
+	}
+
+	var isolated bucket.ConcurrentTable
+	if f.stripes != nil {
+		isolated = bucket.Isolated(table)
+		table = isolated
+	}
+
+	f.lockAll()
+	defer f.unlockAll()
+
+	f.table = table
+	f.stash = append(make([]victim, 0, h.victimCacheSize), stash...)
+	f.stashLen.Store(int32(len(stash)))
+	f.victimCacheSize = h.victimCacheSize
+	f.numBuckets = h.numBuckets
+	if isolated != nil {
+		f.view.Store(&tableView{table: isolated, numBuckets: h.numBuckets})
+	}
+	f.bucketSize = h.bucketSize
+	f.setCount(h.numItems)
+	f.maxKicks = h.maxKicks
+	f.batchSize = h.batchSize
+
+	// The insert history belongs to the replaced contents, as for Reset
+	f.counters.reset()
+	for i := range f.stripes {
+		f.stripes[i].counters.reset()
+	}
+	return cr.n, nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The encoding captures the full filter configuration and every stored
+// fingerprint, so the filter can be restored without the original items.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	f.mu.RLock()
+	buf.Grow(int(headerSize + f.header().bodySize() + checksumSize))
+	f.mu.RUnlock()
+
+	if _, err := f.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The filter adopts the bucket layout stored in data. Data produced with a
+// different hash strategy or fingerprint size is rejected with an
+// *IncompatibleError and leaves the filter unchanged.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if err := checkSize(data); err != nil {
+		return err
+	}
+	_, err := f.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// Read reconstructs a filter from a stream produced by WriteTo.
+// The returned filter uses the hash strategy and fingerprint size recorded in
+// the stream. Exactly one filter is consumed from r. Filters hashed with
+// SipHash or a custom hash function fail with ErrKeyRequired or
+// ErrHashRequired; they are read with ReadWith.
+func Read(r io.Reader) (*Filter, error) {
+	return read(r, nil)
+}
+
+// ReadWith is Read for filters hashed with SipHash under params.Key or with
+// the custom hash function params.Func. Data whose key check differs is
+// rejected with an *IncompatibleError. Filters using other hash strategies
+// are read as by Read.
+func ReadWith(r io.Reader, params hash.Params) (*Filter, error) {
+	return read(r, &params)
+}
+
+// read implements Read and ReadWith
+func read(r io.Reader, params *hash.Params) (*Filter, error) {
+	cr := &checksumReader{r: r}
+
+	h, err := readHeader(cr)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := h.paramsConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	table, stash, err := readBody(cr, h)
+	if err != nil {
+		return nil, err
+	}
+
+	f := newFilter(table, cfg)
+	f.stash = append(f.stash, stash...)
+	f.numItems = h.numItems
+	return f, nil
+}
+
+// Decode reconstructs a filter from data produced by MarshalBinary.
+// The returned filter uses the hash strategy and fingerprint size recorded in data.
+func Decode(data []byte) (*Filter, error) {
+	if err := checkSize(data); err != nil {
+		return nil, err
+	}
+	return Read(bytes.NewReader(data))
+}
+
+// checkSize verifies that data holds exactly one serialized filter.
+// Checking up front avoids allocating a table for a header that lies about its size.
+func checkSize(data []byte) error {
+	h, err := decodeHeader(data)
+	if err != nil {
+		return err
+	}
+	if uint(len(data)) != headerSize+h.bodySize()+checksumSize {
+		return fmt.Errorf("%w: expected %d bytes, got %d",
+			ErrInvalidFormat, headerSize+h.bodySize()+checksumSize, len(data))
+	}
+	return nil
+}
+
+// readHeader reads and validates a serialized header from r
+func readHeader(r io.Reader) (header, error) {
+	var hdr [headerSize]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return header{}, truncated(err)
+	}
+	return decodeHeader(hdr[:])
+}
+
+// readBody reads the fingerprint table and stash described by h followed by
+// the checksum trailer, and verifies the checksum
+func readBody(cr *checksumReader, h header) (bucket.Table, []victim, error) {
+	table, err := readTable(cr, h)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, h.stashLen*stashEntrySize)
+	if _, err := io.ReadFull(cr, buf); err != nil {
+		return nil, nil, truncated(err)
+	}
+
+	expected := cr.crc
+	var trailer [checksumSize]byte
+	if _, err := io.ReadFull(cr, trailer[:]); err != nil {
+		return nil, nil, truncated(err)
+	}
+	if binary.LittleEndian.Uint32(trailer[:]) != expected {
+		return nil, nil, ErrChecksumMismatch
+	}
+
+	stash, err := decodeStash(buf, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, stash, nil
+}
+
+// readTable reads the fingerprint table described by h. The table grows as
+// data arrives, so a truncated stream fails before the full table is allocated.
+func readTable(cr *checksumReader, h header) (bucket.Table, error) {
+	size := h.tableSize()
+	data := bucket.AlignedBytes(min(size, streamChunkSize))
+	for read := uint(0); read < size; {
+		if read == uint(len(data)) {
+			grown := bucket.AlignedBytes(min(size, 2*read))
+			copy(grown, data)
+			data = grown
+		}
+
+		n := min(uint(len(data))-read, streamChunkSize)
+		if _, err := io.ReadFull(cr, data[read:read+n]); err != nil {
+			return nil, truncated(err)
+		}
+		read += n
+	}
+	return bucket.NewTableFrom(data, h.numBuckets, h.bucketSize, h.fingerprintBits), nil
+}
+
+// decodeStash parses and validates the stash entries described by h
+func decodeStash(buf []byte, h header) ([]victim, error) {
+	stash := make([]victim, h.stashLen)
+	for i := range stash {
+		v := victim{
+			index: uint(binary.LittleEndian.Uint64(buf)),
+			fp:    binary.LittleEndian.Uint16(buf[8:]),
+		}
+		if v.index >= h.numBuckets || v.fp == 0 || uint(v.fp)>>h.fingerprintBits != 0 {
+			return nil, fmt.Errorf("%w: stash entry %d", ErrInvalidFormat, i)
+		}
+		stash[i] = v
+		buf = buf[stashEntrySize:]
+	}
+	return stash, nil
+}
+
+// truncated converts an end-of-stream error into ErrInvalidFormat
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// checksumWriter forwards writes to w while tracking their CRC-32C and size
+type checksumWriter struct {
+	w   io.Writer
+	crc uint32
+	n   int64
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.crc = crc32.Update(c.crc, crcTable, p[:n])
+	c.n += int64(n)
+	return n, err
+}
+
+// checksumReader forwards reads from r while tracking their CRC-32C and size
+type checksumReader struct {
+	r   io.Reader
+	crc uint32
+	n   int64
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc = crc32.Update(c.crc, crcTable, p[:n])
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,609 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// TestSerializeRoundTrip tests that every configuration survives MarshalBinary/Decode
+func TestSerializeRoundTrip(t *testing.T) {
+	strategies := []hash.HashStrategy{
+		hash.HashStrategyXXHash,
+		hash.HashStrategyCRC32,
+		hash.HashStrategyFNV,
+	}
+
+	for _, strategy := range strategies {
+		for _, bits := range []uint{4, 8, 12, 16} {
+			t.Run(fmt.Sprintf("%s/%dbits", strategy, bits), func(t *testing.T) {
+				f, _ := New(1000, 4, bits, 500, strategy, 32)
+
+				items := make([][]byte, 500)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("item-%d", i))
+					f.Insert(items[i])
+				}
+
+				data, err := f.MarshalBinary()
+				if err != nil {
+					t.Fatalf("MarshalBinary failed: %v", err)
+				}
+
+				g, err := Decode(data)
+				if err != nil {
+					t.Fatalf("Decode failed: %v", err)
+				}
+
+				if g.Count() != f.Count() {
+					t.Errorf("Expected count %d, got %d", f.Count(), g.Count())
+				}
+				if g.numBuckets != f.numBuckets || g.bucketSize != f.bucketSize {
+					t.Errorf("Layout mismatch: got %dx%d, want %dx%d",
+						g.numBuckets, g.bucketSize, f.numBuckets, f.bucketSize)
+				}
+				if g.hashStrategy != strategy || g.fingerprintBits != bits {
+					t.Errorf("Hash config mismatch: got %s/%d, want %s/%d",
+						g.hashStrategy, g.fingerprintBits, strategy, bits)
+				}
+
+				for i, item := range items {
+					if f.Lookup(item) != g.Lookup(item) {
+						t.Errorf("Lookup mismatch for item %d", i)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestUnmarshalBinaryReplacesContents tests loading into an existing filter
+func TestUnmarshalBinaryReplacesContents(t *testing.T) {
+	src, _ := New(4000, 8, 8, 500, hash.HashStrategyFNV, 32)
+	for i := 0; i < 100; i++ {
+		src.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	data, _ := src.MarshalBinary()
+
+	dst, _ := New(100, 4, 8, 500, hash.HashStrategyFNV, 32)
+	dst.Insert([]byte("stale"))
+
+	if err := dst.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+
+	if dst.Count() != src.Count() {
+		t.Errorf("Expected count %d, got %d", src.Count(), dst.Count())
+	}
+	if dst.Capacity() != src.Capacity() {
+		t.Errorf("Expected capacity %d, got %d", src.Capacity(), dst.Capacity())
+	}
+	for i := 0; i < 100; i++ {
+		if !dst.Lookup([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Errorf("Item %d not found after UnmarshalBinary", i)
+		}
+	}
+}
+
+// TestUnmarshalBinaryIncompatible tests that mismatched hash configurations are rejected
+func TestUnmarshalBinaryIncompatible(t *testing.T) {
+	src, _ := New(1000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+	src.Insert([]byte("item"))
+	data, _ := src.MarshalBinary()
+
+	tests := []struct {
+		name  string
+		dst   *Filter
+		field string
+	}{
+		{"hash strategy", mustNew(t, 1000, 4, 8, hash.HashStrategyCRC32), "hash strategy"},
+		{"fingerprint bits", mustNew(t, 1000, 4, 12, hash.HashStrategyXXHash), "fingerprint bits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.dst.UnmarshalBinary(data)
+			if !errors.Is(err, ErrIncompatible) {
+				t.Fatalf("Expected ErrIncompatible, got %v", err)
+			}
+
+			var incompatible *IncompatibleError
+			if !errors.As(err, &incompatible) {
+				t.Fatalf("Expected *IncompatibleError, got %T", err)
+			}
+			if incompatible.Field != tt.field {
+				t.Errorf("Expected field %q, got %q", tt.field, incompatible.Field)
+			}
+
+			if tt.dst.Count() != 0 {
+				t.Errorf("Filter should be unchanged after rejected load, count=%d", tt.dst.Count())
+			}
+		})
+	}
+}
+
+// TestDecodeCorruptData tests that damaged data is rejected
+func TestDecodeCorruptData(t *testing.T) {
+	f, _ := New(1000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+	for i := 0; i < 100; i++ {
+		f.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	data, _ := f.MarshalBinary()
+
+	corrupt := func(mutate func([]byte) []byte) []byte {
+		buf := append([]byte(nil), data...)
+		return mutate(buf)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrInvalidFormat},
+		{"bad magic", corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), ErrInvalidFormat},
+		{"future version", corrupt(func(b []byte) []byte { b[4] = formatVersion + 1; return b }), ErrUnsupportedVersion},
+		{"truncated", corrupt(func(b []byte) []byte { return b[:len(b)-10] }), ErrInvalidFormat},
+		{"flipped fingerprint", corrupt(func(b []byte) []byte { b[headerSize+3] ^= 0x40; return b }), ErrChecksumMismatch},
+		{"flipped checksum", corrupt(func(b []byte) []byte { b[len(b)-1] ^= 0x01; return b }), ErrChecksumMismatch},
+		{"bad bucket count", corrupt(func(b []byte) []byte { b[24] = 3; return b }), ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decode(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestWriteToReadFrom tests streaming a filter that spans several chunks
+func TestWriteToReadFrom(t *testing.T) {
+	f, _ := New(200000, 4, 16, 500, hash.HashStrategyFNV, 32)
+	for i := 0; i < 100000; i++ {
+		f.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	n, err := f.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo reported %d bytes, wrote %d", n, buf.Len())
+	}
+	if buf.Len() <= streamChunkSize {
+		t.Fatalf("Test filter should span multiple chunks, got %d bytes", buf.Len())
+	}
+
+	data, _ := f.MarshalBinary()
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Error("WriteTo output differs from MarshalBinary")
+	}
+
+	g, _ := New(10, 4, 16, 500, hash.HashStrategyFNV, 32)
+	n, err = g.ReadFrom(&buf)
+	if err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("ReadFrom reported %d bytes, want %d", n, len(data))
+	}
+
+	if g.Count() != f.Count() || g.numBuckets != f.numBuckets {
+		t.Errorf("Restored filter differs: count %d/%d, buckets %d/%d",
+			g.Count(), f.Count(), g.numBuckets, f.numBuckets)
+	}
+	for i := 0; i < 100000; i += 97 {
+		if !g.Lookup([]byte(fmt.Sprintf("item-%d", i))) {
+			t.Errorf("Item %d not found after ReadFrom", i)
+		}
+	}
+}
+
+// TestStreamThroughGzip tests piping a filter through a compressor
+func TestStreamThroughGzip(t *testing.T) {
+	f, _ := New(10000, 8, 8, 500, hash.HashStrategyCRC32, 32)
+	for i := 0; i < 5000; i++ {
+		f.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := f.WriteTo(zw); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	zw.Close()
+
+	zr, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader failed: %v", err)
+	}
+	g, err := Read(zr)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	for i := 0; i < 5000; i++ {
+		item := []byte(fmt.Sprintf("item-%d", i))
+		if f.Lookup(item) != g.Lookup(item) {
+			t.Errorf("Lookup mismatch for item %d", i)
+		}
+	}
+}
+
+// TestReadTruncatedStream tests that every truncation point is detected
+func TestReadTruncatedStream(t *testing.T) {
+	f, _ := New(1000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+	for i := 0; i < 500; i++ {
+		f.Insert([]byte(fmt.Sprintf("item-%d", i)))
+	}
+	data, _ := f.MarshalBinary()
+
+	for _, size := range []int{0, 10, headerSize, headerSize + 1, len(data) / 2, len(data) - 1} {
+		t.Run(fmt.Sprintf("%dbytes", size), func(t *testing.T) {
+			_, err := Read(bytes.NewReader(data[:size]))
+			if !errors.Is(err, ErrInvalidFormat) || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Expected truncation error, got %v", err)
+			}
+
+			g, _ := New(1000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+			g.Insert([]byte("resident"))
+			if _, err := g.ReadFrom(bytes.NewReader(data[:size])); err == nil {
+				t.Error("ReadFrom accepted a truncated stream")
+			}
+			if g.Count() != 1 || !g.Lookup([]byte("resident")) {
+				t.Error("Filter should be unchanged after failed ReadFrom")
+			}
+		})
+	}
+}
+
+// TestReadFromIncompatible tests that ReadFrom rejects other hash configurations
+func TestReadFromIncompatible(t *testing.T) {
+	src, _ := New(1000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+	var buf bytes.Buffer
+	src.WriteTo(&buf)
+
+	dst, _ := New(1000, 4, 8, 500, hash.HashStrategyFNV, 32)
+	if _, err := dst.ReadFrom(&buf); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Expected ErrIncompatible, got %v", err)
+	}
+}
+
+// TestDecodeLegacyLayouts tests that filters written before format version 3,
+// whose fingerprints and indices share the low hash bits, are still readable
+// and keep using that layout
+func TestDecodeLegacyLayouts(t *testing.T) {
+	for _, name := range []string{"v1-fnv-12", "v1-crc32-16", "v1-xxhash-8", "v2-xxhash-16-grown"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join("testdata", name+".bin")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if f.HashLayout() != hash.LayoutLowBits {
+				t.Errorf("HashLayout() = %v, want %v", f.HashLayout(), hash.LayoutLowBits)
+			}
+			mapped, err := OpenMmap(path)
+			if err != nil {
+				t.Fatalf("OpenMmap failed: %v", err)
+			}
+			defer mapped.Close()
+
+			items := make([][]byte, 200)
+			for i := range items {
+				items[i] = []byte(fmt.Sprintf("golden-%d", i))
+				if !f.Lookup(items[i]) || !mapped.Lookup(items[i]) {
+					t.Fatalf("False negative for %q", items[i])
+				}
+			}
+			for i, found := range f.LookupBatch(items) {
+				if !found {
+					t.Fatalf("LookupBatch missed %q", items[i])
+				}
+			}
+
+			if again, _ := f.MarshalBinary(); !bytes.Equal(again, data) {
+				t.Errorf("Re-encoded filter differs from %s", path)
+			}
+
+			fresh, _ := NewWithConfig(f.Capacity(), Config{
+				BucketSize:      f.BucketSize(),
+				FingerprintBits: f.FingerprintBits(),
+				MaxKicks:        500,
+				HashStrategy:    f.HashStrategy(),
+				BatchSize:       32,
+				VictimCacheSize: DefaultVictimCacheSize,
+				GrowthBits:      uint(bits.TrailingZeros(f.GrowthFactor())),
+			})
+			var incompatible *IncompatibleError
+			if _, err := fresh.ReadFrom(bytes.NewReader(data)); !errors.As(err, &incompatible) || incompatible.Field != "hash layout" {
+				t.Errorf("Expected hash layout IncompatibleError, got %v", err)
+			}
+			if err := fresh.Merge(f); !errors.As(err, &incompatible) || incompatible.Field != "hash layout" {
+				t.Errorf("Expected hash layout IncompatibleError from Merge, got %v", err)
+			}
+
+			// The restored filter keeps hashing new items the old way
+			added := insertItems(t, f, "added", 100)
+			decoded, _ := Decode(mustMarshal(t, f))
+			for _, item := range append(items, added...) {
+				if !decoded.Lookup(item) {
+					t.Fatalf("False negative for %q after another round trip", item)
+				}
+			}
+		})
+	}
+}
+
+// TestEncodeHashLayout tests that new unseeded filters are written as
+// version 3 and that unknown layouts are rejected
+func TestEncodeHashLayout(t *testing.T) {
+	f := mustNew(t, 1024, 4, 16, hash.HashStrategyCRC32)
+	if f.HashLayout() != hash.LayoutDisjoint {
+		t.Errorf("HashLayout() = %v, want %v", f.HashLayout(), hash.LayoutDisjoint)
+	}
+	data := mustMarshal(t, f)
+	if data[4] != 3 || hash.Layout(data[41]) != hash.LayoutDisjoint {
+		t.Errorf("Written as version %d with layout %d", data[4], data[41])
+	}
+
+	data[41] = 0
+	binary.LittleEndian.PutUint32(data[len(data)-checksumSize:], crc32.Checksum(data[:len(data)-checksumSize], crcTable))
+	if _, err := Decode(data); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat for layout 0, got %v", err)
+	}
+}
+
+// newKeyed returns a filter with the given seed and hash strategy, keyed
+// with key when the strategy is SipHash
+func newKeyed(t *testing.T, strategy hash.HashStrategy, seed uint64, key [16]byte) *Filter {
+	t.Helper()
+	f, err := NewWithConfig(4096, Config{
+		BucketSize:      4,
+		FingerprintBits: 16,
+		MaxKicks:        500,
+		HashStrategy:    strategy,
+		BatchSize:       32,
+		VictimCacheSize: DefaultVictimCacheSize,
+		Seed:            seed,
+		SipKey:          key,
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig failed: %v", err)
+	}
+	return f
+}
+
+// TestSeedSerialization tests that seeded filters are written as version 4,
+// keep their seed through a round trip and are only combined with filters
+// with the same seed
+func TestSeedSerialization(t *testing.T) {
+	for _, strategy := range []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			f := newKeyed(t, strategy, 0x5eed, [16]byte{})
+			items := insertItems(t, f, "seeded", 1000)
+			data := mustMarshal(t, f)
+			if data[4] != 4 || binary.LittleEndian.Uint64(data[48:]) != 0x5eed {
+				t.Errorf("Written as version %d with seed %#x", data[4], binary.LittleEndian.Uint64(data[48:]))
+			}
+			if plain := mustMarshal(t, newKeyed(t, strategy, 0, [16]byte{})); plain[4] != 3 {
+				t.Errorf("Unseeded filter written as version %d, want 3", plain[4])
+			}
+
+			decoded, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if decoded.HashParams().Seed != 0x5eed {
+				t.Errorf("Decoded seed %#x, want 0x5eed", decoded.HashParams().Seed)
+			}
+			mapped, err := OpenMmap(writeFilterFile(t, f))
+			if err != nil {
+				t.Fatalf("OpenMmap failed: %v", err)
+			}
+			defer mapped.Close()
+			grown, err := f.Grow(2)
+			if err != nil {
+				t.Fatalf("Grow failed: %v", err)
+			}
+			for _, item := range items {
+				if !decoded.Lookup(item) || !mapped.Lookup(item) || !grown.Lookup(item) {
+					t.Fatalf("False negative for %q", item)
+				}
+			}
+
+			other := newKeyed(t, strategy, 0x5eee, [16]byte{})
+			var incompatible *IncompatibleError
+			if _, err := other.ReadFrom(bytes.NewReader(data)); !errors.As(err, &incompatible) || incompatible.Field != "seed" {
+				t.Errorf("Expected seed IncompatibleError, got %v", err)
+			}
+			if err := other.Merge(f); !errors.As(err, &incompatible) || incompatible.Field != "seed" {
+				t.Errorf("Expected seed IncompatibleError from Merge, got %v", err)
+			}
+			if err := newKeyed(t, strategy, 0x5eed, [16]byte{}).Merge(f); err != nil {
+				t.Errorf("Merge with the same seed failed: %v", err)
+			}
+		})
+	}
+}
+
+// TestSipHashSerialization tests that SipHash filters are read only with
+// their key, which isn't written
+func TestSipHashSerialization(t *testing.T) {
+	key := [16]byte{0: 1, 7: 2, 15: 3}
+	f := newKeyed(t, hash.HashStrategySipHash, 0, key)
+	items := insertItems(t, f, "keyed", 1000)
+	data := mustMarshal(t, f)
+	if data[4] != 4 || binary.LittleEndian.Uint64(data[56:]) != hash.SipHash64(key, nil) {
+		t.Errorf("Written as version %d with key check %#x", data[4], binary.LittleEndian.Uint64(data[56:]))
+	}
+	if bytes.Contains(data, key[:]) {
+		t.Error("Serialized filter contains the key")
+	}
+
+	if _, err := Decode(data); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("Expected ErrKeyRequired from Decode, got %v", err)
+	}
+	var incompatible *IncompatibleError
+	if _, err := ReadWith(bytes.NewReader(data), hash.Params{Key: [16]byte{1}}); !errors.As(err, &incompatible) || incompatible.Field != "SipHash key" {
+		t.Errorf("Expected SipHash key IncompatibleError, got %v", err)
+	}
+	path := writeFilterFile(t, f)
+	if _, err := OpenMmap(path); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("Expected ErrKeyRequired from OpenMmap, got %v", err)
+	}
+
+	decoded, err := ReadWith(bytes.NewReader(data), hash.Params{Key: key})
+	if err != nil {
+		t.Fatalf("ReadWith failed: %v", err)
+	}
+	mapped, err := OpenMmapWith(path, hash.Params{Key: key})
+	if err != nil {
+		t.Fatalf("OpenMmapWith failed: %v", err)
+	}
+	defer mapped.Close()
+	for _, item := range items {
+		if !decoded.Lookup(item) || !mapped.Lookup(item) {
+			t.Fatalf("False negative for %q", item)
+		}
+	}
+
+	if _, err := newKeyed(t, hash.HashStrategySipHash, 0, [16]byte{1}).ReadFrom(bytes.NewReader(data)); !errors.As(err, &incompatible) || incompatible.Field != "SipHash key" {
+		t.Errorf("Expected SipHash key IncompatibleError from ReadFrom, got %v", err)
+	}
+	if _, err := newKeyed(t, hash.HashStrategySipHash, 0, key).ReadFrom(bytes.NewReader(data)); err != nil {
+		t.Errorf("ReadFrom with the same key failed: %v", err)
+	}
+}
+
+// TestCustomHashSerialization tests that filters using a custom hash
+// function are read only with a function giving the same key check
+func TestCustomHashSerialization(t *testing.T) {
+	fn := func(item []byte) uint64 { return hash.SipHash64([16]byte{5}, item) }
+	other := func(item []byte) uint64 { return hash.SipHash64([16]byte{6}, item) }
+	f, _ := NewWithConfig(4096, Config{
+		BucketSize:      4,
+		FingerprintBits: 16,
+		MaxKicks:        500,
+		HashStrategy:    hash.HashStrategyCustom,
+		BatchSize:       32,
+		VictimCacheSize: DefaultVictimCacheSize,
+		CustomHash:      fn,
+	})
+	items := insertItems(t, f, "custom", 1000)
+	data := mustMarshal(t, f)
+	if data[4] != 4 || hash.HashStrategy(data[5]) != hash.HashStrategyCustom || binary.LittleEndian.Uint64(data[56:]) != fn([]byte{}) {
+		t.Errorf("Written as version %d, strategy %d, key check %#x", data[4], data[5], binary.LittleEndian.Uint64(data[56:]))
+	}
+
+	if _, err := Decode(data); !errors.Is(err, ErrHashRequired) {
+		t.Errorf("Expected ErrHashRequired from Decode, got %v", err)
+	}
+	if _, err := ReadWith(bytes.NewReader(data), hash.Params{}); !errors.Is(err, ErrHashRequired) {
+		t.Errorf("Expected ErrHashRequired from ReadWith without a function, got %v", err)
+	}
+	var incompatible *IncompatibleError
+	if _, err := ReadWith(bytes.NewReader(data), hash.Params{Func: other}); !errors.As(err, &incompatible) || incompatible.Field != "custom hash" {
+		t.Errorf("Expected custom hash IncompatibleError, got %v", err)
+	}
+
+	decoded, err := ReadWith(bytes.NewReader(data), hash.Params{Func: fn})
+	if err != nil {
+		t.Fatalf("ReadWith failed: %v", err)
+	}
+	mapped, err := OpenMmapWith(writeFilterFile(t, f), hash.Params{Func: fn})
+	if err != nil {
+		t.Fatalf("OpenMmapWith failed: %v", err)
+	}
+	defer mapped.Close()
+	for _, item := range items {
+		if !decoded.Lookup(item) || !mapped.Lookup(item) {
+			t.Fatalf("False negative for %q", item)
+		}
+	}
+
+	g, _ := NewWithConfig(4096, Config{
+		BucketSize:      4,
+		FingerprintBits: 16,
+		MaxKicks:        500,
+		HashStrategy:    hash.HashStrategyCustom,
+		BatchSize:       32,
+		VictimCacheSize: DefaultVictimCacheSize,
+		CustomHash:      other,
+	})
+	if err := g.Merge(f); !errors.As(err, &incompatible) || incompatible.Field != "custom hash" {
+		t.Errorf("Expected custom hash IncompatibleError from Merge, got %v", err)
+	}
+}
+
+// mustMarshal encodes f, failing the test on error
+func mustMarshal(t *testing.T, f *Filter) []byte {
+	t.Helper()
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	return data
+}
+
+// TestWriteToPropagatesErrors tests that writer failures are returned
+func TestWriteToPropagatesErrors(t *testing.T) {
+	f, _ := New(100000, 4, 8, 500, hash.HashStrategyXXHash, 32)
+
+	errWrite := errors.New("disk full")
+	w := &failingWriter{limit: headerSize + 100, err: errWrite}
+	n, err := f.WriteTo(w)
+	if !errors.Is(err, errWrite) {
+		t.Errorf("Expected write error, got %v", err)
+	}
+	if n > int64(w.limit) {
+		t.Errorf("Reported %d bytes written, writer accepted at most %d", n, w.limit)
+	}
+}
+
+// failingWriter accepts limit bytes and then fails with err
+type failingWriter struct {
+	limit   int
+	written int
+	err     error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		n := w.limit - w.written
+		w.written = w.limit
+		return n, w.err
+	}
+	w.written += len(p)
+	return len(p), nil
+}
+
+// mustNew creates a filter with default kick and batch settings
+func mustNew(t *testing.T, capacity, bucketSize, fingerprintBits uint, strategy hash.HashStrategy) *Filter {
+	t.Helper()
+	f, err := New(capacity, bucketSize, fingerprintBits, 500, strategy, 32)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return f
+}
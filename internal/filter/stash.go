@@ -0,0 +1,60 @@
+//go:build amd64 || arm64
+
+package filter
+
+const (
+	// DefaultVictimCacheSize is the number of stash entries used when none is configured
+	DefaultVictimCacheSize = 1
+
+	// MaxVictimCacheSize is the largest supported stash
+	MaxVictimCacheSize = 255
+)
+
+// victim is a fingerprint that could not be placed in the table after
+// maxKicks relocations, together with one of its two candidate buckets.
+// Its alternate bucket is derived from index and fp as usual.
+type victim struct {
+	index uint
+	fp    uint16
+}
+
+// matches reports whether v holds fp for an item with buckets i1 and i2
+func (v victim) matches(i1, i2 uint, fp uint16) bool {
+	return v.fp == fp && (v.index == i1 || v.index == i2)
+}
+
+// stashContains reports whether stash holds fp for buckets i1 and i2
+func stashContains(stash []victim, i1, i2 uint, fp uint16) bool {
+	for _, v := range stash {
+		if v.matches(i1, i2, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// stashRemove removes one stash entry holding fp for buckets i1 and i2.
+// Callers must hold f.mu for writing.
+func (f *Filter) stashRemove(i1, i2 uint, fp uint16) bool {
+	for j, v := range f.stash {
+		if v.matches(i1, i2, fp) {
+			f.stash = append(f.stash[:j], f.stash[j+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// drainStash moves stashed fingerprints back into the table wherever one of
+// their buckets has a free slot. Callers must hold f.mu for writing.
+func (f *Filter) drainStash() {
+	kept := f.stash[:0]
+	for _, v := range f.stash {
+		if f.table.Insert(v.index, v.fp) ||
+			f.table.Insert(f.hash.GetAltIndex(v.index, v.fp, f.numBuckets), v.fp) {
+			continue
+		}
+		kept = append(kept, v)
+	}
+	f.stash = kept
+}
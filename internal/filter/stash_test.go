@@ -0,0 +1,269 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// fillPastCapacity inserts items until well past the point where inserts
+// start failing, and returns the items that were accepted
+func fillPastCapacity(f *Filter, prefix string) [][]byte {
+	var accepted [][]byte
+	for i := uint(0); i < 2*f.Capacity(); i++ {
+		item := []byte(fmt.Sprintf("%s-%d", prefix, i))
+		if f.Insert(item) {
+			accepted = append(accepted, item)
+		}
+	}
+	return accepted
+}
+
+// TestStashNoFalseNegatives tests that overfilling never loses an accepted item
+func TestStashNoFalseNegatives(t *testing.T) {
+	strategies := []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash}
+
+	for _, strategy := range strategies {
+		for _, victims := range []uint{1, 4, 16} {
+			t.Run(fmt.Sprintf("%s/Victims%d", strategy, victims), func(t *testing.T) {
+				f, _ := NewWithConfig(256, Config{
+					BucketSize:      4,
+					FingerprintBits: 12,
+					MaxKicks:        50,
+					HashStrategy:    strategy,
+					BatchSize:       32,
+					VictimCacheSize: victims,
+				})
+
+				accepted := fillPastCapacity(f, "overfill")
+
+				if uint(len(f.stash)) != victims {
+					t.Errorf("Expected a full stash of %d after overfilling, got %d", victims, len(f.stash))
+				}
+				if f.Count() != uint(len(accepted)) {
+					t.Errorf("Count() = %d, accepted %d items", f.Count(), len(accepted))
+				}
+
+				for _, item := range accepted {
+					if !f.Lookup(item) {
+						t.Errorf("False negative for accepted item %q", item)
+					}
+				}
+				for i, found := range f.LookupBatch(accepted) {
+					if !found {
+						t.Errorf("LookupBatch false negative for accepted item %q", accepted[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestStashDrainOnDelete tests that deletes move stashed fingerprints back
+// into the table and make room for new inserts
+func TestStashDrainOnDelete(t *testing.T) {
+	f := mustNew(t, 256, 4, 12, hash.HashStrategyXXHash)
+	accepted := fillPastCapacity(f, "drain")
+
+	if len(f.stash) != DefaultVictimCacheSize {
+		t.Fatalf("Expected a full stash, got %d entries", len(f.stash))
+	}
+
+	// Every accepted item can be deleted, including the one in the stash.
+	// Draining doesn't relocate, so enough is deleted that both buckets of
+	// every stashed fingerprint almost surely lose one.
+	deleted := accepted[:len(accepted)/2]
+	for _, item := range deleted {
+		if !f.Delete(item) {
+			t.Errorf("Delete failed for accepted item %q", item)
+		}
+	}
+
+	if len(f.stash) != 0 {
+		t.Errorf("Stash should drain after deletes free slots, %d entries left", len(f.stash))
+	}
+	if f.Count() != uint(len(accepted)-len(deleted)) {
+		t.Errorf("Count() = %d, want %d", f.Count(), len(accepted)-len(deleted))
+	}
+	for _, item := range accepted[len(deleted):] {
+		if !f.Lookup(item) {
+			t.Errorf("False negative for remaining item %q", item)
+		}
+	}
+
+	if !f.Insert([]byte("after-drain")) {
+		t.Error("Insert should succeed after deletes")
+	}
+}
+
+// TestStashDelete tests deleting an item whose fingerprint is in the stash
+func TestStashDelete(t *testing.T) {
+	f := mustNew(t, 64, 4, 16, hash.HashStrategyFNV)
+	accepted := fillPastCapacity(f, "stashed")
+
+	if len(f.stash) != 1 {
+		t.Fatalf("Expected one stashed fingerprint, got %d", len(f.stash))
+	}
+	v := f.stash[0]
+
+	for _, item := range accepted {
+		i1, i2, fp := f.hash.GetIndices(item, f.numBuckets)
+		if !v.matches(i1, i2, fp) {
+			continue
+		}
+		if !f.Delete(item) {
+			t.Fatalf("Delete failed for stashed item %q", item)
+		}
+		if stashContains(f.stash, i1, i2, fp) {
+			t.Error("Stash entry should be removed by Delete")
+		}
+		return
+	}
+	t.Fatal("No accepted item matches the stashed fingerprint")
+}
+
+// TestStashReset tests that Reset clears the stash
+func TestStashReset(t *testing.T) {
+	f := mustNew(t, 64, 4, 8, hash.HashStrategyCRC32)
+	fillPastCapacity(f, "reset")
+
+	f.Reset()
+	if len(f.stash) != 0 || f.Count() != 0 {
+		t.Errorf("Reset left %d stash entries and count %d", len(f.stash), f.Count())
+	}
+}
+
+// TestStashSerialization tests that stashed fingerprints survive a round trip
+func TestStashSerialization(t *testing.T) {
+	f, _ := NewWithConfig(256, Config{
+		BucketSize:      4,
+		FingerprintBits: 12,
+		MaxKicks:        50,
+		HashStrategy:    hash.HashStrategyXXHash,
+		BatchSize:       32,
+		VictimCacheSize: 8,
+	})
+	accepted := fillPastCapacity(f, "serialize")
+
+	data, _ := f.MarshalBinary()
+	g, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if len(g.stash) != len(f.stash) || g.victimCacheSize != 8 {
+		t.Errorf("Restored stash has %d/%d entries, want %d/8", len(g.stash), g.victimCacheSize, len(f.stash))
+	}
+
+	m, err := OpenMmap(writeFilterFile(t, f))
+	if err != nil {
+		t.Fatalf("OpenMmap failed: %v", err)
+	}
+	defer m.Close()
+
+	for _, item := range accepted {
+		if !g.Lookup(item) {
+			t.Errorf("False negative after Decode for %q", item)
+		}
+		if !m.Lookup(item) {
+			t.Errorf("False negative in mapped filter for %q", item)
+		}
+	}
+}
+
+// TestStashInsertUnique tests that InsertUnique finds fingerprints in the
+// stash as well as the table
+func TestStashInsertUnique(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			f, _ := NewWithConfig(256, Config{
+				BucketSize:      4,
+				FingerprintBits: 12,
+				MaxKicks:        50,
+				HashStrategy:    hash.HashStrategyXXHash,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+				LockStripes:     stripes,
+			})
+			accepted := fillPastCapacity(f, "unique")
+			if len(f.stash) == 0 {
+				t.Fatal("Expected stashed fingerprints after overfilling")
+			}
+
+			count := f.Count()
+			for _, item := range accepted {
+				if status := f.InsertUnique(item); status != Duplicate {
+					t.Fatalf("InsertUnique(%q) = %v, want %v", item, status, Duplicate)
+				}
+			}
+			if f.Count() != count {
+				t.Errorf("Count() changed from %d to %d", count, f.Count())
+			}
+		})
+	}
+}
+
+// TestStashInsertEx tests that InsertEx reports relocations, stashed
+// fingerprints and failures while overfilling
+func TestStashInsertEx(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			f, _ := NewWithConfig(256, Config{
+				BucketSize:      4,
+				FingerprintBits: 12,
+				MaxKicks:        50,
+				HashStrategy:    hash.HashStrategyXXHash,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+				LockStripes:     stripes,
+				SetSemantics:    true,
+			})
+
+			var inserted, kicked, displaced, full uint
+			var accepted [][]byte
+			for i := uint(0); i < 2*f.Capacity(); i++ {
+				item := []byte(fmt.Sprintf("ex-%d", i))
+				r := f.InsertEx(item)
+				switch {
+				case i == 0 && r != InsertResult{Status: Inserted}:
+					t.Fatalf("First insert = %+v, want a plain insert", r)
+				case r.Status == Full && r.Displaced:
+					t.Fatalf("Failed insert %q reports a displaced victim", item)
+				case stripes == 0 && r.Kicks > f.maxKicks:
+					t.Fatalf("Insert of %q took %d kicks, limit %d", item, r.Kicks, f.maxKicks)
+				case stripes == 0 && r.Displaced && r.Kicks != f.maxKicks:
+					t.Fatalf("Insert of %q stashed a victim after %d kicks", item, r.Kicks)
+				}
+
+				switch r.Status {
+				case Inserted:
+					inserted++
+					accepted = append(accepted, item)
+				case Full:
+					full++
+				}
+				if r.Kicks > 0 {
+					kicked++
+				}
+				if r.Displaced {
+					displaced++
+				}
+			}
+
+			if kicked == 0 || displaced == 0 || full == 0 {
+				t.Errorf("Expected relocations, stashed victims and failures; got %d, %d, %d", kicked, displaced, full)
+			}
+			if f.Count() != inserted {
+				t.Errorf("Count() = %d, %d inserts reported", f.Count(), inserted)
+			}
+			for i, r := range f.InsertBatchEx(accepted) {
+				if r.Status != Duplicate {
+					t.Fatalf("InsertBatchEx(%q) = %+v, want %v", accepted[i], r, Duplicate)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Stats is a snapshot of a filter's occupancy and insert history
+type Stats struct {
+	Items      uint    // Stored fingerprints, including stashed ones
+	Capacity   uint    // Fingerprint slots in the table
+	LoadFactor float64 // Items / Capacity
+
+	// Occupancy[n] is the number of buckets holding n fingerprints,
+	// for n from 0 to the bucket size
+	Occupancy   []uint
+	FullBuckets uint // Occupancy[bucket size]
+	Stashed     uint // Fingerprints in the victim stash
+
+	Inserts        uint64  // Successful inserts; duplicates skipped by set semantics don't count
+	FailedInserts  uint64  // Inserts rejected because the filter was full
+	Kicks          uint64  // Fingerprints relocated by all inserts
+	KicksPerInsert float64 // Kicks / Inserts
+
+	// EstimatedFalsePositiveRate is the probability that a lookup of an
+	// absent item matches one of the fingerprints currently stored in its
+	// two buckets: 1 - (1 - 2^-f)^(2 b LoadFactor)
+	EstimatedFalsePositiveRate float64
+
+	MemoryBytes uint // Size of the fingerprint table
+}
+
+// insertCounters accumulate the outcome of inserts. They are atomic so that
+// striped-mode writers can update them under their own stripe and Stats can
+// read them without stopping writers.
+type insertCounters struct {
+	inserts atomic.Uint64
+	failed  atomic.Uint64
+	kicks   atomic.Uint64
+}
+
+// record adds the outcome of one insert
+func (c *insertCounters) record(r InsertResult) {
+	switch r.Status {
+	case Inserted:
+		c.inserts.Add(1)
+	case Full:
+		c.failed.Add(1)
+	}
+	if r.Kicks > 0 {
+		c.kicks.Add(uint64(r.Kicks))
+	}
+}
+
+func (c *insertCounters) reset() {
+	c.inserts.Store(0)
+	c.failed.Store(0)
+	c.kicks.Store(0)
+}
+
+// addTo adds the counters to s
+func (c *insertCounters) addTo(s *Stats) {
+	s.Inserts += c.inserts.Load()
+	s.FailedInserts += c.failed.Load()
+	s.Kicks += c.kicks.Load()
+}
+
+// Stats returns the filter's statistics. The occupancy histogram takes a
+// pass over every bucket, during which inserts and deletes wait; the insert
+// counters are maintained as inserts run and cost nothing to read.
+func (f *Filter) Stats() Stats {
+	f.rlockAll()
+	defer f.runlockAll()
+
+	s := Stats{
+		Items:       f.count(),
+		Capacity:    f.numBuckets * f.bucketSize,
+		Occupancy:   make([]uint, f.bucketSize+1),
+		MemoryBytes: uint(len(f.table.Bytes())),
+	}
+	for i := uint(0); i < f.numBuckets; i++ {
+		s.Occupancy[f.table.Count(i)]++
+	}
+	s.FullBuckets = s.Occupancy[f.bucketSize]
+
+	if f.stripes != nil {
+		f.stashMu.Lock()
+	}
+	s.Stashed = uint(len(f.stash))
+	if f.stripes != nil {
+		f.stashMu.Unlock()
+	}
+
+	f.counters.addTo(&s)
+	for i := range f.stripes {
+		f.stripes[i].counters.addTo(&s)
+	}
+
+	s.LoadFactor = float64(s.Items) / float64(s.Capacity)
+	if s.Inserts > 0 {
+		s.KicksPerInsert = float64(s.Kicks) / float64(s.Inserts)
+	}
+	s.EstimatedFalsePositiveRate = EstimatedFalsePositiveRate(f.bucketSize, f.fingerprintBits-f.growthBits, s.LoadFactor)
+	return s
+}
+
+// EstimatedFalsePositiveRate returns the false positive rate of a filter at
+// the given load factor. A lookup compares its fingerprint against the
+// 2b*loadFactor fingerprints stored in its two buckets on average, each
+// matching with probability 1/2^f. At full load it approaches
+// FalsePositiveBound.
+func EstimatedFalsePositiveRate(bucketSize, fingerprintBits uint, loadFactor float64) float64 {
+	comparisons := 2 * float64(bucketSize) * loadFactor
+	return -math.Expm1(comparisons * math.Log1p(-math.Exp2(-float64(fingerprintBits))))
+}
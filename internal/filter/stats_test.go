@@ -0,0 +1,234 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// TestStats tests that Stats agrees with the table and with the results
+// reported by InsertEx
+func TestStats(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			f, _ := NewWithConfig(1024, Config{
+				BucketSize:      4,
+				FingerprintBits: 12,
+				MaxKicks:        50,
+				HashStrategy:    hash.HashStrategyXXHash,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+				LockStripes:     stripes,
+			})
+
+			if s := f.Stats(); s.Items != 0 || s.Occupancy[0] != f.numBuckets || s.EstimatedFalsePositiveRate != 0 {
+				t.Errorf("Unexpected stats for an empty filter: %+v", s)
+			}
+
+			var inserts, failed, kicks uint64
+			for i := uint(0); i < 2*f.Capacity(); i++ {
+				r := f.InsertEx([]byte(fmt.Sprintf("stats-%d", i)))
+				if r.Status == Full {
+					failed++
+				} else {
+					inserts++
+				}
+				kicks += uint64(r.Kicks)
+			}
+
+			s := f.Stats()
+			if s.Inserts != inserts || s.FailedInserts != failed || s.Kicks != kicks || failed == 0 {
+				t.Errorf("Counters %d/%d/%d, want %d/%d/%d", s.Inserts, s.FailedInserts, s.Kicks, inserts, failed, kicks)
+			}
+			if want := float64(kicks) / float64(inserts); s.KicksPerInsert != want {
+				t.Errorf("KicksPerInsert = %f, want %f", s.KicksPerInsert, want)
+			}
+			if s.Items != f.Count() || s.Capacity != f.Capacity() || s.LoadFactor != f.LoadFactor() ||
+				s.MemoryBytes != f.MemoryUsage() || s.Stashed != uint(len(f.stash)) {
+				t.Errorf("Stats disagree with the filter: %+v", s)
+			}
+
+			buckets, stored := uint(0), uint(0)
+			for n, c := range s.Occupancy {
+				buckets += c
+				stored += uint(n) * c
+			}
+			if buckets != f.numBuckets || stored+s.Stashed != s.Items {
+				t.Errorf("Occupancy covers %d buckets and %d items, want %d and %d", buckets, stored+s.Stashed, f.numBuckets, s.Items)
+			}
+			if s.FullBuckets != s.Occupancy[f.bucketSize] || s.FullBuckets == 0 {
+				t.Errorf("FullBuckets = %d, histogram %v", s.FullBuckets, s.Occupancy)
+			}
+			if s.EstimatedFalsePositiveRate <= 0 || s.EstimatedFalsePositiveRate > f.FalsePositiveRate()*s.LoadFactor {
+				t.Errorf("EstimatedFalsePositiveRate = %g, bound %g", s.EstimatedFalsePositiveRate, f.FalsePositiveRate())
+			}
+
+			f.Reset()
+			if s := f.Stats(); s.Inserts != 0 || s.FailedInserts != 0 || s.Kicks != 0 || s.Items != 0 {
+				t.Errorf("Stats not cleared by Reset: %+v", s)
+			}
+		})
+	}
+}
+
+// TestStatsReadFrom tests that ReadFrom clears the insert counters along
+// with the contents it replaces
+func TestStatsReadFrom(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		t.Run(fmt.Sprintf("Stripes%d", stripes), func(t *testing.T) {
+			cfg := Config{
+				BucketSize:      4,
+				FingerprintBits: 12,
+				MaxKicks:        50,
+				HashStrategy:    hash.HashStrategyXXHash,
+				BatchSize:       32,
+				VictimCacheSize: 4,
+				LockStripes:     stripes,
+			}
+			f, _ := NewWithConfig(1024, cfg)
+			for i := uint(0); i < 2*f.Capacity(); i++ {
+				f.Insert([]byte(fmt.Sprintf("stats-%d", i)))
+			}
+			if s := f.Stats(); s.Inserts == 0 || s.FailedInserts == 0 || s.Kicks == 0 {
+				t.Fatalf("Counters not recorded before ReadFrom: %+v", s)
+			}
+
+			src, _ := NewWithConfig(1024, cfg)
+			for i := 0; i < 100; i++ {
+				src.Insert([]byte(fmt.Sprintf("restored-%d", i)))
+			}
+			var buf bytes.Buffer
+			if _, err := src.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo failed: %v", err)
+			}
+			if _, err := f.ReadFrom(&buf); err != nil {
+				t.Fatalf("ReadFrom failed: %v", err)
+			}
+
+			s := f.Stats()
+			if s.Inserts != 0 || s.FailedInserts != 0 || s.Kicks != 0 || s.KicksPerInsert != 0 {
+				t.Errorf("Counters not cleared by ReadFrom: %+v", s)
+			}
+			if s.Items != src.Count() {
+				t.Errorf("Items = %d after ReadFrom, want %d", s.Items, src.Count())
+			}
+		})
+	}
+}
+
+// TestStatsConcurrent tests reading Stats while writers run.
+// Run with -race to check the counters.
+func TestStatsConcurrent(t *testing.T) {
+	for _, stripes := range []uint{0, 8} {
+		f := newStriped(t, 8192, 4, 12, stripes)
+
+		var wg sync.WaitGroup
+		var inserted atomic.Uint64
+		for w := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 1000 {
+					if f.Insert([]byte(fmt.Sprintf("writer-%d-%d", w, i))) {
+						inserted.Add(1)
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				f.Stats()
+			}
+		}()
+		wg.Wait()
+
+		if s := f.Stats(); s.Inserts != inserted.Load() || s.Inserts+s.FailedInserts != 4000 || s.Items != f.Count() {
+			t.Errorf("Stripes %d: Inserts %d, FailedInserts %d, Items %d; %d inserted", stripes, s.Inserts, s.FailedInserts, s.Items, inserted.Load())
+		}
+	}
+}
+
+// TestEstimatedFalsePositiveRate tests the estimate against its limits
+func TestEstimatedFalsePositiveRate(t *testing.T) {
+	if r := EstimatedFalsePositiveRate(4, 8, 0); r != 0 {
+		t.Errorf("Empty filter estimate = %g, want 0", r)
+	}
+	for _, bits := range []uint{8, 12, 16} {
+		full := EstimatedFalsePositiveRate(4, bits, 1)
+		bound := FalsePositiveBound(4, bits)
+		if full > bound || full < 0.95*bound {
+			t.Errorf("%d bits: full-load estimate %g, bound %g", bits, full, bound)
+		}
+		if half := EstimatedFalsePositiveRate(4, bits, 0.5); math.Abs(half-full/2) > 0.01*full {
+			t.Errorf("%d bits: half-load estimate %g, expected about %g", bits, half, full/2)
+		}
+	}
+}
+
+// TestEmpiricalFalsePositiveRate fills filters of every bucket and
+// fingerprint size with each hash strategy until the first insert needs the
+// stash, then checks that absent items are found no more often than the
+// rate predicted for uniform, independent fingerprints, nor FalsePositiveBound
+// allows. Fingerprints that share bits with the bucket index match far more
+// often. With few fingerprint values, relocation clusters equal fingerprints
+// in the same buckets, so the rate can be somewhat lower than predicted.
+func TestEmpiricalFalsePositiveRate(t *testing.T) {
+	strategies := []hash.HashStrategy{hash.HashStrategyFNV, hash.HashStrategyCRC32, hash.HashStrategyXXHash}
+	minLoad := map[uint]float64{2: 0.75, 4: 0.9, 8: 0.95, 16: 0.95}
+
+	for _, strategy := range strategies {
+		for _, bucketSize := range []uint{2, 4, 8, 16} {
+			for _, fingerprintBits := range []uint{4, 8, 12, 16} {
+				name := fmt.Sprintf("%s/Bucket%d/Bits%d", strategy, bucketSize, fingerprintBits)
+				t.Run(name, func(t *testing.T) {
+					f := mustNew(t, 4096, bucketSize, fingerprintBits, strategy)
+					for i := 0; f.LoadFactor() < 0.95; i++ {
+						if r := f.InsertEx([]byte(fmt.Sprintf("member-%d", i))); r.Status != Inserted || r.Displaced {
+							break
+						}
+					}
+					load := f.LoadFactor()
+					if fingerprintBits > 4 && load < minLoad[bucketSize] {
+						t.Errorf("First relocation failure at load factor %.3f, want at least %.2f", load, minLoad[bucketSize])
+					}
+
+					// Zero fingerprints are stored as 1, so 1 is twice as
+					// likely as any other value and two fingerprints match
+					// with probability (2^f + 2) / 4^f rather than 1/2^f
+					space := math.Exp2(float64(fingerprintBits))
+					match := (space + 2) / (space * space)
+					want := -math.Expm1(2 * float64(bucketSize) * load * math.Log1p(-match))
+					probes := int(min(max(200/want, 20_000), 500_000))
+
+					found := 0
+					for i := range probes {
+						if f.Lookup([]byte(fmt.Sprintf("absent-%d", i))) {
+							found++
+						}
+					}
+
+					got := float64(found) / float64(probes)
+					sigma := math.Sqrt(want * (1 - want) / float64(probes))
+					if got > want+6*sigma {
+						t.Errorf("False positive rate %.3g at load factor %.3f, want at most %.3g", got, load, want+6*sigma)
+					}
+					if bound := FalsePositiveBound(bucketSize, fingerprintBits); got > bound+6*sigma {
+						t.Errorf("False positive rate %.3g exceeds bound %.3g", got, bound)
+					}
+					if est := EstimatedFalsePositiveRate(bucketSize, fingerprintBits, load); math.Abs(est-want) > 0.15*want {
+						t.Errorf("EstimatedFalsePositiveRate = %.3g, want %.3g", est, want)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,489 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/shaia/simdcuckoofilter/internal/bucket"
+)
+
+const (
+	// MaxLockStripes is the largest supported number of lock stripes
+	MaxLockStripes = 1 << 16
+
+	// stripeShift groups 2^stripeShift consecutive buckets under one stripe:
+	// exactly one bucket.IsolationGroup, so stripes never share table words,
+	// and for common layouts at least a cache line.
+	stripeShift = 4
+
+	// stripedPathAttempts is the number of cuckoo paths an insert tries before
+	// falling back to the stash. Paths fail when a concurrent writer changes
+	// one of their buckets.
+	stripedPathAttempts = 4
+
+	// optimisticAttempts is the number of lock-free reads a lookup tries
+	// before locking its buckets
+	optimisticAttempts = 4
+)
+
+// stripe guards the buckets mapped to it in concurrent mode.
+// Stripes are padded to a cache line so that neighbours don't contend.
+type stripe struct {
+	mu       sync.Mutex
+	items    atomic.Int64   // Items added minus items removed under this stripe
+	version  atomic.Uint64  // Odd while a writer modifies the stripe's buckets
+	counters insertCounters // Outcomes of inserts whose first bucket is under this stripe
+	_        [bucket.CacheLineSize - unsafe.Sizeof(sync.Mutex{}) - 16 - unsafe.Sizeof(insertCounters{})]byte
+}
+
+// tableView is the table of a concurrent-mode filter with its bucket count.
+// ReadFrom replaces the view as a whole, so a reader that loaded it hashes
+// and reads against a matching pair.
+type tableView struct {
+	table      bucket.ConcurrentTable
+	numBuckets uint
+}
+
+// pathStep is one move of a cuckoo path: the fingerprint fp in slot pos of
+// bucket index moves to its alternate bucket
+type pathStep struct {
+	index, pos uint
+	fp         uint16
+}
+
+// Concurrent mode
+//
+// With Config.LockStripes set, buckets are partitioned into lock stripes and
+// Insert, Delete and Lookup lock only the stripes of an item's two buckets,
+// always in stripe order. f.mu is then taken only by operations on the whole
+// filter (Reset, ReadFrom, WriteTo), which also lock every stripe, so fields
+// such as table and numBuckets are stable while any stripe is held.
+// Per-item operations hash with the bucket count of f.view before locking and
+// retry if the table was replaced in between.
+//
+// Relocations don't hold locks across kicks. A path to a free slot is found
+// by a random walk that locks one bucket at a time, then applied from its
+// free end backwards, each move copying a fingerprint into its alternate
+// bucket before clearing the original slot while holding both stripes. An
+// item is therefore always in one of its buckets and lookups never miss it.
+// The stash is guarded by stashMu, which is always taken after stripes.
+//
+// Lookups take no locks. Every stripe has a version counter that writers
+// make odd while they modify its buckets and even again before unlocking,
+// like a seqlock. A lookup reads the versions of its stripes, checks both
+// buckets with atomic loads (bucket.ConcurrentTable.ContainsAtomic), and
+// keeps the answer only if the versions are unchanged and even. Otherwise it
+// retries, and after optimisticAttempts tries it locks the buckets. Lookups
+// that would have to search the stash also lock, since stashMu guards it.
+
+// stripeIndex returns the index of the stripe guarding bucket i
+func (f *Filter) stripeIndex(i uint) uint {
+	return (i >> stripeShift) & f.stripeMask
+}
+
+// stripeOf returns the stripe guarding bucket i
+func (f *Filter) stripeOf(i uint) *stripe {
+	return &f.stripes[f.stripeIndex(i)]
+}
+
+// orderedStripes returns the stripes of buckets i1 and i2 in lock order.
+// b is nil when both buckets share a stripe.
+func (f *Filter) orderedStripes(i1, i2 uint) (a, b *stripe) {
+	s1, s2 := f.stripeIndex(i1), f.stripeIndex(i2)
+	if s1 == s2 {
+		return &f.stripes[s1], nil
+	}
+	if s1 > s2 {
+		s1, s2 = s2, s1
+	}
+	return &f.stripes[s1], &f.stripes[s2]
+}
+
+// lockBuckets locks the stripes of buckets i1 and i2. It reports false,
+// holding no locks, if the table no longer has nb buckets.
+func (f *Filter) lockBuckets(i1, i2, nb uint) bool {
+	a, b := f.orderedStripes(i1, i2)
+	a.mu.Lock()
+	if b != nil {
+		b.mu.Lock()
+	}
+	if f.numBuckets != nb {
+		f.unlockBuckets(i1, i2)
+		return false
+	}
+	return true
+}
+
+func (f *Filter) unlockBuckets(i1, i2 uint) {
+	a, b := f.orderedStripes(i1, i2)
+	if b != nil {
+		b.mu.Unlock()
+	}
+	a.mu.Unlock()
+}
+
+// writeLockBuckets is lockBuckets for modifying buckets i1 and i2: it also
+// makes the stripe versions odd, so optimistic lookups retry.
+func (f *Filter) writeLockBuckets(i1, i2, nb uint) bool {
+	if !f.lockBuckets(i1, i2, nb) {
+		return false
+	}
+	a, b := f.orderedStripes(i1, i2)
+	a.version.Add(1)
+	if b != nil {
+		b.version.Add(1)
+	}
+	return true
+}
+
+func (f *Filter) writeUnlockBuckets(i1, i2 uint) {
+	a, b := f.orderedStripes(i1, i2)
+	a.version.Add(1)
+	if b != nil {
+		b.version.Add(1)
+	}
+	f.unlockBuckets(i1, i2)
+}
+
+// lockAll acquires f.mu, every stripe and stashMu, excluding all other operations
+func (f *Filter) lockAll() {
+	f.mu.Lock()
+	for i := range f.stripes {
+		f.stripes[i].mu.Lock()
+		f.stripes[i].version.Add(1)
+	}
+	f.stashMu.Lock()
+}
+
+func (f *Filter) unlockAll() {
+	f.stashMu.Unlock()
+	for i := range f.stripes {
+		f.stripes[i].version.Add(1)
+		f.stripes[i].mu.Unlock()
+	}
+	f.mu.Unlock()
+}
+
+// rlockAll excludes all modifications: it holds f.mu for reading and every
+// stripe. Concurrent readers of the whole filter only contend in concurrent mode.
+func (f *Filter) rlockAll() {
+	f.mu.RLock()
+	for i := range f.stripes {
+		f.stripes[i].mu.Lock()
+	}
+}
+
+func (f *Filter) runlockAll() {
+	for i := range f.stripes {
+		f.stripes[i].mu.Unlock()
+	}
+	f.mu.RUnlock()
+}
+
+// count returns the number of stored items.
+// Callers must hold f.mu.
+func (f *Filter) count() uint {
+	if f.stripes == nil {
+		return f.numItems
+	}
+	total := int64(0)
+	for i := range f.stripes {
+		total += f.stripes[i].items.Load()
+	}
+	return uint(max(total, 0))
+}
+
+// setCount replaces the number of stored items.
+// Callers must hold f.mu for writing and, in concurrent mode, every stripe.
+func (f *Filter) setCount(n uint) {
+	f.numItems = n
+	for i := range f.stripes {
+		f.stripes[i].items.Store(0)
+	}
+	if f.stripes != nil {
+		f.stripes[0].items.Store(int64(n))
+	}
+}
+
+// indexFunc returns the buckets and fingerprint of an item for a table of
+// nb buckets. Concurrent-mode operations call it again when the table is
+// replaced while they run.
+type indexFunc func(nb uint) (i1, i2 uint, fp uint16)
+
+// itemIndices returns the indexFunc hashing item
+func (f *Filter) itemIndices(item []byte) indexFunc {
+	return func(nb uint) (uint, uint, uint16) { return f.hash.GetIndices(item, nb) }
+}
+
+func (f *Filter) insertStriped(indices indexFunc, unique bool) InsertResult {
+	for {
+		nb := f.view.Load().numBuckets
+		i1, i2, fp := indices(nb)
+
+		if f.stashLen.Load() > 0 {
+			f.drainStriped(nb)
+		}
+		if result, current := f.insertFingerprint(i1, i2, fp, nb, unique); current {
+			f.stripeOf(i1).counters.record(result)
+			return result
+		}
+	}
+}
+
+// insertFingerprint stores fp in bucket i1 or i2, freeing a slot along a
+// cuckoo path when both are full and falling back to the stash. If unique is
+// set, fp is not stored again if present. current is false if the table was
+// replaced and the caller must hash again. Kicks counts the fingerprints
+// moved by every attempt, including ones whose slot was then taken.
+func (f *Filter) insertFingerprint(i1, i2 uint, fp uint16, nb uint, unique bool) (result InsertResult, current bool) {
+	exhausted := false
+	for attempt := 1; ; attempt++ {
+		if !f.writeLockBuckets(i1, i2, nb) {
+			return result, false
+		}
+		if unique && f.containsStriped(i1, i2, fp) {
+			f.writeUnlockBuckets(i1, i2)
+			result.Status = Duplicate
+			return result, true
+		}
+		stored := f.table.Insert(i1, fp) || f.table.Insert(i2, fp)
+		if !stored && exhausted {
+			stored = f.stashAppend(i1, fp)
+			result.Displaced = stored
+		}
+		if stored {
+			f.stripeOf(i1).items.Add(1)
+			f.writeUnlockBuckets(i1, i2)
+			result.Status = Inserted
+			return result, true
+		}
+		// As in single-lock mode, a full stash means the filter is full
+		if exhausted || uint(f.stashLen.Load()) == f.victimCacheSize {
+			f.writeUnlockBuckets(i1, i2)
+			result.Status = Full
+			return result, true
+		}
+		bucketSize, maxKicks := f.bucketSize, f.maxKicks
+		f.writeUnlockBuckets(i1, i2)
+
+		moved, ok := f.freeSlot(i1, i2, nb, bucketSize, maxKicks)
+		result.Kicks += moved
+		exhausted = attempt == stripedPathAttempts || !ok
+	}
+}
+
+// freeSlot moves fingerprints along a cuckoo path of at most maxKicks steps
+// so that bucket i1 or i2 gets a free slot, and returns how many it moved.
+// It reports false if no path was found, the table was replaced, or a
+// concurrent writer changed the path.
+func (f *Filter) freeSlot(i1, i2, nb, bucketSize, maxKicks uint) (moved uint, ok bool) {
+	index := i1
+	if rand.IntN(2) == 1 {
+		index = i2
+	}
+
+	var path []pathStep
+	for range maxKicks {
+		pos := rand.UintN(bucketSize)
+		if !f.lockBuckets(index, index, nb) {
+			return 0, false
+		}
+		fp := f.table.Get(index, pos)
+		f.unlockBuckets(index, index)
+		if fp == 0 {
+			// A slot was freed since the bucket was found full
+			return f.applyPath(path, nb)
+		}
+		path = append(path, pathStep{index: index, pos: pos, fp: fp})
+
+		alt := f.hash.GetAltIndex(index, fp, nb)
+		if !f.lockBuckets(alt, alt, nb) {
+			return 0, false
+		}
+		free := f.table.Count(alt) < bucketSize
+		f.unlockBuckets(alt, alt)
+		if free {
+			return f.applyPath(path, nb)
+		}
+		index = alt
+	}
+	return 0, false
+}
+
+// applyPath performs the moves of path from last to first, so that every
+// move targets a bucket with a free slot. It returns the number of moves made
+// and whether the whole path was applied.
+func (f *Filter) applyPath(path []pathStep, nb uint) (moved uint, ok bool) {
+	for _, step := range slices.Backward(path) {
+		alt := f.hash.GetAltIndex(step.index, step.fp, nb)
+		if !f.writeLockBuckets(step.index, alt, nb) {
+			return moved, false
+		}
+		done := f.table.Get(step.index, step.pos) == step.fp && f.table.Insert(alt, step.fp)
+		if done {
+			f.table.Swap(step.index, step.pos, 0)
+		}
+		f.writeUnlockBuckets(step.index, alt)
+		if !done {
+			return moved, false
+		}
+		moved++
+	}
+	return moved, true
+}
+
+func (f *Filter) deleteStriped(indices indexFunc) bool {
+	for {
+		nb := f.view.Load().numBuckets
+		i1, i2, fp := indices(nb)
+		if !f.writeLockBuckets(i1, i2, nb) {
+			continue
+		}
+
+		deleted := f.table.Remove(i1, fp) || f.table.Remove(i2, fp) ||
+			f.stashLen.Load() > 0 && f.stashDelete(i1, i2, fp)
+		if deleted {
+			f.stripeOf(i1).items.Add(-1)
+		}
+		f.writeUnlockBuckets(i1, i2)
+
+		if deleted && f.stashLen.Load() > 0 {
+			f.drainStriped(nb)
+		}
+		return deleted
+	}
+}
+
+func (f *Filter) lookupStriped(indices indexFunc) bool {
+	for range optimisticAttempts {
+		v := f.view.Load()
+		i1, i2, fp := indices(v.numBuckets)
+		if found, ok := f.containsOptimistic(v, i1, i2, fp); ok {
+			return found
+		}
+	}
+	return f.lookupLocked(indices)
+}
+
+func (f *Filter) lookupBatchStriped(items [][]byte) []bool {
+	results := make([]bool, len(items))
+
+	v := f.view.Load()
+	for i, hr := range f.hash.GetIndicesBatch(items, v.numBuckets) {
+		found, ok := f.containsOptimistic(v, hr.I1, hr.I2, hr.Fp)
+		if !ok {
+			// A writer got in the way or the table was replaced mid-batch
+			found = f.lookupStriped(f.itemIndices(items[i]))
+		}
+		results[i] = found
+	}
+	return results
+}
+
+// lookupLocked looks up an item holding the stripes of its buckets
+func (f *Filter) lookupLocked(indices indexFunc) bool {
+	for {
+		nb := f.view.Load().numBuckets
+		i1, i2, fp := indices(nb)
+		if !f.lockBuckets(i1, i2, nb) {
+			continue
+		}
+		found := f.containsStriped(i1, i2, fp)
+		f.unlockBuckets(i1, i2)
+		return found
+	}
+}
+
+// containsOptimistic reports whether fp is stored in bucket i1 or i2 of v
+// without taking locks. ok is false if a writer modified either bucket or
+// replaced the table during the read, or if the stash has to be searched.
+func (f *Filter) containsOptimistic(v *tableView, i1, i2 uint, fp uint16) (found, ok bool) {
+	a, b := f.orderedStripes(i1, i2)
+	va := a.version.Load()
+	vb := uint64(0)
+	if b != nil {
+		vb = b.version.Load()
+	}
+	if (va|vb)&1 != 0 {
+		return false, false
+	}
+
+	found = v.table.ContainsAtomic(i1, fp) || v.table.ContainsAtomic(i2, fp)
+	if !found && f.stashLen.Load() > 0 {
+		return false, false
+	}
+
+	if a.version.Load() != va || b != nil && b.version.Load() != vb || f.view.Load() != v {
+		return false, false
+	}
+	return found, true
+}
+
+// containsStriped reports whether fp is stored for buckets i1 and i2.
+// Callers must hold both stripes.
+func (f *Filter) containsStriped(i1, i2 uint, fp uint16) bool {
+	if f.table.Contains(i1, fp) || f.table.Contains(i2, fp) {
+		return true
+	}
+	if f.stashLen.Load() == 0 {
+		return false
+	}
+	f.stashMu.Lock()
+	defer f.stashMu.Unlock()
+	return stashContains(f.stash, i1, i2, fp)
+}
+
+// stashAppend stashes fp for bucket index if there is room.
+// Callers must hold the stripes of both of its buckets.
+func (f *Filter) stashAppend(index uint, fp uint16) bool {
+	f.stashMu.Lock()
+	defer f.stashMu.Unlock()
+	if uint(len(f.stash)) == f.victimCacheSize {
+		return false
+	}
+	f.stash = append(f.stash, victim{index: index, fp: fp})
+	f.stashLen.Add(1)
+	return true
+}
+
+// stashDelete removes fp for buckets i1 and i2 from the stash.
+// Callers must hold both stripes.
+func (f *Filter) stashDelete(i1, i2 uint, fp uint16) bool {
+	f.stashMu.Lock()
+	defer f.stashMu.Unlock()
+	if !f.stashRemove(i1, i2, fp) {
+		return false
+	}
+	f.stashLen.Add(-1)
+	return true
+}
+
+// drainStriped moves stashed fingerprints back into the table, locking the
+// buckets of one entry at a time
+func (f *Filter) drainStriped(nb uint) {
+	f.stashMu.Lock()
+	pending := slices.Clone(f.stash)
+	f.stashMu.Unlock()
+
+	for _, v := range pending {
+		alt := f.hash.GetAltIndex(v.index, v.fp, nb)
+		if !f.writeLockBuckets(v.index, alt, nb) {
+			return
+		}
+		f.stashMu.Lock()
+		if f.stashRemove(v.index, alt, v.fp) {
+			if f.table.Insert(v.index, v.fp) || f.table.Insert(alt, v.fp) {
+				f.stashLen.Add(-1)
+			} else {
+				f.stash = append(f.stash, v)
+			}
+		}
+		f.stashMu.Unlock()
+		f.writeUnlockBuckets(v.index, alt)
+	}
+}
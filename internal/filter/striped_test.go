@@ -0,0 +1,345 @@
+//go:build amd64 || arm64
+
+package filter
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// newStriped creates a concurrent-mode filter
+func newStriped(t *testing.T, capacity, bucketSize, fingerprintBits, stripes uint) *Filter {
+	t.Helper()
+	f, err := NewWithConfig(capacity, Config{
+		BucketSize:      bucketSize,
+		FingerprintBits: fingerprintBits,
+		MaxKicks:        500,
+		HashStrategy:    hash.HashStrategyXXHash,
+		BatchSize:       32,
+		VictimCacheSize: 4,
+		LockStripes:     stripes,
+	})
+	if err != nil {
+		t.Fatalf("NewWithConfig failed: %v", err)
+	}
+	return f
+}
+
+// TestStripedStripeCount tests that the stripe count is rounded up to a power of 2
+func TestStripedStripeCount(t *testing.T) {
+	for _, tt := range []struct{ requested, want uint }{{1, 1}, {3, 4}, {64, 64}, {100, 128}} {
+		f := newStriped(t, 1000, 4, 8, tt.requested)
+		if uint(len(f.stripes)) != tt.want || f.stripeMask != tt.want-1 {
+			t.Errorf("LockStripes %d: got %d stripes, mask %d", tt.requested, len(f.stripes), f.stripeMask)
+		}
+	}
+
+	if f := mustNew(t, 1000, 4, 8, hash.HashStrategyXXHash); f.stripes != nil {
+		t.Error("Filters without LockStripes should use the single lock")
+	}
+}
+
+// TestStripedOverfill tests that a concurrent-mode filter filled past capacity
+// relocates and stashes without losing accepted items
+func TestStripedOverfill(t *testing.T) {
+	for _, bits := range []uint{5, 8, 12, 16} {
+		for _, bucketSize := range []uint{2, 4, 8} {
+			t.Run(fmt.Sprintf("%dbits/Size%d", bits, bucketSize), func(t *testing.T) {
+				f := newStriped(t, 2048, bucketSize, bits, 16)
+				accepted := fillPastCapacity(f, "striped")
+
+				if f.Count() != uint(len(accepted)) {
+					t.Errorf("Count() = %d, accepted %d items", f.Count(), len(accepted))
+				}
+				if int(f.stashLen.Load()) != len(f.stash) {
+					t.Errorf("stashLen %d out of sync with stash of %d", f.stashLen.Load(), len(f.stash))
+				}
+				for _, item := range accepted {
+					if !f.Lookup(item) {
+						t.Fatalf("False negative for accepted item %q", item)
+					}
+				}
+				for i, found := range f.LookupBatch(accepted) {
+					if !found {
+						t.Fatalf("LookupBatch false negative for accepted item %q", accepted[i])
+					}
+				}
+
+				for _, item := range accepted {
+					if !f.Delete(item) {
+						t.Fatalf("Delete failed for accepted item %q", item)
+					}
+				}
+				if f.Count() != 0 || len(f.stash) != 0 {
+					t.Errorf("Expected empty filter, count %d, stash %d", f.Count(), len(f.stash))
+				}
+			})
+		}
+	}
+}
+
+// TestStripedConcurrentOperations tests writers and readers on shared buckets.
+// Run with -race to check the locking.
+func TestStripedConcurrentOperations(t *testing.T) {
+	for _, bits := range []uint{8, 12} {
+		t.Run(fmt.Sprintf("%dbits", bits), func(t *testing.T) {
+			f := newStriped(t, 8192, 4, bits, 8)
+
+			const workers = 8
+			const perWorker = 800 // ~80% load, so inserts relocate
+
+			var wg sync.WaitGroup
+			accepted := make([][][]byte, workers)
+			failures := make(chan string, workers)
+
+			for w := range workers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range perWorker {
+						item := []byte(fmt.Sprintf("worker-%d-item-%d", w, i))
+						if !f.Insert(item) {
+							continue
+						}
+						accepted[w] = append(accepted[w], item)
+
+						// Items inserted so far must stay visible while
+						// other workers relocate their fingerprints
+						if probe := accepted[w][i%len(accepted[w])]; !f.Lookup(probe) {
+							failures <- fmt.Sprintf("false negative for %q during inserts", probe)
+							return
+						}
+					}
+
+					// Delete every other item, checking the rest are still found
+					for i, item := range accepted[w] {
+						if i%2 == 0 {
+							if !f.Delete(item) {
+								failures <- fmt.Sprintf("Delete failed for %q", item)
+								return
+							}
+						} else if !f.Lookup(item) {
+							failures <- fmt.Sprintf("false negative for %q during deletes", item)
+							return
+						}
+					}
+				}()
+			}
+
+			wg.Wait()
+			close(failures)
+			for failure := range failures {
+				t.Error(failure)
+			}
+
+			kept := uint(0)
+			for w := range workers {
+				for i, item := range accepted[w] {
+					if i%2 == 1 {
+						kept++
+						if !f.Lookup(item) {
+							t.Errorf("False negative for %q after concurrent operations", item)
+						}
+					}
+				}
+			}
+			if f.Count() != kept {
+				t.Errorf("Count() = %d, want %d", f.Count(), kept)
+			}
+		})
+	}
+}
+
+// TestStripedOptimisticLookups tests that lookups read without locking and
+// fall back to the stripe locks when a writer is active
+func TestStripedOptimisticLookups(t *testing.T) {
+	f := newStriped(t, 1024, 4, 12, 4)
+	items := make([][]byte, 200)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("optimistic-%d", i))
+		f.Insert(items[i])
+	}
+
+	// Held locks don't block lookups while no writer is active
+	for i := range f.stripes {
+		f.stripes[i].mu.Lock()
+	}
+	for _, item := range items {
+		if !f.Lookup(item) {
+			t.Fatalf("False negative for %q", item)
+		}
+	}
+	for i, found := range f.LookupBatch(items) {
+		if !found {
+			t.Fatalf("LookupBatch false negative for %q", items[i])
+		}
+	}
+	for i := range f.stripes {
+		f.stripes[i].mu.Unlock()
+	}
+
+	// An odd version makes lookups wait for the writer's locks
+	f.lockAll()
+	done := make(chan bool)
+	go func() { done <- f.Lookup(items[0]) }()
+	select {
+	case <-done:
+		t.Fatal("Lookup returned while a writer held every stripe")
+	default:
+	}
+	f.unlockAll()
+	if !<-done {
+		t.Errorf("False negative for %q after the writer finished", items[0])
+	}
+}
+
+// TestStripedLookupStress mixes inserts, deletes and lookups of churning
+// items with lookups of items that stay in the filter, which must never be
+// missed. Run with -race to check the optimistic reads.
+func TestStripedLookupStress(t *testing.T) {
+	for _, bits := range []uint{7, 8, 16} {
+		for _, bucketSize := range []uint{2, 4} {
+			t.Run(fmt.Sprintf("%dbits/Size%d", bits, bucketSize), func(t *testing.T) {
+				f := newStriped(t, 4096, bucketSize, bits, 8)
+
+				var stable [][]byte
+				for i := 0; len(stable) < 1500; i++ {
+					item := []byte(fmt.Sprintf("stable-%d", i))
+					if f.Insert(item) {
+						stable = append(stable, item)
+					}
+				}
+
+				var writers, readers sync.WaitGroup
+				stop := make(chan struct{})
+				failures := make(chan string, 4)
+				for w := range 4 {
+					writers.Add(1)
+					go func() {
+						defer writers.Done()
+						// Churn at high load so inserts relocate stable items
+						for i := 0; ; i++ {
+							select {
+							case <-stop:
+								return
+							default:
+							}
+							item := []byte(fmt.Sprintf("churn-%d-%d", w, i%500))
+							if f.Insert(item) {
+								f.Lookup(item)
+								f.Delete(item)
+							}
+						}
+					}()
+				}
+				for r := range 4 {
+					readers.Add(1)
+					go func() {
+						defer readers.Done()
+						for round := range 20 {
+							if r%2 == 0 {
+								for _, item := range stable {
+									if !f.Lookup(item) {
+										failures <- fmt.Sprintf("Lookup false negative for %q in round %d", item, round)
+										return
+									}
+								}
+								continue
+							}
+							for i, found := range f.LookupBatch(stable) {
+								if !found {
+									failures <- fmt.Sprintf("LookupBatch false negative for %q in round %d", stable[i], round)
+									return
+								}
+							}
+						}
+					}()
+				}
+
+				readers.Wait()
+				close(stop)
+				writers.Wait()
+				close(failures)
+				for failure := range failures {
+					t.Error(failure)
+				}
+			})
+		}
+	}
+}
+
+// TestStripedReadFrom tests replacing the table of a concurrent-mode filter
+// while other goroutines use it
+func TestStripedReadFrom(t *testing.T) {
+	src := mustNew(t, 4096, 8, 12, hash.HashStrategyXXHash)
+	for i := 0; i < 2000; i++ {
+		src.Insert([]byte(fmt.Sprintf("loaded-%d", i)))
+	}
+	data, _ := src.MarshalBinary()
+
+	f := newStriped(t, 512, 4, 12, 8)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				item := []byte(fmt.Sprintf("background-%d-%d", w, i))
+				f.Insert(item)
+				f.Lookup(item)
+				f.Delete(item)
+			}
+		}()
+	}
+
+	for range 3 {
+		if _, err := f.ReadFrom(bytes.NewReader(data)); err != nil {
+			t.Fatalf("ReadFrom failed: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if f.numBuckets != src.numBuckets || f.Capacity() != src.Capacity() {
+		t.Errorf("Expected layout of the loaded filter, got %d buckets", f.numBuckets)
+	}
+	for i := 0; i < 2000; i++ {
+		if !f.Lookup([]byte(fmt.Sprintf("loaded-%d", i))) {
+			t.Errorf("Loaded item %d not found", i)
+		}
+	}
+
+	out, _ := f.MarshalBinary()
+	g, err := Decode(out)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if g.Count() != f.Count() {
+		t.Errorf("Round trip count %d, want %d", g.Count(), f.Count())
+	}
+}
+
+// TestStripedReset tests clearing a concurrent-mode filter
+func TestStripedReset(t *testing.T) {
+	f := newStriped(t, 256, 4, 8, 4)
+	fillPastCapacity(f, "reset")
+
+	f.Reset()
+	if f.Count() != 0 || f.LoadFactor() != 0 || f.stashLen.Load() != 0 {
+		t.Errorf("Filter not empty after Reset: count %d, stash %d", f.Count(), f.stashLen.Load())
+	}
+	if !f.Insert([]byte("after-reset")) || !f.Lookup([]byte("after-reset")) {
+		t.Error("Filter unusable after Reset")
+	}
+}
@@ -68,6 +68,48 @@ func BenchmarkBatchXXHash(b *testing.B) {
 	}
 }
 
+// BenchmarkBatchXXHashShortKeys measures batches of short keys, which the
+// ARM64 NEON kernel hashes 4 at a time. 64-byte keys exceed its limit and
+// are hashed one at a time there.
+func BenchmarkBatchXXHashShortKeys(b *testing.B) {
+	const numItems = 64
+	for _, size := range []int{8, 16, 24, 32, 64} {
+		items := make([][]byte, numItems)
+		for i := range items {
+			items[i] = make([]byte, size)
+			for j := range items[i] {
+				items[i][j] = byte(i*size + j)
+			}
+		}
+
+		b.Run(fmt.Sprintf("Scalar/%dbytes", size), func(b *testing.B) {
+			xxh := xxhash.NewXXHash(8, nil)
+			numBuckets := uint(1024)
+			results := make([]types.HashResult, len(items))
+			b.SetBytes(int64(numItems * size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j, item := range items {
+					i1, i2, fp := xxh.GetIndices(item, numBuckets)
+					results[j] = types.HashResult{I1: i1, I2: i2, Fp: fp}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("SIMD/%dbytes", size), func(b *testing.B) {
+			xxh := xxhash.NewXXHash(8, xxhash.NewBatchHashProcessor())
+			numBuckets := uint(1024)
+			b.SetBytes(int64(numItems * size))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = xxh.GetIndicesBatch(items, numBuckets)
+			}
+		})
+	}
+}
+
 func TestXXHashConsistency(t *testing.T) {
 	// Verify that hash function produces consistent results
 	testCases := [][]byte{
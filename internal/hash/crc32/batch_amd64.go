@@ -32,16 +32,14 @@ func NewBatchProcessor(table *crc32.Table) *BatchProcessor {
 //
 // The Go standard library's crc32 already uses SSE4.2 hardware acceleration,
 // so we focus on parallel processing rather than custom SIMD assembly.
-func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	results := make([]types.HashResult, len(items))
 
 	// For small-to-medium batches, sequential processing is faster
 	// Goroutine overhead is ~1-2µs per goroutine, which exceeds the benefit for small batches
 	if len(items) < 32 {
 		for i, item := range items {
-			hashVal := crc32.Checksum(item, p.table)
-			fp := fingerprint(uint64(hashVal), fingerprintBits)
-			i1 := uint(hashVal % uint32(numBuckets))
+			i1, fp := layout.Split(hash64(item, p.table, layout, seed), fingerprintBits, numBuckets)
 
 			// Use 2 bytes for fingerprint hash if needed
 			fpBuf := [2]byte{byte(fp), byte(fp >> 8)}
@@ -83,9 +81,7 @@ func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits, numBucket
 		go func(start, end int) {
 			for i := start; i < end; i++ {
 				item := items[i]
-				hashVal := crc32.Checksum(item, p.table)
-				fp := fingerprint(uint64(hashVal), fingerprintBits)
-				i1 := uint(hashVal % uint32(numBuckets))
+				i1, fp := layout.Split(hash64(item, p.table, layout, seed), fingerprintBits, numBuckets)
 
 				// Use 2 bytes for fingerprint hash if needed
 				fpBuf := [2]byte{byte(fp), byte(fp >> 8)}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"hash/crc32"
 	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
 )
 
 // TestSIMDCorrectness verifies SIMD CRC32 against stdlib
@@ -63,7 +65,7 @@ func TestSIMDBatchSizes(t *testing.T) {
 			}
 
 			// Process batch
-			results := processor.ProcessBatch(items, 8, 1000)
+			results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 
 			// Verify correct number of results
 			if len(results) != size {
@@ -87,7 +89,7 @@ func TestSIMDEdgeCases(t *testing.T) {
 
 	t.Run("all empty", func(t *testing.T) {
 		items := [][]byte{[]byte(""), []byte(""), []byte(""), []byte("")}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 4 {
 			t.Errorf("Expected 4 results, got %d", len(results))
 		}
@@ -99,7 +101,7 @@ func TestSIMDEdgeCases(t *testing.T) {
 			largeData[i] = byte(i % 256)
 		}
 		items := [][]byte{largeData}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 1 {
 			t.Errorf("Expected 1 result, got %d", len(results))
 		}
@@ -116,7 +118,7 @@ func TestSIMDEdgeCases(t *testing.T) {
 			[]byte("abcdef"),
 			[]byte("abcdefg"),
 		}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 8 {
 			t.Errorf("Expected 8 results, got %d", len(results))
 		}
@@ -32,14 +32,12 @@ func NewBatchProcessor(table *crc32.Table) *BatchProcessor {
 //
 // ARM64 has dedicated CRC32C instructions that match the Castagnoli polynomial,
 // which is exactly what we need for this hash function.
-func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	results := make([]types.HashResult, len(items))
 
 	// Process items using hardware-accelerated CRC32C
 	for i, item := range items {
-		hashVal := crc32.Checksum(item, p.table)
-		fp := fingerprint(uint64(hashVal), fingerprintBits)
-		i1 := uint(hashVal % uint32(numBuckets))
+		i1, fp := layout.Split(hash64(item, p.table, layout, seed), fingerprintBits, numBuckets)
 
 		// Use 2 bytes for fingerprint hash if needed
 		fpBuf := [2]byte{byte(fp), byte(fp >> 8)}
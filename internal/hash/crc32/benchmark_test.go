@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"hash/crc32"
 	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
 )
 
 // BenchmarkSIMDBatchSizes benchmarks different batch sizes
@@ -26,7 +28,7 @@ func BenchmarkSIMDBatchSizes(b *testing.B) {
 			processor := NewBatchProcessor(table)
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				processor.ProcessBatch(items, 8, 1000)
+				processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 			}
 		})
 	}
@@ -79,7 +81,7 @@ func BenchmarkSIMDItemSizes(b *testing.B) {
 			b.SetBytes(int64(itemSize * 16)) // Total bytes processed per iteration
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				processor.ProcessBatch(items, 8, 1000)
+				processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 			}
 		})
 	}
@@ -106,7 +108,7 @@ func BenchmarkSIMDThroughput(b *testing.B) {
 		b.SetBytes(batchSize * itemSize)
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			processor.ProcessBatch(items, 8, 10000)
+			processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 10000)
 		}
 	})
 }
@@ -133,7 +135,7 @@ func BenchmarkCompareSingleVsBatch(b *testing.B) {
 	b.Run("BatchSIMD", func(b *testing.B) {
 		b.ResetTimer()
 		for i := 0; i < b.N; i++ {
-			processor.ProcessBatch(items, 8, 1000)
+			processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		}
 	})
 }
@@ -25,14 +25,17 @@ import (
 type CRC32Hash struct {
 	Table           *crc32.Table
 	FingerprintBits uint
+	Layout          types.Layout // How hashes are split into bucket and fingerprint
+	Seed            uint64       // Initial CRC registers: low half for Table, high half for IEEE
 	batchProcessor  *BatchProcessor
 }
 
-// NewCRC32Hash creates a new CRC32Hash instance
+// NewCRC32Hash creates a new CRC32Hash instance using types.LayoutDisjoint
 func NewCRC32Hash(table *crc32.Table, fingerprintBits uint, batchProcessor *BatchProcessor) *CRC32Hash {
 	return &CRC32Hash{
 		Table:           table,
 		FingerprintBits: fingerprintBits,
+		Layout:          types.LayoutDisjoint,
 		batchProcessor:  batchProcessor,
 	}
 }
@@ -42,7 +45,9 @@ func NewCRC32Hash(table *crc32.Table, fingerprintBits uint, batchProcessor *Batc
 // This method hashes the input item using CRC32C (Castagnoli) and derives:
 //   - i1: The primary bucket index, computed as crc32c(item) % numBuckets
 //   - i2: The alternative bucket index, computed as (i1 ^ crc32c(fp)) % numBuckets
-//   - fp: A non-zero fingerprint (1-255) extracted from the hash, used to identify the item
+//   - fp: A non-zero fingerprint extracted from the hash, used to identify the item.
+//     A 32-bit checksum has too few bits for both, so with types.LayoutDisjoint the
+//     fingerprint comes from the top bits of a second, CRC-32 (IEEE) checksum.
 //
 // CRC32C is hardware-accelerated on modern CPUs (SSE4.2 on AMD64, ARMv8 CRC32 on ARM64),
 // providing excellent performance with minimal CPU overhead.
@@ -54,7 +59,7 @@ func NewCRC32Hash(table *crc32.Table, fingerprintBits uint, batchProcessor *Batc
 // Returns:
 //   - i1: Primary bucket index (0 <= i1 < numBuckets)
 //   - i2: Alternative bucket index (0 <= i2 < numBuckets), where i2 = GetAltIndex(i1, fp, numBuckets)
-//   - fp: Fingerprint byte (1 <= fp <= 255, never 0 as that indicates an empty slot)
+//   - fp: Fingerprint (1 <= fp < 2^FingerprintBits, never 0 as that indicates an empty slot)
 //
 // Thread-safety: This method is safe for concurrent use by multiple goroutines.
 //
@@ -66,18 +71,29 @@ func NewCRC32Hash(table *crc32.Table, fingerprintBits uint, batchProcessor *Batc
 //	// fp identifies the item within those buckets
 func (h *CRC32Hash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp uint16) {
 	// CRC32C checksum (hardware accelerated on modern CPUs)
-	hashVal := crc32.Checksum(item, h.Table)
-
-	// Extract fingerprint
-	fp = fingerprint(uint64(hashVal), h.FingerprintBits)
-
-	// Calculate first index
-	i1 = uint(hashVal % uint32(numBuckets))
+	return h.indices(hash64(item, h.Table, h.Layout, h.Seed), numBuckets)
+}
 
-	// Calculate second index
-	i2 = h.GetAltIndex(i1, fp, numBuckets)
+// indices derives the buckets and fingerprint of a hash value
+func (h *CRC32Hash) indices(hashVal uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	i1, fp = h.Layout.Split(hashVal, h.FingerprintBits, numBuckets)
+	return i1, h.GetAltIndex(i1, fp, numBuckets), fp
+}
 
-	return i1, i2, fp
+// hash64 returns the checksum of item with table in the low 32 bits and, for
+// layouts other than types.LayoutLowBits, its IEEE checksum in the high 32
+// bits. Each checksum continues from the matching half of seed.
+//
+// CRCs are linear: seeding adds a constant that depends only on the length
+// of item, so items of equal length that collide with one seed collide with
+// every seed. A seed changes where items are placed, but can't stop an
+// attacker who knows how to build colliding items; use SipHash for that.
+func hash64(item []byte, table *crc32.Table, layout types.Layout, seed uint64) uint64 {
+	hashVal := uint64(crc32.Update(uint32(seed), table, item))
+	if layout != types.LayoutLowBits {
+		hashVal |= uint64(crc32.Update(uint32(seed>>32), crc32.IEEETable, item)) << 32
+	}
+	return hashVal
 }
 
 // GetAltIndex computes the alternative bucket index given a current index and fingerprint.
@@ -113,17 +129,14 @@ func (h *CRC32Hash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp ui
 //	i2 := crc.GetAltIndex(i1, fp, 1024)  // Get alternative location
 //	i1Back := crc.GetAltIndex(i2, fp, 1024)  // Returns to i1 (symmetry property)
 func (h *CRC32Hash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
-	// Use stack-allocated buffer to avoid heap allocation
-	// We need to hash the fingerprint to get the alternative index
-	// Since fp is uint16, we need 2 bytes
-	fpBuf := [2]byte{byte(fp), byte(fp >> 8)}
+	// Hash the fingerprint bytes without a slice, which would escape to the heap.
 	// For 8-bit or less, we only use the first byte to maintain backward compatibility
 	// and consistency with how it was done before
 	length := 1
 	if h.FingerprintBits > 8 {
 		length = 2
 	}
-	fpHash := crc32.Checksum(fpBuf[:length], h.Table)
+	fpHash := ^update(^uint32(0), h.Table, uint64(fp), length)
 	altIndex := (uint64(index) ^ uint64(fpHash)) % uint64(numBuckets)
 	return uint(altIndex)
 }
@@ -167,7 +180,7 @@ func (h *CRC32Hash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
 func (h *CRC32Hash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashResult {
 	// Use batch processor if available
 	if h.batchProcessor != nil {
-		return h.batchProcessor.ProcessBatch(items, h.FingerprintBits, numBuckets)
+		return h.batchProcessor.ProcessBatch(items, h.FingerprintBits, h.Layout, h.Seed, numBuckets)
 	}
 
 	// Fallback to sequential processing
@@ -178,13 +191,3 @@ func (h *CRC32Hash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.Has
 	}
 	return results
 }
-
-// fingerprint extracts a fingerprint from a hash value
-func fingerprint(hashVal uint64, bits uint) uint16 {
-	fp := uint16(hashVal & ((1 << bits) - 1))
-	// Ensure fingerprint is never zero (0 means empty slot)
-	if fp == 0 {
-		fp = 1
-	}
-	return fp
-}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"hash/crc32"
 	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
 )
 
 // TestARM64HardwareVsSoftware compares ARM64 hardware CRC32 vs software implementation
@@ -100,10 +102,10 @@ func TestARM64HardwareVsSoftware(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Process with hardware acceleration
-			hardwareResults := hardwareProcessor.ProcessBatch(tc.items, 8, 1000)
+			hardwareResults := hardwareProcessor.ProcessBatch(tc.items, 8, types.LayoutDisjoint, 0, 1000)
 
 			// Process without hardware acceleration
-			softwareResults := softwareProcessor.ProcessBatch(tc.items, 8, 1000)
+			softwareResults := softwareProcessor.ProcessBatch(tc.items, 8, types.LayoutDisjoint, 0, 1000)
 
 			// Compare results
 			if len(hardwareResults) != len(softwareResults) {
@@ -189,8 +191,8 @@ func TestARM64BatchSizes(t *testing.T) {
 			}
 
 			// Process with both methods
-			hardwareResults := hardwareProcessor.ProcessBatch(items, 8, 1000)
-			softwareResults := softwareProcessor.ProcessBatch(items, 8, 1000)
+			hardwareResults := hardwareProcessor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
+			softwareResults := softwareProcessor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 
 			// Verify they match
 			for i := range hardwareResults {
@@ -209,7 +211,7 @@ func TestARM64EdgeCases(t *testing.T) {
 
 	t.Run("all empty", func(t *testing.T) {
 		items := [][]byte{[]byte(""), []byte(""), []byte(""), []byte("")}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 4 {
 			t.Errorf("Expected 4 results, got %d", len(results))
 		}
@@ -227,7 +229,7 @@ func TestARM64EdgeCases(t *testing.T) {
 			largeData[i] = byte(i % 256)
 		}
 		items := [][]byte{largeData}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 1 {
 			t.Errorf("Expected 1 result, got %d", len(results))
 		}
@@ -244,7 +246,7 @@ func TestARM64EdgeCases(t *testing.T) {
 			[]byte("abcdef"),
 			[]byte("abcdefg"),
 		}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != 8 {
 			t.Errorf("Expected 8 results, got %d", len(results))
 		}
@@ -263,7 +265,7 @@ func TestARM64EdgeCases(t *testing.T) {
 			[]byte("12345678"),
 			[]byte("123456789"),
 		}
-		results := processor.ProcessBatch(items, 8, 1000)
+		results := processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1000)
 		if len(results) != len(items) {
 			t.Errorf("Expected %d results, got %d", len(items), len(results))
 		}
@@ -306,7 +308,7 @@ func BenchmarkARM64Hardware(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = processor.ProcessBatch(items, 8, 1024)
+		_ = processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1024)
 	}
 }
 
@@ -323,6 +325,6 @@ func BenchmarkARM64Software(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = processor.ProcessBatch(items, 8, 1024)
+		_ = processor.ProcessBatch(items, 8, types.LayoutDisjoint, 0, 1024)
 	}
 }
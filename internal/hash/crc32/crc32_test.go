@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"hash/crc32"
 	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
 )
 
 // TestCRC32HashConsistency verifies that hash function produces consistent results
@@ -54,35 +56,57 @@ func TestCRC32HashCorrectness(t *testing.T) {
 	}
 
 	table := crc32.MakeTable(crc32.Castagnoli)
-	h := NewCRC32Hash(table, 8, nil)
+	h := NewCRC32Hash(table, 16, nil)
+	const numBuckets = 1 << 20
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Compute expected hash using stdlib
-			expectedHash := crc32.Checksum(tc.data, table)
+			// Compute expected hashes using stdlib: the Castagnoli checksum
+			// in the low and the IEEE checksum in the high 32 bits
+			expectedHash := uint64(crc32.ChecksumIEEE(tc.data))<<32 | uint64(crc32.Checksum(tc.data, table))
+			expectedI1, expectedFp := types.LayoutDisjoint.Split(expectedHash, 16, numBuckets)
 
 			// Compute using our implementation
-			i1, _, fp := h.GetIndices(tc.data, 1000000)
+			i1, _, fp := h.GetIndices(tc.data, numBuckets)
 
-			// Verify the indices are derived from the same hash
+			// Verify the fingerprint is never zero
 			if fp == 0 {
 				t.Errorf("%s: fingerprint is zero (should never happen)", tc.name)
 			}
 
 			// Verify index is in valid range
-			if i1 >= 1000000 {
+			if i1 >= numBuckets {
 				t.Errorf("%s: index out of range: %d", tc.name, i1)
 			}
 
-			// Verify the hash value matches stdlib
-			if uint32(expectedHash)%uint32(1000000) != uint32(i1) {
-				t.Errorf("%s: index doesn't match expected hash: got %d, expected %d",
-					tc.name, i1, uint32(expectedHash)%1000000)
+			// Verify the result matches the stdlib checksums
+			if i1 != expectedI1 || fp != expectedFp {
+				t.Errorf("%s: got (%d, 0x%x), expected (%d, 0x%x)", tc.name, i1, fp, expectedI1, expectedFp)
 			}
 		})
 	}
 }
 
+// TestCRC32SeedLinearity documents why a seed can't stop collisions: for
+// items of equal length, the seeded hash of a^b^c is the XOR of the hashes
+// of a, b and c, whatever the seed, so a collision found for one seed is a
+// collision for all of them
+func TestCRC32SeedLinearity(t *testing.T) {
+	table := crc32.MakeTable(crc32.Castagnoli)
+	a, b, c := []byte("linear-a"), []byte("linear-b"), []byte("xyzzy-cc")
+	abc := make([]byte, len(a))
+	for i := range abc {
+		abc[i] = a[i] ^ b[i] ^ c[i]
+	}
+
+	for _, seed := range []uint64{0, 1, 0xdeadbeefcafebabe} {
+		h := func(item []byte) uint64 { return hash64(item, table, types.LayoutDisjoint, seed) }
+		if h(a)^h(b)^h(c) != h(abc) {
+			t.Errorf("Seed %#x: hash is not linear", seed)
+		}
+	}
+}
+
 // TestCRC32FingerprintBits tests different fingerprint bit sizes
 func TestCRC32FingerprintBits(t *testing.T) {
 	// Fingerprints are stored as bytes, so only 1-8 bits are supported
@@ -359,32 +383,6 @@ func TestCRC32Tables(t *testing.T) {
 	}
 }
 
-// TestCRC32FingerprintFunction tests the fingerprint extraction
-func TestCRC32FingerprintFunction(t *testing.T) {
-	testCases := []struct {
-		hashVal uint64
-		bits    uint
-		expect  uint16
-	}{
-		{0x00, 8, 1},      // Zero should become 1
-		{0xFF, 8, 0xFF},   // All bits set
-		{0x80, 8, 0x80},   // High bit set
-		{0x01, 8, 0x01},   // Low bit set
-		{0x100, 8, 1},     // Overflow, low bits zero -> 1
-		{0x1234, 8, 0x34}, // Extract low 8 bits
-		{0x00, 4, 1},      // Zero with 4 bits -> 1
-		{0x0F, 4, 0x0F},   // Max 4-bit value
-	}
-
-	for _, tc := range testCases {
-		result := fingerprint(tc.hashVal, tc.bits)
-		if result != tc.expect {
-			t.Errorf("fingerprint(0x%x, %d) = 0x%x, want 0x%x",
-				tc.hashVal, tc.bits, result, tc.expect)
-		}
-	}
-}
-
 // BenchmarkCRC32Hash benchmarks single hash operation
 func BenchmarkCRC32Hash(b *testing.B) {
 	table := crc32.MakeTable(crc32.Castagnoli)
@@ -0,0 +1,42 @@
+package crc32hash
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
+)
+
+// GetIndicesUint64 returns the result of GetIndices for the 8-byte
+// little-endian encoding of key, computed without a byte slice
+func (h *CRC32Hash) GetIndicesUint64(key uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	hashVal := uint64(^update(^uint32(h.Seed), h.Table, key, 8))
+	if h.Layout != types.LayoutLowBits {
+		hashVal |= uint64(^update(^uint32(h.Seed>>32), crc32.IEEETable, key, 8)) << 32
+	}
+	return h.indices(hashVal, numBuckets)
+}
+
+// GetIndicesBytes16 returns the result of GetIndices for the 16 bytes of key,
+// computed without a byte slice
+func (h *CRC32Hash) GetIndicesBytes16(key [16]byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	lo := binary.LittleEndian.Uint64(key[:8])
+	hi := binary.LittleEndian.Uint64(key[8:])
+	hashVal := uint64(^update(update(^uint32(h.Seed), h.Table, lo, 8), h.Table, hi, 8))
+	if h.Layout != types.LayoutLowBits {
+		ieee := update(update(^uint32(h.Seed>>32), crc32.IEEETable, lo, 8), crc32.IEEETable, hi, 8)
+		hashVal |= uint64(^ieee) << 32
+	}
+	return h.indices(hashVal, numBuckets)
+}
+
+// update continues crc over the low n little-endian bytes of v, one table
+// lookup per byte. crc32.Checksum would take a slice that escapes to the
+// heap, which costs more than the lookups for keys this short.
+func update(crc uint32, tab *crc32.Table, v uint64, n int) uint32 {
+	for range n {
+		crc = tab[byte(crc)^byte(v)] ^ crc>>8
+		v >>= 8
+	}
+	return crc
+}
@@ -0,0 +1,52 @@
+package hash
+
+import "encoding/binary"
+
+// customHash derives bucket indices and fingerprints from a 64-bit hash
+// function supplied by the caller, as the built-in strategies do from theirs
+type customHash struct {
+	fn              func([]byte) uint64
+	fingerprintBits uint
+	layout          Layout
+}
+
+// GetIndices splits fn(item) into the primary bucket and fingerprint with
+// the layout. The alternate bucket is GetAltIndex(i1, fp, numBuckets).
+func (h *customHash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	return h.indices(h.fn(item), numBuckets)
+}
+
+// indices derives the buckets and fingerprint of a hash value
+func (h *customHash) indices(hashVal uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	i1, fp = h.layout.Split(hashVal, h.fingerprintBits, numBuckets)
+	return i1, h.GetAltIndex(i1, fp, numBuckets), fp
+}
+
+// GetAltIndex computes the alternative bucket index given a current index
+// and fingerprint. GetAltIndex(GetAltIndex(i, fp, n), fp, n) == i.
+func (h *customHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
+	// Odd, so the alternate bucket differs from index when numBuckets > 1
+	hash := uint64(fp)*0x5bd1e995 | 1
+	return uint((uint64(index) ^ hash) % uint64(numBuckets))
+}
+
+// GetIndicesBatch computes indices and fingerprints for multiple items
+func (h *customHash) GetIndicesBatch(items [][]byte, numBuckets uint) []HashResult {
+	results := make([]HashResult, len(items))
+	for i, item := range items {
+		i1, i2, fp := h.GetIndices(item, numBuckets)
+		results[i] = HashResult{I1: i1, I2: i2, Fp: fp}
+	}
+	return results
+}
+
+// GetIndicesUint64 hashes the 8-byte little-endian encoding of key. The
+// encoding escapes to fn, so unlike the built-in strategies this allocates.
+func (h *customHash) GetIndicesUint64(key uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	return h.GetIndices(binary.LittleEndian.AppendUint64(nil, key), numBuckets)
+}
+
+// GetIndicesBytes16 hashes the 16 bytes of key
+func (h *customHash) GetIndicesBytes16(key [16]byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	return h.GetIndices(key[:], numBuckets)
+}
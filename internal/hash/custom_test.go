@@ -0,0 +1,46 @@
+package hash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+// TestCustomHash tests that HashStrategyCustom splits the values of
+// Params.Func like the built-in strategies split theirs
+func TestCustomHash(t *testing.T) {
+	const numBuckets = 1 << 16
+	fn := func(item []byte) uint64 { return SipHash64([16]byte{1}, item) }
+
+	for _, layout := range []Layout{LayoutLowBits, LayoutDisjoint} {
+		for _, bits := range []uint{4, 8, 16} {
+			t.Run(fmt.Sprintf("%s/%dbits", layout, bits), func(t *testing.T) {
+				h := NewHashFunctionWithParams(HashStrategyCustom, bits, Params{Layout: layout, Func: fn})
+				items := make([][]byte, 100)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("custom-%d", i))
+					i1, i2, fp := h.GetIndices(items[i], numBuckets)
+					w1, wfp := layout.Split(fn(items[i]), bits, numBuckets)
+					if i1 != w1 || fp != wfp {
+						t.Fatalf("GetIndices(%q) = (%d, %d), want (%d, %d)", items[i], i1, fp, w1, wfp)
+					}
+					if i1 == i2 || h.GetAltIndex(i2, fp, numBuckets) != i1 {
+						t.Fatalf("Alternate bucket %d of %d is not symmetric", i2, i1)
+					}
+				}
+				for i, r := range h.GetIndicesBatch(items, numBuckets) {
+					if i1, i2, fp := h.GetIndices(items[i], numBuckets); r != (HashResult{I1: i1, I2: i2, Fp: fp}) {
+						t.Fatalf("GetIndicesBatch differs from GetIndices for %q", items[i])
+					}
+				}
+
+				fixed := h.(FixedHasher)
+				key := binary.LittleEndian.AppendUint64(nil, 0xdeadbeef)
+				i1, i2, fp := h.GetIndices(key, numBuckets)
+				if g1, g2, gfp := fixed.GetIndicesUint64(0xdeadbeef, numBuckets); g1 != i1 || g2 != i2 || gfp != fp {
+					t.Errorf("GetIndicesUint64 = (%d, %d, %d), want (%d, %d, %d)", g1, g2, gfp, i1, i2, fp)
+				}
+			})
+		}
+	}
+}
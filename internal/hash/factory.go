@@ -5,22 +5,44 @@ import (
 
 	crc32hash "github.com/shaia/simdcuckoofilter/internal/hash/crc32"
 	fnvhash "github.com/shaia/simdcuckoofilter/internal/hash/fnv"
+	"github.com/shaia/simdcuckoofilter/internal/hash/siphash"
 	"github.com/shaia/simdcuckoofilter/internal/hash/xxhash"
 )
 
-// NewHashFunction creates a hash function based on the strategy and fingerprint bits.
+// NewHashFunction creates a hash function based on the strategy and fingerprint bits,
+// splitting hashes with LayoutDisjoint.
 // Automatically uses the best SIMD implementation available for the platform at compile time.
 func NewHashFunction(strategy HashStrategy, fingerprintBits uint) HashInterface {
+	return NewHashFunctionWithParams(strategy, fingerprintBits, Params{})
+}
+
+// NewHashFunctionWithParams is NewHashFunction for filters with a seed, a
+// SipHash key, a custom hash function or another layout
+func NewHashFunctionWithParams(strategy HashStrategy, fingerprintBits uint, params Params) HashInterface {
+	if params.Layout == 0 {
+		params.Layout = LayoutDisjoint
+	}
+
 	switch strategy {
 	case HashStrategyCRC32:
 		crcTable := stdcrc32.MakeTable(stdcrc32.Castagnoli)
 		crcBatchProcessor := crc32hash.NewBatchProcessor(crcTable)
-		return crc32hash.NewCRC32Hash(crcTable, fingerprintBits, crcBatchProcessor)
+		h := crc32hash.NewCRC32Hash(crcTable, fingerprintBits, crcBatchProcessor)
+		h.Layout = params.Layout
+		h.Seed = params.Seed
+		return h
 	case HashStrategyXXHash:
 		xxhashBatchProcessor := xxhash.NewBatchHashProcessor()
-		return xxhash.NewXXHash(fingerprintBits, xxhashBatchProcessor)
+		return xxhash.NewXXHash(fingerprintBits, xxhashBatchProcessor).WithLayout(params.Layout).WithSeed(params.Seed)
+	case HashStrategySipHash:
+		return siphash.NewSipHash(params.Key, fingerprintBits)
+	case HashStrategyCustom:
+		return &customHash{fn: params.Func, fingerprintBits: fingerprintBits, layout: params.Layout}
 	default: // HashStrategyFNV
 		fnvBatchProcessor := fnvhash.NewBatchProcessor()
-		return fnvhash.NewFNVHash(fingerprintBits, fnvBatchProcessor)
+		h := fnvhash.NewFNVHash(fingerprintBits, fnvBatchProcessor)
+		h.Layout = params.Layout
+		h.Seed = params.Seed
+		return h
 	}
 }
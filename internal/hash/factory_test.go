@@ -5,6 +5,7 @@ import (
 
 	crc32hash "github.com/shaia/simdcuckoofilter/internal/hash/crc32"
 	fnvhash "github.com/shaia/simdcuckoofilter/internal/hash/fnv"
+	"github.com/shaia/simdcuckoofilter/internal/hash/siphash"
 	"github.com/shaia/simdcuckoofilter/internal/hash/xxhash"
 )
 
@@ -18,6 +19,7 @@ func TestNewHashFunction(t *testing.T) {
 	}{
 		{"CRC32", HashStrategyCRC32, "*crc32.CRC32Hash"},
 		{"XXHash", HashStrategyXXHash, "*xxhash.XXHash"},
+		{"SipHash", HashStrategySipHash, "*siphash.SipHash"},
 		{"FNV", HashStrategyFNV, "*fnvhash.FNVHash"},
 		{"Default", HashStrategy(99), "*fnvhash.FNVHash"}, // Unknown defaults to FNV
 	}
@@ -39,6 +41,10 @@ func TestNewHashFunction(t *testing.T) {
 				if _, ok := h.(*xxhash.XXHash); !ok {
 					t.Errorf("Expected *xxhash.XXHash, got %T", h)
 				}
+			case HashStrategySipHash:
+				if _, ok := h.(*siphash.SipHash); !ok {
+					t.Errorf("Expected *siphash.SipHash, got %T", h)
+				}
 			default: // FNV or unknown
 				if _, ok := h.(*fnvhash.FNVHash); !ok {
 					t.Errorf("Expected *fnvhash.FNVHash, got %T", h)
@@ -111,6 +117,7 @@ func TestFactoryBatchProcessing(t *testing.T) {
 		{"CRC32", HashStrategyCRC32},
 		{"XXHash", HashStrategyXXHash},
 		{"FNV", HashStrategyFNV},
+		{"SipHash", HashStrategySipHash},
 	}
 
 	items := [][]byte{
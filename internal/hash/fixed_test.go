@@ -0,0 +1,53 @@
+package hash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestFixedHasher tests that fixed-width keys hash like their byte encodings,
+// with the same seed or key, and don't allocate
+func TestFixedHasher(t *testing.T) {
+	const numBuckets = 1 << 20
+	strategies := []HashStrategy{HashStrategyFNV, HashStrategyCRC32, HashStrategyXXHash, HashStrategySipHash}
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, strategy := range strategies {
+		for _, bits := range []uint{8, 12, 16} {
+			t.Run(fmt.Sprintf("%s/%dbits", strategy, bits), func(t *testing.T) {
+				h := NewHashFunctionWithParams(strategy, bits, Params{Seed: 0x5eed, Key: [16]byte{7}})
+				fixed, ok := h.(FixedHasher)
+				if !ok {
+					t.Fatalf("%T does not implement FixedHasher", h)
+				}
+
+				for _, key := range []uint64{0, 1, 1 << 63, ^uint64(0), rng.Uint64(), rng.Uint64()} {
+					var buf [8]byte
+					binary.LittleEndian.PutUint64(buf[:], key)
+					i1, i2, fp := h.GetIndices(buf[:], numBuckets)
+					if g1, g2, gfp := fixed.GetIndicesUint64(key, numBuckets); g1 != i1 || g2 != i2 || gfp != fp {
+						t.Errorf("GetIndicesUint64(%#x) = (%d, %d, %d), want (%d, %d, %d)", key, g1, g2, gfp, i1, i2, fp)
+					}
+
+					var uuid [16]byte
+					binary.LittleEndian.PutUint64(uuid[:8], key)
+					binary.LittleEndian.PutUint64(uuid[8:], rng.Uint64())
+					i1, i2, fp = h.GetIndices(uuid[:], numBuckets)
+					if g1, g2, gfp := fixed.GetIndicesBytes16(uuid, numBuckets); g1 != i1 || g2 != i2 || gfp != fp {
+						t.Errorf("GetIndicesBytes16(%x) = (%d, %d, %d), want (%d, %d, %d)", uuid, g1, g2, gfp, i1, i2, fp)
+					}
+				}
+
+				allocs := testing.AllocsPerRun(100, func() {
+					fixed.GetIndicesUint64(42, numBuckets)
+					fixed.GetIndicesBytes16([16]byte{42}, numBuckets)
+				})
+				if allocs != 0 {
+					t.Errorf("Fixed-width hashing allocates %v times per key", allocs)
+				}
+			})
+		}
+	}
+}
@@ -25,13 +25,13 @@ func NewBatchProcessor() *BatchProcessor {
 //
 // Future optimization: Could implement SIMD vectorization of FNV-1a
 // using AVX2 to hash 4 items simultaneously with vector operations.
-func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+func (p *BatchProcessor) ProcessBatch(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	// For small-to-medium batches, sequential processing is faster
 	// Goroutine overhead is ~1-2µs per goroutine, which exceeds the benefit for small batches
 	if len(items) < 32 {
-		return processSequential(items, fingerprintBits, numBuckets)
+		return processSequential(items, fingerprintBits, layout, seed, numBuckets)
 	}
 
 	// For larger batches, process in parallel
-	return processParallel(items, fingerprintBits, numBuckets)
+	return processParallel(items, fingerprintBits, layout, seed, numBuckets)
 }
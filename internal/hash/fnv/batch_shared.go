@@ -8,15 +8,9 @@ import (
 
 // processItemFNV computes hash result for a single item using FNV-1a.
 // This is the core hashing logic shared across all platforms.
-func processItemFNV(item []byte, fingerprintBits, numBuckets uint) types.HashResult {
-	// Hash the item
-	hasher := fnv.New64a()
-	hasher.Write(item)
-	hashVal := hasher.Sum64()
-
-	// Extract fingerprint
-	fp := fingerprint(hashVal, fingerprintBits)
-	i1 := uint(hashVal % uint64(numBuckets))
+func processItemFNV(item []byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) types.HashResult {
+	// Extract fingerprint and primary bucket
+	i1, fp := layout.Split(sum64(seed, item), fingerprintBits, numBuckets)
 
 	// Calculate alternative index using fingerprint hash
 	// Use stack-allocated buffer to avoid heap allocation
@@ -35,17 +29,17 @@ func processItemFNV(item []byte, fingerprintBits, numBuckets uint) types.HashRes
 
 // processSequential processes items sequentially without goroutines.
 // Used for small batches where goroutine overhead exceeds any benefit.
-func processSequential(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+func processSequential(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	results := make([]types.HashResult, len(items))
 	for i, item := range items {
-		results[i] = processItemFNV(item, fingerprintBits, numBuckets)
+		results[i] = processItemFNV(item, fingerprintBits, layout, seed, numBuckets)
 	}
 	return results
 }
 
 // processParallel processes items in parallel using goroutines.
 // Splits work into chunks to maximize CPU utilization for large batches.
-func processParallel(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+func processParallel(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	results := make([]types.HashResult, len(items))
 
 	// Calculate optimal chunk size
@@ -73,7 +67,7 @@ func processParallel(items [][]byte, fingerprintBits, numBuckets uint) []types.H
 	for _, c := range chunks {
 		go func(start, end int) {
 			for i := start; i < end; i++ {
-				results[i] = processItemFNV(items[i], fingerprintBits, numBuckets)
+				results[i] = processItemFNV(items[i], fingerprintBits, layout, seed, numBuckets)
 			}
 			done <- struct{}{}
 		}(c.start, c.end)
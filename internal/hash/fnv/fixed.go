@@ -0,0 +1,32 @@
+package fnv
+
+import "encoding/binary"
+
+// FNV-1a 64-bit parameters, as used by hash/fnv
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+// GetIndicesUint64 returns the result of GetIndices for the 8-byte
+// little-endian encoding of key, computed without a byte slice
+func (h *FNVHash) GetIndicesUint64(key uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	return h.indices(fnv1a64(offset64^h.Seed, key), numBuckets)
+}
+
+// GetIndicesBytes16 returns the result of GetIndices for the 16 bytes of key,
+// computed without a byte slice
+func (h *FNVHash) GetIndicesBytes16(key [16]byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	hash := fnv1a64(offset64^h.Seed, binary.LittleEndian.Uint64(key[:8]))
+	return h.indices(fnv1a64(hash, binary.LittleEndian.Uint64(key[8:])), numBuckets)
+}
+
+// fnv1a64 continues an FNV-1a hash over the 8 little-endian bytes of v
+func fnv1a64(hash, v uint64) uint64 {
+	for range 8 {
+		hash ^= v & 0xff
+		hash *= prime64
+		v >>= 8
+	}
+	return hash
+}
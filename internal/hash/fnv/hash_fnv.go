@@ -22,13 +22,16 @@ import (
 // FNVHash instances are safe for concurrent use by multiple goroutines.
 type FNVHash struct {
 	FingerprintBits uint
+	Layout          types.Layout // How hashes are split into bucket and fingerprint
+	Seed            uint64       // XORed into the offset basis; 0 is standard FNV-1a
 	batchProcessor  *BatchProcessor
 }
 
-// NewFNVHash creates a new FNVHash instance
+// NewFNVHash creates a new FNVHash instance using types.LayoutDisjoint
 func NewFNVHash(fingerprintBits uint, batchProcessor *BatchProcessor) *FNVHash {
 	return &FNVHash{
 		FingerprintBits: fingerprintBits,
+		Layout:          types.LayoutDisjoint,
 		batchProcessor:  batchProcessor,
 	}
 }
@@ -38,7 +41,8 @@ func NewFNVHash(fingerprintBits uint, batchProcessor *BatchProcessor) *FNVHash {
 // This method hashes the input item using FNV-1a (Fowler-Noll-Vo) and derives:
 //   - i1: The primary bucket index, computed as fnv1a(item) % numBuckets
 //   - i2: The alternative bucket index, computed as (i1 ^ fnv1a(fp)) % numBuckets
-//   - fp: A non-zero fingerprint (1-255) extracted from the hash, used to identify the item
+//   - fp: A non-zero fingerprint extracted from the hash, used to identify the item.
+//     With types.LayoutDisjoint it comes from the top bits, disjoint from those of i1.
 //
 // FNV-1a is a simple, fast non-cryptographic hash function with good distribution properties.
 // It's implemented in pure Go without assembly, making it portable across all architectures.
@@ -50,10 +54,10 @@ func NewFNVHash(fingerprintBits uint, batchProcessor *BatchProcessor) *FNVHash {
 // Returns:
 //   - i1: Primary bucket index (0 <= i1 < numBuckets)
 //   - i2: Alternative bucket index (0 <= i2 < numBuckets), where i2 = GetAltIndex(i1, fp, numBuckets)
-//   - fp: Fingerprint byte (1 <= fp <= 255, never 0 as that indicates an empty slot)
+//   - fp: Fingerprint (1 <= fp < 2^FingerprintBits, never 0 as that indicates an empty slot)
 //
 // Thread-safety: This method is safe for concurrent use by multiple goroutines.
-// Each call creates a new hasher instance, avoiding any shared state.
+// It keeps no state between calls.
 //
 // Example:
 //
@@ -62,15 +66,24 @@ func NewFNVHash(fingerprintBits uint, batchProcessor *BatchProcessor) *FNVHash {
 //	// i1 and i2 are candidate buckets where the item could be stored
 //	// fp identifies the item within those buckets
 func (h *FNVHash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp uint16) {
-	hasher := fnv.New64a()
-	hasher.Write(item)
-	hashVal := hasher.Sum64()
+	return h.indices(sum64(h.Seed, item), numBuckets)
+}
 
-	fp = fingerprint(hashVal, h.FingerprintBits)
-	i1 = uint(hashVal % uint64(numBuckets))
-	i2 = h.GetAltIndex(i1, fp, numBuckets)
+// sum64 returns the FNV-1a hash of data, starting from the offset basis
+// XORed with seed
+func sum64(seed uint64, data []byte) uint64 {
+	hash := uint64(offset64) ^ seed
+	for _, b := range data {
+		hash ^= uint64(b)
+		hash *= prime64
+	}
+	return hash
+}
 
-	return i1, i2, fp
+// indices derives the buckets and fingerprint of a hash value
+func (h *FNVHash) indices(hashVal uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	i1, fp = h.Layout.Split(hashVal, h.FingerprintBits, numBuckets)
+	return i1, h.GetAltIndex(i1, fp, numBuckets), fp
 }
 
 // GetAltIndex computes the alternative bucket index given a current index and fingerprint.
@@ -158,7 +171,7 @@ func (h *FNVHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
 func (h *FNVHash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashResult {
 	// Use batch processor if available
 	if h.batchProcessor != nil {
-		return h.batchProcessor.ProcessBatch(items, h.FingerprintBits, numBuckets)
+		return h.batchProcessor.ProcessBatch(items, h.FingerprintBits, h.Layout, h.Seed, numBuckets)
 	}
 
 	// Fallback to sequential processing
@@ -169,13 +182,3 @@ func (h *FNVHash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashR
 	}
 	return results
 }
-
-// fingerprint extracts a fingerprint from a hash value
-func fingerprint(hashVal uint64, bits uint) uint16 {
-	fp := uint16(hashVal & ((1 << bits) - 1))
-	// Ensure fingerprint is never zero (0 means empty slot)
-	if fp == 0 {
-		fp = 1
-	}
-	return fp
-}
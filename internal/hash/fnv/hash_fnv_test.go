@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
 )
 
 // TestFNVHashConsistency verifies that hash function produces consistent results
@@ -75,9 +77,10 @@ func TestFNVHashCorrectness(t *testing.T) {
 			}
 
 			// Verify the hash value matches stdlib (indirect verification)
-			if expectedHash%1000000 != uint64(i1) {
-				t.Errorf("%s: index doesn't match expected hash: got %d, expected %d",
-					tc.name, i1, expectedHash%1000000)
+			expectedI1, expectedFp := types.LayoutDisjoint.Split(expectedHash, 8, 1000000)
+			if i1 != expectedI1 || fp != expectedFp {
+				t.Errorf("%s: got (%d, 0x%x), expected (%d, 0x%x) from the stdlib hash",
+					tc.name, i1, fp, expectedI1, expectedFp)
 			}
 		})
 	}
@@ -0,0 +1,63 @@
+package hash
+
+// Grown tables
+//
+// A filter can grow to 2^bits times its bucket count without the original
+// items because the extra index bits are taken from the fingerprint. The
+// grown table is split into 2^bits segments of the original size; the top
+// bits of an item's fingerprint select its segment, and within the segment
+// its buckets are the ones the hash function gives for the original size.
+// Both buckets of an item are in the same segment, so a fingerprint stored
+// in bucket i of the original table moves to bucket i of its segment.
+//
+// Fingerprints in a segment share their top bits, so only the remaining
+// fingerprintBits-bits bits tell items apart: every doubling doubles the
+// false positive rate at a given load factor.
+
+// grownHash adapts a hash function to a table grown by 2^bits
+type grownHash struct {
+	HashInterface
+	fingerprintBits uint
+	bits            uint
+}
+
+// Grown returns h for a table of 2^bits times the bucket count it was
+// designed for. bits must be less than fingerprintBits.
+func Grown(h HashInterface, fingerprintBits, bits uint) HashInterface {
+	if bits == 0 {
+		return h
+	}
+	return &grownHash{HashInterface: h, fingerprintBits: fingerprintBits, bits: bits}
+}
+
+// GrownIndex returns the bucket of a grown table holding fp in bucket index
+// of its segment, for segments of base buckets
+func GrownIndex(index uint, fp uint16, base, fingerprintBits, bits uint) uint {
+	if bits == 0 {
+		return index
+	}
+	return index | (uint(fp)>>(fingerprintBits-bits))*base
+}
+
+func (h *grownHash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	base := numBuckets >> h.bits
+	i1, i2, fp = h.HashInterface.GetIndices(item, base)
+	segment := GrownIndex(0, fp, base, h.fingerprintBits, h.bits)
+	return i1 | segment, i2 | segment, fp
+}
+
+func (h *grownHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
+	base := numBuckets >> h.bits
+	return index&^(base-1) | h.HashInterface.GetAltIndex(index&(base-1), fp, base)
+}
+
+func (h *grownHash) GetIndicesBatch(items [][]byte, numBuckets uint) []HashResult {
+	base := numBuckets >> h.bits
+	results := h.HashInterface.GetIndicesBatch(items, base)
+	for i := range results {
+		segment := GrownIndex(0, results[i].Fp, base, h.fingerprintBits, h.bits)
+		results[i].I1 |= segment
+		results[i].I2 |= segment
+	}
+	return results
+}
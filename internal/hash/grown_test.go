@@ -0,0 +1,53 @@
+package hash
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestGrown tests that a grown hash keeps both buckets of an item in the
+// segment picked by its fingerprint, at the position the original table used
+func TestGrown(t *testing.T) {
+	const base, bits, fpBits = 256, 3, 16
+
+	for _, strategy := range []HashStrategy{HashStrategyFNV, HashStrategyCRC32, HashStrategyXXHash} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			h := NewHashFunction(strategy, fpBits)
+			g := Grown(h, fpBits, bits)
+			if Grown(h, fpBits, 0) != h {
+				t.Error("Grown with 0 bits should return the hash unchanged")
+			}
+
+			items := make([][]byte, 1000)
+			segments := make(map[uint]int)
+			for i := range items {
+				items[i] = []byte(fmt.Sprintf("grown-%d", i))
+			}
+			batch := g.GetIndicesBatch(items, base<<bits)
+
+			for i, item := range items {
+				i1, i2, fp := h.GetIndices(item, base)
+				g1, g2, gfp := g.GetIndices(item, base<<bits)
+				segment := uint(fp) >> (fpBits - bits)
+
+				if gfp != fp || g1 != i1+segment*base || g2 != i2+segment*base {
+					t.Fatalf("%q: grown (%d, %d, %d), original (%d, %d, %d)", item, g1, g2, gfp, i1, i2, fp)
+				}
+				if g.GetAltIndex(g1, fp, base<<bits) != g2 || g.GetAltIndex(g2, fp, base<<bits) != g1 {
+					t.Fatalf("%q: GetAltIndex doesn't map between %d and %d", item, g1, g2)
+				}
+				if b := batch[i]; b.I1 != g1 || b.I2 != g2 || b.Fp != fp {
+					t.Fatalf("%q: batch %+v, single (%d, %d, %d)", item, b, g1, g2, fp)
+				}
+				if GrownIndex(i1, fp, base, fpBits, bits) != g1 {
+					t.Fatalf("%q: GrownIndex disagrees with GetIndices", item)
+				}
+				segments[segment]++
+			}
+
+			if len(segments) != 1<<bits {
+				t.Errorf("Items use %d of %d segments", len(segments), 1<<bits)
+			}
+		})
+	}
+}
@@ -4,6 +4,8 @@
 //   - XXHash: Fastest, best overall performance with SIMD optimizations
 //   - CRC32C: Hardware-accelerated on modern CPUs (SSE4.2)
 //   - FNV-1a: Simple, good distribution, pure Go fallback
+//   - SipHash-2-4: Keyed, for filters fed by untrusted input
+//   - Custom: a 64-bit hash function supplied by the caller
 //
 // All hash implementations support batch processing for improved throughput.
 //
@@ -13,6 +15,7 @@
 //   - xxhash: XXHash64 with SIMD optimization (AVX2)
 //   - crc32: CRC32C with hardware acceleration
 //   - fnv: FNV-1a hash
+//   - siphash: SipHash-2-4 keyed hash
 //
 // Each subpackage follows a consistent structure:
 //   - Main implementation file (xxhash.go, hash_crc32.go, hash_fnv.go)
@@ -20,11 +23,51 @@
 //   - Assembly optimizations where applicable (*.s files)
 package hash
 
-import "github.com/shaia/simdcuckoofilter/internal/hash/types"
+import (
+	"github.com/shaia/simdcuckoofilter/internal/hash/siphash"
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
+)
 
 // HashResult is an alias to types.HashResult for convenience
 type HashResult = types.HashResult
 
+// Layout is an alias to types.Layout for convenience
+type Layout = types.Layout
+
+const (
+	// LayoutLowBits takes fingerprint and primary bucket from the same low hash bits.
+	// It is kept for reading filters serialized before layouts were recorded.
+	LayoutLowBits = types.LayoutLowBits
+
+	// LayoutDisjoint takes the fingerprint from the top hash bits and the
+	// primary bucket from the low bits. New filters use it.
+	LayoutDisjoint = types.LayoutDisjoint
+)
+
+// Params are the settings of a hash function other than its strategy and
+// fingerprint size. The zero value is the unseeded LayoutDisjoint hash.
+type Params struct {
+	Layout Layout // Zero means LayoutDisjoint
+
+	// Seed selects one of 2^64 variants of the FNV-1a, CRC32C and XXHash64
+	// strategies, so items are placed differently than with other seeds. It
+	// doesn't stop an attacker who can observe the filter or, for CRC32C,
+	// who builds colliding items, which collide under every seed.
+	// HashStrategySipHash ignores it.
+	Seed uint64
+
+	// Key is the secret key of HashStrategySipHash; other strategies ignore it
+	Key [16]byte
+
+	// Func is the hash function of HashStrategyCustom; other strategies ignore it
+	Func func([]byte) uint64
+}
+
+// SipHash64 returns the SipHash-2-4 of data under key
+func SipHash64(key [16]byte, data []byte) uint64 {
+	return siphash.Sum64(key, data)
+}
+
 // HashInterface defines the interface for hash functions used in the cuckoo filter.
 type HashInterface interface {
 	// GetIndices returns the two bucket indices and fingerprint for an item
@@ -38,6 +81,18 @@ type HashInterface interface {
 	GetIndicesBatch(items [][]byte, numBuckets uint) []HashResult
 }
 
+// FixedHasher is implemented by every hash function returned by
+// NewHashFunction. It hashes fixed-width keys without a byte slice, so
+// callers don't allocate one per key. Results equal those of GetIndices on
+// the key's little-endian encoding, so a key is found either way.
+type FixedHasher interface {
+	// GetIndicesUint64 hashes the 8-byte little-endian encoding of key
+	GetIndicesUint64(key uint64, numBuckets uint) (i1, i2 uint, fp uint16)
+
+	// GetIndicesBytes16 hashes the 16 bytes of key
+	GetIndicesBytes16(key [16]byte, numBuckets uint) (i1, i2 uint, fp uint16)
+}
+
 // HashStrategy represents different hash function options
 type HashStrategy int
 
@@ -48,6 +103,10 @@ const (
 	HashStrategyCRC32
 	// HashStrategyXXHash uses XXHash (fastest, best performance)
 	HashStrategyXXHash
+	// HashStrategySipHash uses SipHash-2-4 keyed with Params.Key (resists key flooding)
+	HashStrategySipHash
+	// HashStrategyCustom uses Params.Func, a 64-bit hash supplied by the caller
+	HashStrategyCustom
 )
 
 // String returns the name of the hash strategy
@@ -59,6 +118,10 @@ func (s HashStrategy) String() string {
 		return "CRC32C"
 	case HashStrategyXXHash:
 		return "XXHash64"
+	case HashStrategySipHash:
+		return "SipHash-2-4"
+	case HashStrategyCustom:
+		return "Custom"
 	default:
 		return "Unknown"
 	}
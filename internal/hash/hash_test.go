@@ -18,6 +18,8 @@ func TestHashStrategyString(t *testing.T) {
 		{HashStrategyFNV, "FNV-1a"},
 		{HashStrategyCRC32, "CRC32C"},
 		{HashStrategyXXHash, "XXHash64"},
+		{HashStrategySipHash, "SipHash-2-4"},
+		{HashStrategyCustom, "Custom"},
 		{HashStrategy(999), "Unknown"},
 	}
 
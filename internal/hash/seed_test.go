@@ -0,0 +1,72 @@
+package hash
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSeededPlacement tests that different seeds, or SipHash keys, place
+// items in different buckets with different fingerprints, and that seed 0
+// is the unseeded hash
+func TestSeededPlacement(t *testing.T) {
+	const numBuckets = 1 << 16
+	items := make([][]byte, 2000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("item-%d", i))
+	}
+
+	for _, strategy := range []HashStrategy{HashStrategyFNV, HashStrategyCRC32, HashStrategyXXHash, HashStrategySipHash} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			unseeded := NewHashFunction(strategy, 16)
+			zero := NewHashFunctionWithParams(strategy, 16, Params{})
+			a := NewHashFunctionWithParams(strategy, 16, Params{Seed: 1, Key: [16]byte{1}})
+			b := NewHashFunctionWithParams(strategy, 16, Params{Seed: 2, Key: [16]byte{2}})
+
+			same, sameBucket := 0, 0
+			for _, item := range items {
+				ui1, ui2, ufp := unseeded.GetIndices(item, numBuckets)
+				if zi1, zi2, zfp := zero.GetIndices(item, numBuckets); zi1 != ui1 || zi2 != ui2 || zfp != ufp {
+					t.Fatalf("Seed 0 places %q differently from the unseeded hash", item)
+				}
+
+				ai1, _, afp := a.GetIndices(item, numBuckets)
+				bi1, _, bfp := b.GetIndices(item, numBuckets)
+				if ai1 == bi1 {
+					sameBucket++
+					if afp == bfp {
+						same++
+					}
+				}
+			}
+
+			// Independent placements share a bucket with probability 2^-16
+			if same != 0 || sameBucket > 5 {
+				t.Errorf("%d of %d items in the same bucket under both seeds, %d with the same fingerprint",
+					sameBucket, len(items), same)
+			}
+		})
+	}
+}
+
+// TestSeededBatch tests that batch hashing uses the seed or key, through
+// both the sequential and the parallel batch paths
+func TestSeededBatch(t *testing.T) {
+	const numBuckets = 1 << 20
+	for _, strategy := range []HashStrategy{HashStrategyFNV, HashStrategyCRC32, HashStrategyXXHash, HashStrategySipHash} {
+		for _, n := range []int{8, 100} {
+			t.Run(fmt.Sprintf("%s/%d", strategy, n), func(t *testing.T) {
+				h := NewHashFunctionWithParams(strategy, 12, Params{Seed: 0xfeedface, Key: [16]byte{9, 9}})
+				items := make([][]byte, n)
+				for i := range items {
+					items[i] = []byte(fmt.Sprintf("batch-%d", i))
+				}
+
+				for i, r := range h.GetIndicesBatch(items, numBuckets) {
+					if i1, i2, fp := h.GetIndices(items[i], numBuckets); r.I1 != i1 || r.I2 != i2 || r.Fp != fp {
+						t.Errorf("Batch result %+v for %q, want (%d, %d, %d)", r, items[i], i1, i2, fp)
+					}
+				}
+			})
+		}
+	}
+}
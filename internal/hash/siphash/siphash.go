@@ -0,0 +1,161 @@
+// Package siphash provides a keyed SipHash-2-4 hash for the Cuckoo Filter.
+//
+// The other strategies are fast but unkeyed or linear, so anyone who knows
+// which one a filter uses can compute keys that land in the same buckets
+// with the same fingerprint, making inserts fail and lookups match. SipHash
+// is a pseudorandom function of a secret 128-bit key: without the key,
+// placements can't be predicted or steered. It is slower than the other
+// strategies and has no SIMD batch path.
+package siphash
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/shaia/simdcuckoofilter/internal/hash/types"
+)
+
+// SipHash derives bucket indices and fingerprints from SipHash-2-4 keyed
+// with a secret key. Hashes are split with types.LayoutDisjoint.
+//
+// SipHash instances are safe for concurrent use by multiple goroutines.
+type SipHash struct {
+	fingerprintBits uint
+	k0, k1          uint64
+}
+
+// NewSipHash creates a SipHash instance with the given key. The key must be
+// kept secret and must be the same every time the filter is used.
+func NewSipHash(key [16]byte, fingerprintBits uint) *SipHash {
+	return &SipHash{
+		fingerprintBits: fingerprintBits,
+		k0:              binary.LittleEndian.Uint64(key[:8]),
+		k1:              binary.LittleEndian.Uint64(key[8:]),
+	}
+}
+
+// GetIndices computes the two bucket indices and fingerprint for an item.
+//
+// The item is hashed with SipHash-2-4 under the key; the fingerprint comes
+// from the top bits of the hash and the primary bucket from the low bits.
+// The alternate bucket is GetAltIndex(i1, fp, numBuckets).
+func (h *SipHash) GetIndices(item []byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	return h.indices(sum64(h.k0, h.k1, item), numBuckets)
+}
+
+// indices derives the buckets and fingerprint of a hash value
+func (h *SipHash) indices(hashVal uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	i1, fp = types.LayoutDisjoint.Split(hashVal, h.fingerprintBits, numBuckets)
+	return i1, h.GetAltIndex(i1, fp, numBuckets), fp
+}
+
+// GetAltIndex computes the alternative bucket index given a current index
+// and fingerprint. GetAltIndex(GetAltIndex(i, fp, n), fp, n) == i.
+//
+// It doesn't use the key: the fingerprint and the primary bucket it is
+// applied to are already unpredictable.
+func (h *SipHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
+	// Odd, so the alternate bucket differs from index when numBuckets > 1
+	hash := uint64(fp)*0x5bd1e995 | 1
+	return uint((uint64(index) ^ hash) % uint64(numBuckets))
+}
+
+// GetIndicesBatch computes indices and fingerprints for multiple items
+func (h *SipHash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashResult {
+	results := make([]types.HashResult, len(items))
+	for i, item := range items {
+		i1, i2, fp := h.GetIndices(item, numBuckets)
+		results[i] = types.HashResult{I1: i1, I2: i2, Fp: fp}
+	}
+	return results
+}
+
+// GetIndicesUint64 returns the result of GetIndices for the 8-byte
+// little-endian encoding of key, computed without a byte slice
+func (h *SipHash) GetIndicesUint64(key uint64, numBuckets uint) (i1, i2 uint, fp uint16) {
+	s := newState(h.k0, h.k1)
+	s.block(key)
+	return h.indices(s.finish(8<<56), numBuckets)
+}
+
+// GetIndicesBytes16 returns the result of GetIndices for the 16 bytes of key,
+// computed without a byte slice
+func (h *SipHash) GetIndicesBytes16(key [16]byte, numBuckets uint) (i1, i2 uint, fp uint16) {
+	s := newState(h.k0, h.k1)
+	s.block(binary.LittleEndian.Uint64(key[:8]))
+	s.block(binary.LittleEndian.Uint64(key[8:]))
+	return h.indices(s.finish(16<<56), numBuckets)
+}
+
+// Sum64 returns the SipHash-2-4 of data under key
+func Sum64(key [16]byte, data []byte) uint64 {
+	return sum64(binary.LittleEndian.Uint64(key[:8]), binary.LittleEndian.Uint64(key[8:]), data)
+}
+
+// sum64 returns the SipHash-2-4 of data under the key k0, k1
+func sum64(k0, k1 uint64, data []byte) uint64 {
+	s := newState(k0, k1)
+	length := len(data)
+	for ; len(data) >= 8; data = data[8:] {
+		s.block(binary.LittleEndian.Uint64(data))
+	}
+
+	// The last block holds the remaining bytes and the length in its top byte
+	last := uint64(length) << 56
+	for i, b := range data {
+		last |= uint64(b) << (8 * i)
+	}
+	return s.finish(last)
+}
+
+// state is the internal state of SipHash
+type state struct {
+	v0, v1, v2, v3 uint64
+}
+
+// newState initializes the state for the key k0, k1
+func newState(k0, k1 uint64) state {
+	return state{
+		v0: k0 ^ 0x736f6d6570736575,
+		v1: k1 ^ 0x646f72616e646f6d,
+		v2: k0 ^ 0x6c7967656e657261,
+		v3: k1 ^ 0x7465646279746573,
+	}
+}
+
+// block compresses one 8-byte message block with 2 rounds
+func (s *state) block(m uint64) {
+	s.v3 ^= m
+	s.round()
+	s.round()
+	s.v0 ^= m
+}
+
+// finish compresses the last block and returns the hash after 4
+// finalization rounds
+func (s *state) finish(last uint64) uint64 {
+	s.block(last)
+	s.v2 ^= 0xff
+	for range 4 {
+		s.round()
+	}
+	return s.v0 ^ s.v1 ^ s.v2 ^ s.v3
+}
+
+// round is one SipRound
+func (s *state) round() {
+	s.v0 += s.v1
+	s.v1 = bits.RotateLeft64(s.v1, 13)
+	s.v1 ^= s.v0
+	s.v0 = bits.RotateLeft64(s.v0, 32)
+	s.v2 += s.v3
+	s.v3 = bits.RotateLeft64(s.v3, 16)
+	s.v3 ^= s.v2
+	s.v0 += s.v3
+	s.v3 = bits.RotateLeft64(s.v3, 21)
+	s.v3 ^= s.v0
+	s.v2 += s.v1
+	s.v1 = bits.RotateLeft64(s.v1, 17)
+	s.v1 ^= s.v2
+	s.v2 = bits.RotateLeft64(s.v2, 32)
+}
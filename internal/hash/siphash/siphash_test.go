@@ -0,0 +1,75 @@
+package siphash
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// TestSum64Vectors checks Sum64 against the test vectors of the SipHash
+// reference implementation: key 00 01 .. 0f, messages 00 01 .. of each length
+func TestSum64Vectors(t *testing.T) {
+	var key [16]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	data := make([]byte, 16)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	vectors := map[int]uint64{
+		0:  0x726fdb47dd0e0e31,
+		1:  0x74f839c593dc67fd,
+		15: 0xa129ca6149be45e5,
+	}
+	for n, want := range vectors {
+		if got := Sum64(key, data[:n]); got != want {
+			t.Errorf("Sum64 of %d bytes = %#x, want %#x", n, got, want)
+		}
+	}
+}
+
+// TestFixedWidthKeys tests that fixed-width keys hash like their byte encodings
+func TestFixedWidthKeys(t *testing.T) {
+	h := NewSipHash([16]byte{1, 2, 3}, 12)
+	const numBuckets = 1 << 16
+
+	for _, key := range []uint64{0, 1, 0xdeadbeef, ^uint64(0)} {
+		var buf [16]byte
+		binary.LittleEndian.PutUint64(buf[:], key)
+		i1, i2, fp := h.GetIndices(buf[:8], numBuckets)
+		if g1, g2, gfp := h.GetIndicesUint64(key, numBuckets); g1 != i1 || g2 != i2 || gfp != fp {
+			t.Errorf("GetIndicesUint64(%#x) = (%d, %d, %d), want (%d, %d, %d)", key, g1, g2, gfp, i1, i2, fp)
+		}
+
+		binary.LittleEndian.PutUint64(buf[8:], ^key)
+		i1, i2, fp = h.GetIndices(buf[:], numBuckets)
+		if g1, g2, gfp := h.GetIndicesBytes16(buf, numBuckets); g1 != i1 || g2 != i2 || gfp != fp {
+			t.Errorf("GetIndicesBytes16(%x) = (%d, %d, %d), want (%d, %d, %d)", buf, g1, g2, gfp, i1, i2, fp)
+		}
+	}
+}
+
+// TestKeyedPlacement tests that items are placed differently under
+// different keys and that the alternate index is an involution
+func TestKeyedPlacement(t *testing.T) {
+	a := NewSipHash([16]byte{1}, 16)
+	b := NewSipHash([16]byte{2}, 16)
+	const numBuckets = 1 << 12
+
+	same := 0
+	for i := range 1000 {
+		item := binary.LittleEndian.AppendUint32(nil, uint32(i))
+		ai1, ai2, afp := a.GetIndices(item, numBuckets)
+		bi1, _, bfp := b.GetIndices(item, numBuckets)
+		if ai1 == bi1 && afp == bfp {
+			same++
+		}
+		if a.GetAltIndex(ai2, afp, numBuckets) != ai1 {
+			t.Fatalf("GetAltIndex is not an involution for item %d", i)
+		}
+	}
+	if same != 0 {
+		t.Errorf("%d of 1000 items placed identically under different keys", same)
+	}
+}
@@ -8,3 +8,69 @@ type HashResult struct {
 	I1, I2 uint   // Two bucket indices for cuckoo hashing
 	Fp     uint16 // Fingerprint value (never zero, as 0 indicates empty slot)
 }
+
+// Layout selects which bits of an item's hash become its fingerprint and
+// which its primary bucket. Filters record the layout they were built with,
+// so data written with an older layout keeps being read with it.
+type Layout uint8
+
+const (
+	// LayoutLowBits takes both the fingerprint and the primary bucket from
+	// the low bits of the hash. Items sharing a bucket then share the low
+	// bits of their fingerprints, which raises the false positive rate well
+	// above 2b/2^f and, when the alternate bucket depends on those bits,
+	// stops inserts early. It is only used for data serialized before the
+	// layout was recorded.
+	LayoutLowBits Layout = 1
+
+	// LayoutDisjoint mixes the hash with a 64-bit finalizer, then takes the
+	// fingerprint from the top bits and the primary bucket from the low
+	// bits, so the two are independent for tables of up to 2^(64-f)
+	// buckets. The finalizer spreads hashes whose high bits are weak, such
+	// as FNV-1a of short keys, over all 64 bits.
+	LayoutDisjoint Layout = 2
+)
+
+// Split returns the primary bucket and the non-zero fingerprint of an item
+// with the given hash
+func (l Layout) Split(hash uint64, fingerprintBits, numBuckets uint) (i1 uint, fp uint16) {
+	if l == LayoutLowBits {
+		fp = uint16(hash & (1<<fingerprintBits - 1))
+	} else {
+		hash = Mix(hash)
+		fp = uint16(hash >> (64 - fingerprintBits))
+	}
+	// Ensure fingerprint is never zero (0 means empty slot)
+	if fp == 0 {
+		fp = 1
+	}
+	return uint(hash % uint64(numBuckets)), fp
+}
+
+// Mix is the 64-bit finalizer of MurmurHash3, a bijection in which every
+// input bit affects every output bit
+func Mix(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
+
+// Valid reports whether l is a known layout
+func (l Layout) Valid() bool {
+	return l == LayoutLowBits || l == LayoutDisjoint
+}
+
+// String returns the name of the layout
+func (l Layout) String() string {
+	switch l {
+	case LayoutLowBits:
+		return "low bits"
+	case LayoutDisjoint:
+		return "disjoint"
+	default:
+		return "unknown"
+	}
+}
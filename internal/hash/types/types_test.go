@@ -0,0 +1,71 @@
+package types
+
+import (
+	"math/bits"
+	"testing"
+)
+
+// TestLayoutSplit tests where each layout takes bucket and fingerprint bits from
+func TestLayoutSplit(t *testing.T) {
+	testCases := []struct {
+		hashVal uint64
+		bits    uint
+		i1      uint
+		fp      uint16
+	}{
+		{0x00, 8, 0, 1},            // Zero should become 1
+		{0xFF, 8, 0xFF, 0xFF},      // All bits set
+		{0x100, 8, 0x100, 1},       // Overflow, low bits zero -> 1
+		{0x1234, 8, 0x234, 0x34},   // Extract low 8 bits
+		{0x1234, 12, 0x234, 0x234}, // Bucket and fingerprint overlap
+		{0x0F, 4, 0xF, 0x0F},       // Max 4-bit value
+	}
+	for _, tc := range testCases {
+		i1, fp := LayoutLowBits.Split(tc.hashVal, tc.bits, 1024)
+		if i1 != tc.i1 || fp != tc.fp {
+			t.Errorf("LayoutLowBits.Split(0x%x, %d) = (0x%x, 0x%x), want (0x%x, 0x%x)",
+				tc.hashVal, tc.bits, i1, fp, tc.i1, tc.fp)
+		}
+	}
+
+	// LayoutDisjoint takes the bucket from the low and the fingerprint from
+	// the high end of the mixed hash
+	for _, hashVal := range []uint64{0, 1, 0x1234, 0xABCD << 48, ^uint64(0)} {
+		for _, fpBits := range []uint{4, 8, 16} {
+			mixed := Mix(hashVal)
+			wantFp := uint16(mixed >> (64 - fpBits))
+			if wantFp == 0 {
+				wantFp = 1
+			}
+			i1, fp := LayoutDisjoint.Split(hashVal, fpBits, 1024)
+			if i1 != uint(mixed&1023) || fp != wantFp {
+				t.Errorf("LayoutDisjoint.Split(0x%x, %d) = (0x%x, 0x%x), want (0x%x, 0x%x)",
+					hashVal, fpBits, i1, fp, mixed&1023, wantFp)
+			}
+		}
+	}
+
+	if Layout(0).Valid() || Layout(3).Valid() || !LayoutLowBits.Valid() || !LayoutDisjoint.Valid() {
+		t.Error("Valid() accepts unknown layouts or rejects known ones")
+	}
+}
+
+// TestMixAvalanche tests that flipping any input bit flips about half of the
+// output bits, including those a fingerprint is taken from
+func TestMixAvalanche(t *testing.T) {
+	for bit := range 64 {
+		var flipped, top int
+		for i := range uint64(1000) {
+			hashVal := i * 0x9E3779B97F4A7C15
+			diff := Mix(hashVal) ^ Mix(hashVal^1<<bit)
+			flipped += bits.OnesCount64(diff)
+			top += bits.OnesCount64(diff >> 48)
+		}
+		if avg := float64(flipped) / 1000; avg < 28 || avg > 36 {
+			t.Errorf("Flipping bit %d flips %.1f output bits on average, want about 32", bit, avg)
+		}
+		if avg := float64(top) / 1000; avg < 6 || avg > 10 {
+			t.Errorf("Flipping bit %d flips %.1f of the top 16 bits on average, want about 8", bit, avg)
+		}
+	}
+}
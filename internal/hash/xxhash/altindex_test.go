@@ -156,7 +156,7 @@ func TestSingleByteFingerprintHashing(t *testing.T) {
 		hashResult := xxh.hash64(fpBuf[:])
 
 		// Verify using the Go reference implementation
-		expectedHash := hash64XXHashGo(fpBuf[:])
+		expectedHash := hash64XXHashGo(fpBuf[:], 0)
 
 		if hashResult != expectedHash {
 			if failureCount == 0 {
@@ -10,7 +10,7 @@ import (
 )
 
 // BatchHashProcessor handles AVX2-optimized batch hashing for AMD64.
-// Processes 4 items in parallel using 256-bit AVX2 registers.
+// Processes 4 items in parallel using 256-bit AVX2 registers when the CPU supports them.
 type BatchHashProcessor struct{}
 
 // NewBatchHashProcessor creates a new batch hash processor
@@ -19,25 +19,42 @@ func NewBatchHashProcessor() *BatchHashProcessor {
 }
 
 // ProcessBatchXXHash processes multiple items using XXHash.
-// Currently falls back to scalar implementation to support 16-bit fingerprints.
-// TODO: Update AVX2 assembly to support 16-bit fingerprints and re-enable.
-func (p *BatchHashProcessor) ProcessBatchXXHash(items [][]byte, fingerprintBits, numBuckets uint) []types.HashResult {
+//
+// On CPUs with AVX2, groups of 4 items are hashed in parallel by
+// processBatchXXHashAVX2 when the bucket count is a power of 2, as it is in
+// every filter. The last len(items)%4 items, and all items on other CPUs,
+// are hashed one at a time. Results equal those of XXHash.GetIndices.
+func (p *BatchHashProcessor) ProcessBatchXXHash(items [][]byte, fingerprintBits uint, layout types.Layout, seed uint64, numBuckets uint) []types.HashResult {
 	results := make([]types.HashResult, len(items))
 
-	// Use scalar implementation for now
-	xxh := &XXHash{fingerprintBits: fingerprintBits}
-	for i, item := range items {
-		i1, i2, fp := xxh.GetIndices(item, numBuckets)
+	n := 0
+	if hasAVX2 && numBuckets > 0 && numBuckets&(numBuckets-1) == 0 {
+		n = len(items) &^ 3
+	}
+	if n > 0 {
+		// types.Layout.Split, with the fingerprint taken from the top bits
+		// of the mixed hash unless the layout is types.LayoutLowBits
+		mix := layout != types.LayoutLowBits
+		var fpShift uint64
+		if mix {
+			fpShift = uint64(64 - fingerprintBits)
+		}
+		processBatchXXHashAVX2(items[:n], results[:n], seed, uint64(numBuckets-1), fpShift, 1<<fingerprintBits-1, mix)
+	}
+
+	xxh := &XXHash{fingerprintBits: fingerprintBits, layout: layout, seed: seed}
+	for i := n; i < len(items); i++ {
+		i1, i2, fp := xxh.GetIndices(items[i], numBuckets)
 		results[i] = types.HashResult{I1: i1, I2: i2, Fp: fp}
 	}
 
 	return results
 }
 
-// processBatchXXHashAVX2 is implemented in batch_avx2_amd64.s
-// Processes 4 items in parallel using AVX2 256-bit SIMD instructions.
-// Note: Currently unused as we fallback to scalar for 16-bit support
-/*
+// processBatchXXHashAVX2 is implemented in batch_avx2_amd64.s.
+// It hashes len(items) items, a multiple of 4, into results for a table of
+// bucketMask+1 buckets. Fingerprints are hash>>fpShift & fpMask, after
+// types.Mix if mix is set.
+//
 //go:noescape
-func processBatchXXHashAVX2(items [][]byte, results []types.HashResult, fingerprintBits, numBuckets uint)
-*/
+func processBatchXXHashAVX2(items [][]byte, results []types.HashResult, seed, bucketMask, fpShift, fpMask uint64, mix bool)
@@ -0,0 +1,76 @@
+//go:build arm64
+// +build arm64
+
+package xxhash
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestHashLanesNEON verifies that every lane of the NEON kernel matches the Go
+// reference for all key lengths it accepts, alongside keys of other lengths
+func TestHashLanesNEON(t *testing.T) {
+	data := make([]byte, 4*neonMaxLen)
+	for i := range data {
+		data[i] = byte(i*31 + 7)
+	}
+
+	for _, seed := range []uint64{0, 1, 0x9e3779b97f4a7c15, ^uint64(0)} {
+		for length := 0; length <= neonMaxLen; length++ {
+			// The key under test in each lane, next to keys of other lengths
+			for lane := 0; lane < 4; lane++ {
+				var lanes neonLanes
+				var keys [4][]byte
+				blocks, tails := 0, 0
+				for l := range keys {
+					n := (length + 11*l) % (neonMaxLen + 1)
+					if l == lane {
+						n = length
+					}
+					keys[l] = data[l*neonMaxLen : l*neonMaxLen+n]
+					b, tl := lanes.set(l, keys[l])
+					blocks, tails = max(blocks, b), max(tails, tl)
+				}
+
+				var hashes [4]uint64
+				hashLanesNEON(&lanes, seed, blocks, tails, &hashes)
+				for l, key := range keys {
+					if want := hash64XXHashGo(key, seed); hashes[l] != want {
+						t.Fatalf("seed %#x, lane %d, %d bytes: NEON %016x, Go %016x", seed, l, len(key), hashes[l], want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestHashLanesNEONReused verifies that data left in lanes by earlier keys
+// does not affect shorter keys or unused lanes
+func TestHashLanesNEONReused(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var lanes neonLanes
+	for iter := 0; iter < 10000; iter++ {
+		seed := rng.Uint64()
+		n := 2 + rng.Intn(3)
+		keys := make([][]byte, n)
+		blocks, tails := 0, 0
+		for l := range lanes.lens {
+			lanes.lens[l] = 0
+		}
+		for l := range keys {
+			keys[l] = make([]byte, rng.Intn(neonMaxLen+1))
+			rng.Read(keys[l])
+			b, tl := lanes.set(l, keys[l])
+			blocks, tails = max(blocks, b), max(tails, tl)
+		}
+
+		var hashes [4]uint64
+		hashLanesNEON(&lanes, seed, blocks, tails, &hashes)
+		for l, key := range keys {
+			if want := hash64XXHashGo(key, seed); hashes[l] != want {
+				t.Fatalf("iteration %d, lane %d of %d, %d bytes: NEON %016x, Go %016x", iter, l, n, len(key), hashes[l], want)
+			}
+		}
+	}
+}
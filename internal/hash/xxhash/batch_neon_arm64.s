@@ -0,0 +1,146 @@
+//go:build arm64
+// +build arm64
+
+#include "textflag.h"
+
+// NEON has no 64-bit multiply, so products are built from 32-bit halves:
+// a*c mod 2^64 = lo(a)*lo(c) + (hi(a)*lo(c) + lo(a)*hi(c))<<32.
+// clo and chi hold the low and high words of the constant c in every
+// 32-bit element. lo and hi are clobbered; d may be the same register as a.
+#define MUL64(a, clo, chi, lo, hi, d) \
+	VXTN   a.D2, lo.S2;          \
+	VSHRN  $32, a.D2, hi.S2;     \
+	VUMULL clo.S2, hi.S2, d.D2;  \
+	VUMLAL chi.S2, lo.S2, d.D2;  \
+	VSHL   $32, d.D2, d.D2;      \
+	VUMLAL clo.S2, lo.S2, d.D2
+
+// ROTL sets each lane of d to x rotated left by r bits
+#define ROTL(x, r, d) \
+	VSHL $r, x.D2, d.D2; \
+	VSRI $(64-r), x.D2, d.D2
+
+// XORSHIFT sets each lane of x to x ^ x>>r, clobbering t
+#define XORSHIFT(x, r, t) \
+	VUSHR $r, x.D2, t.D2; \
+	VEOR  t.B16, x.B16, x.B16
+
+// BLOCK mixes the 8-byte blocks in k into the lanes of h whose bits are set
+// in mask m: h = rotl(h^(rotl(k*prime64_2, 31)*prime64_1), 27)*prime64_1 + prime64_4
+#define BLOCK(h, k, m, t0, t1, t2, t3) \
+	MUL64(k, V16, V17, t0, t1, t2);  \
+	ROTL(t2, 31, t3);                \
+	MUL64(t3, V18, V19, t0, t1, t2); \
+	VEOR  t2.B16, h.B16, t3.B16;     \
+	ROTL(t3, 27, t2);                \
+	MUL64(t2, V18, V19, t0, t1, t3); \
+	VADD  V24.D2, t3.D2, t3.D2;      \
+	VBIT  m.B16, t3.B16, h.B16
+
+// TAILBYTE mixes the bytes in b into the lanes of h whose bits are set in
+// mask m: h = rotl(h^(b*prime64_5), 11)*prime64_1
+#define TAILBYTE(h, b, m, t0, t1, t2, t3) \
+	MUL64(b, V20, V21, t0, t1, t2);  \
+	VEOR  t2.B16, h.B16, t3.B16;     \
+	ROTL(t3, 11, t2);                \
+	MUL64(t2, V18, V19, t0, t1, t3); \
+	VBIT  m.B16, t3.B16, h.B16
+
+// AVALANCHE is the final mix of hash64XXHashInternal
+#define AVALANCHE(h, t0, t1, t2) \
+	XORSHIFT(h, 33, t2);           \
+	MUL64(h, V16, V17, t0, t1, h); \
+	XORSHIFT(h, 29, t2);           \
+	MUL64(h, V22, V23, t0, t1, h); \
+	XORSHIFT(h, 32, t2)
+
+// hashLanesNEON computes hash64XXHashInternal of the 4 keys in lanes, two
+// per 128-bit register. Every lane runs the same sequence of steps; a step
+// past the end of a key is computed but not kept, selected out with VBIT by
+// comparing the step number with the key's block or tail byte count.
+// blocks and tails are the largest counts among the keys.
+//
+// func hashLanesNEON(lanes *neonLanes, seed uint64, blocks, tails int, hashes *[4]uint64)
+TEXT ·hashLanesNEON(SB), NOSPLIT, $0-40
+	MOVD lanes+0(FP), R0
+	MOVD seed+8(FP), R1
+	MOVD blocks+16(FP), R2
+	MOVD tails+24(FP), R3
+	MOVD hashes+32(FP), R4
+
+	// Low and high words of the primes
+	MOVD $0x27d4eb4f, R5 // prime64_2
+	VDUP R5, V16.S4
+	MOVD $0xc2b2ae3d, R5
+	VDUP R5, V17.S4
+	MOVD $0x85ebca87, R5 // prime64_1
+	VDUP R5, V18.S4
+	MOVD $0x9e3779b1, R5
+	VDUP R5, V19.S4
+	MOVD $0x165667c5, R5 // prime64_5
+	VDUP R5, V20.S4
+	MOVD $0x27d4eb2f, R5
+	VDUP R5, V21.S4
+	MOVD $0x9e3779f9, R5 // prime64_3
+	VDUP R5, V22.S4
+	MOVD $0x165667b1, R5
+	VDUP R5, V23.S4
+	MOVD $0x85ebca77c2b2ae63, R5 // prime64_4
+	VDUP R5, V24.D2
+	MOVD $1, R5
+	VDUP R5, V11.D2
+
+	// Lengths of keys 0-1 in V0, 2-3 in V1
+	VLD1.P 32(R0), [V0.D2, V1.D2]
+
+	// hash = seed + prime64_5 + len
+	MOVD $0x27d4eb2f165667c5, R5
+	ADD  R1, R5, R5
+	VDUP R5, V2.D2
+	VADD V2.D2, V0.D2, V4.D2
+	VADD V2.D2, V1.D2, V5.D2
+
+	// Block counts in V8-V9, tail byte counts in V6-V7
+	VUSHR $3, V0.D2, V8.D2
+	VUSHR $3, V1.D2, V9.D2
+	MOVD  $7, R5
+	VDUP  R5, V2.D2
+	VAND  V2.B16, V0.B16, V6.B16
+	VAND  V2.B16, V1.B16, V7.B16
+
+	// Tail bytes follow the 4 rows of blocks
+	ADD  $128, R0, R6
+
+	// V10 = step number
+	VEOR V10.B16, V10.B16, V10.B16
+	CBZ  R2, tail
+
+block_loop:
+	VLD1.P 32(R0), [V12.D2, V13.D2]
+	VCMHI  V10.D2, V8.D2, V14.D2
+	VCMHI  V10.D2, V9.D2, V15.D2
+	BLOCK(V4, V12, V14, V25, V26, V27, V28)
+	BLOCK(V5, V13, V15, V29, V30, V31, V3)
+	VADD   V11.D2, V10.D2, V10.D2
+	SUB    $1, R2
+	CBNZ   R2, block_loop
+
+tail:
+	VEOR V10.B16, V10.B16, V10.B16
+	CBZ  R3, finalize
+
+tail_loop:
+	VLD1.P 32(R6), [V12.D2, V13.D2]
+	VCMHI  V10.D2, V6.D2, V14.D2
+	VCMHI  V10.D2, V7.D2, V15.D2
+	TAILBYTE(V4, V12, V14, V25, V26, V27, V28)
+	TAILBYTE(V5, V13, V15, V29, V30, V31, V3)
+	VADD   V11.D2, V10.D2, V10.D2
+	SUB    $1, R3
+	CBNZ   R3, tail_loop
+
+finalize:
+	AVALANCHE(V4, V25, V26, V27)
+	AVALANCHE(V5, V29, V30, V31)
+	VST1 [V4.D2, V5.D2], (R4)
+	RET
@@ -201,3 +201,44 @@ func TestBatchProcessingMemorySafety(t *testing.T) {
 		}
 	}
 }
+
+// FuzzProcessBatchXXHash compares ProcessBatchXXHash, which uses the AVX2
+// kernel for groups of 4 items where available, with scalar GetIndices.
+//
+// The items are cut from data: each one starts with a byte giving its
+// length, so batch sizes and item lengths both vary with the input.
+func FuzzProcessBatchXXHash(f *testing.F) {
+	f.Add([]byte("\x01a\x02bc\x03def\x04ghij"), uint64(0), uint8(8), uint8(10), false)
+	f.Add([]byte("\x10the quick brown \x09fox jumps\x00\x21over the lazy dog, over and again"), uint64(42), uint8(16), uint8(20), false)
+	f.Add(make([]byte, 200), uint64(1)<<63, uint8(1), uint8(0), true)
+	f.Add([]byte("\x08abcdefgh\x08ijklmnop\x08qrstuvwx\x08yz012345\x07abcdefg"), ^uint64(0), uint8(12), uint8(3), true)
+
+	f.Fuzz(func(t *testing.T, data []byte, seed uint64, bits, logBuckets uint8, lowBits bool) {
+		fingerprintBits := uint(bits%16) + 1
+		numBuckets := uint(1) << (logBuckets % 25)
+		layout := types.LayoutDisjoint
+		if lowBits {
+			layout = types.LayoutLowBits
+		}
+
+		var items [][]byte
+		for len(data) > 0 {
+			n := min(int(data[0])%72, len(data)-1)
+			items = append(items, data[1:1+n])
+			data = data[1+n:]
+		}
+
+		ref := &XXHash{fingerprintBits: fingerprintBits, layout: layout, seed: seed}
+		results := NewBatchHashProcessor().ProcessBatchXXHash(items, fingerprintBits, layout, seed, numBuckets)
+		if len(results) != len(items) {
+			t.Fatalf("got %d results for %d items", len(results), len(items))
+		}
+		for i, item := range items {
+			i1, i2, fp := ref.GetIndices(item, numBuckets)
+			if want := (types.HashResult{I1: i1, I2: i2, Fp: fp}); results[i] != want {
+				t.Fatalf("item %d of %d (%d bytes, %d fingerprint bits, %d buckets, %v): got %+v, want %+v",
+					i, len(items), len(item), fingerprintBits, numBuckets, layout, results[i], want)
+			}
+		}
+	})
+}
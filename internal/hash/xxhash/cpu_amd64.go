@@ -0,0 +1,37 @@
+//go:build amd64
+// +build amd64
+
+package xxhash
+
+// hasAVX2 reports whether the CPU supports AVX2 and the operating system
+// saves the YMM registers, so processBatchXXHashAVX2 can run
+var hasAVX2 = detectAVX2()
+
+// detectAVX2 checks CPUID and XCR0 for AVX2 support
+func detectAVX2() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+
+	// AVX, and XGETBV enabled by the OS (OSXSAVE)
+	const osxsave, avx = 1 << 27, 1 << 28
+	if _, _, ecx, _ := cpuid(1, 0); ecx&(osxsave|avx) != osxsave|avx {
+		return false
+	}
+	// XMM and YMM state saved on context switches
+	if xcr0, _ := xgetbv(); xcr0&6 != 6 {
+		return false
+	}
+
+	_, ebx, _, _ := cpuid(7, 0)
+	return ebx&(1<<5) != 0
+}
+
+// cpuid executes CPUID with the given leaf and subleaf.
+// Implemented in cpu_amd64.s
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// xgetbv returns the low and high words of XCR0.
+// Implemented in cpu_amd64.s
+func xgetbv() (eax, edx uint32)
@@ -0,0 +1,39 @@
+package xxhash
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// GetIndicesUint64 returns the result of GetIndices for the 8-byte
+// little-endian encoding of key, computed without a byte slice
+func (h *XXHash) GetIndicesUint64(key uint64, numBuckets uint) (uint, uint, uint16) {
+	return h.indices(avalanche(round(h.seed+prime64_5+8, key)), numBuckets)
+}
+
+// GetIndicesBytes16 returns the result of GetIndices for the 16 bytes of key,
+// computed without a byte slice
+func (h *XXHash) GetIndicesBytes16(key [16]byte, numBuckets uint) (uint, uint, uint16) {
+	hash := round(h.seed+prime64_5+16, binary.LittleEndian.Uint64(key[:8]))
+	hash = round(hash, binary.LittleEndian.Uint64(key[8:]))
+	return h.indices(avalanche(hash), numBuckets)
+}
+
+// round mixes one 8-byte lane into hash, as hash64XXHashGo does
+func round(hash, lane uint64) uint64 {
+	lane *= prime64_2
+	lane = bits.RotateLeft64(lane, 31)
+	lane *= prime64_1
+	hash ^= lane
+	return bits.RotateLeft64(hash, 27)*prime64_1 + prime64_4
+}
+
+// avalanche is the final mix of hash64XXHashGo
+func avalanche(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= prime64_2
+	hash ^= hash >> 29
+	hash *= prime64_3
+	hash ^= hash >> 32
+	return hash
+}
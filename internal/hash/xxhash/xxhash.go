@@ -2,7 +2,7 @@
 // This is the primary hash function used by the Cuckoo Filter with
 // multi-architecture SIMD support:
 //   - AMD64: AVX2 SIMD batch processing
-//   - ARM64: Optimized assembly (NEON batch processing planned for future)
+//   - ARM64: NEON batch processing of short keys, optimized assembly for single items
 package xxhash
 
 import "github.com/shaia/simdcuckoofilter/internal/hash/types"
@@ -14,17 +14,35 @@ import "github.com/shaia/simdcuckoofilter/internal/hash/types"
 // XXHash instances are safe for concurrent use by multiple goroutines.
 type XXHash struct {
 	fingerprintBits uint
+	layout          types.Layout
+	seed            uint64
 	batchProcessor  *BatchHashProcessor
 }
 
-// NewXXHash creates a new XXHash instance
+// NewXXHash creates a new XXHash instance using types.LayoutDisjoint
 func NewXXHash(fingerprintBits uint, batchProcessor *BatchHashProcessor) *XXHash {
 	return &XXHash{
 		fingerprintBits: fingerprintBits,
+		layout:          types.LayoutDisjoint,
 		batchProcessor:  batchProcessor,
 	}
 }
 
+// WithLayout returns a copy of h that splits hashes with layout
+func (h *XXHash) WithLayout(layout types.Layout) *XXHash {
+	c := *h
+	c.layout = layout
+	return &c
+}
+
+// WithSeed returns a copy of h that starts hashing from seed instead of 0,
+// as the seed parameter of XXHash64 does
+func (h *XXHash) WithSeed(seed uint64) *XXHash {
+	c := *h
+	c.seed = seed
+	return &c
+}
+
 const (
 	prime64_1 = 11400714785074694791
 	prime64_2 = 14029467366897019727
@@ -38,7 +56,8 @@ const (
 // This method hashes the input item using XXHash64 and derives:
 //   - i1: The primary bucket index, computed as hash(item) % numBuckets
 //   - i2: The alternative bucket index, computed as (i1 ^ hash(fp)) % numBuckets
-//   - fp: A non-zero fingerprint (1-255) extracted from the hash, used to identify the item
+//   - fp: A non-zero fingerprint extracted from the hash, used to identify the item.
+//     With types.LayoutDisjoint it comes from the top bits, disjoint from those of i1.
 //
 // Parameters:
 //   - item: The data to hash (typically a key or value being inserted into the filter)
@@ -47,7 +66,7 @@ const (
 // Returns:
 //   - i1: Primary bucket index (0 <= i1 < numBuckets)
 //   - i2: Alternative bucket index (0 <= i2 < numBuckets), where i2 = GetAltIndex(i1, fp, numBuckets)
-//   - fp: Fingerprint byte (1 <= fp <= 255, never 0 as that indicates an empty slot)
+//   - fp: Fingerprint (1 <= fp < 2^fingerprintBits, never 0 as that indicates an empty slot)
 //
 // Thread-safety: This method is safe for concurrent use by multiple goroutines.
 //
@@ -58,18 +77,13 @@ const (
 //	// i1 and i2 are candidate buckets where the item could be stored
 //	// fp identifies the item within those buckets
 func (h *XXHash) GetIndices(item []byte, numBuckets uint) (uint, uint, uint16) {
-	hashVal := h.hash64(item)
-
-	// Extract fingerprint
-	fp := fingerprint(hashVal, h.fingerprintBits)
-
-	// Calculate first index
-	i1 := uint(hashVal % uint64(numBuckets))
-
-	// Calculate second index
-	i2 := h.GetAltIndex(i1, fp, numBuckets)
+	return h.indices(h.hash64(item), numBuckets)
+}
 
-	return i1, i2, fp
+// indices derives the buckets and fingerprint of a hash value
+func (h *XXHash) indices(hashVal uint64, numBuckets uint) (uint, uint, uint16) {
+	i1, fp := h.layout.Split(hashVal, h.fingerprintBits, numBuckets)
+	return i1, h.GetAltIndex(i1, fp, numBuckets), fp
 }
 
 // GetAltIndex computes the alternative bucket index given a current index and fingerprint.
@@ -123,7 +137,7 @@ func (h *XXHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
 // This method processes multiple items in a single call, leveraging SIMD optimizations
 // when available to achieve significant performance gains:
 //   - AMD64: AVX2 (4-way parallel) batch processing
-//   - ARM64: Optimized assembly (NEON batch processing planned for future)
+//   - ARM64: NEON (4 short keys in parallel) batch processing
 //   - Fallback: Sequential scalar processing if no SIMD support
 //
 // The batch processing can be 2-4x faster than calling GetIndices repeatedly,
@@ -157,7 +171,7 @@ func (h *XXHash) GetAltIndex(index uint, fp uint16, numBuckets uint) uint {
 func (h *XXHash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashResult {
 	// Use SIMD batch processor if available
 	if h.batchProcessor != nil {
-		return h.batchProcessor.ProcessBatchXXHash(items, h.fingerprintBits, numBuckets)
+		return h.batchProcessor.ProcessBatchXXHash(items, h.fingerprintBits, h.layout, h.seed, numBuckets)
 	}
 
 	// Scalar fallback
@@ -170,15 +184,5 @@ func (h *XXHash) GetIndicesBatch(items [][]byte, numBuckets uint) []types.HashRe
 }
 
 func (h *XXHash) hash64(data []byte) uint64 {
-	return hash64XXHashInternal(data)
-}
-
-// fingerprint extracts a fingerprint from a hash value
-func fingerprint(hashVal uint64, bits uint) uint16 {
-	fp := uint16(hashVal & ((1 << bits) - 1))
-	// Ensure fingerprint is never zero (0 means empty slot)
-	if fp == 0 {
-		fp = 1
-	}
-	return fp
+	return hash64XXHashInternal(data, h.seed)
 }
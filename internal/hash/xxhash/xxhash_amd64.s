@@ -4,7 +4,6 @@
 #include "textflag.h"
 
 // Constants for XXHash - stored as data
-// Note: These constants are shared with batch_avx2_amd64.s, so they cannot be file-private
 DATA prime64_1+0(SB)/8, $11400714785074694791
 DATA prime64_2+0(SB)/8, $14029467366897019727
 DATA prime64_3+0(SB)/8, $1609587929392839161
@@ -17,14 +16,15 @@ GLOBL prime64_4(SB), RODATA|NOPTR, $8
 GLOBL prime64_5(SB), RODATA|NOPTR, $8
 
 // hash64XXHashInternal computes XXHash64 for a single item
-// func hash64XXHashInternal(data []byte) uint64
-TEXT ·hash64XXHashInternal(SB), NOSPLIT, $0-32
+// func hash64XXHashInternal(data []byte, seed uint64) uint64
+TEXT ·hash64XXHashInternal(SB), NOSPLIT, $0-40
     // Load arguments
     MOVQ data_base+0(FP), BX   // BX = data pointer
     MOVQ data_len+8(FP), CX    // CX = data length
     
-    // Initialize hash = prime64_5 + len
+    // Initialize hash = seed + prime64_5 + len
     MOVQ prime64_5(SB), AX   // AX = hash = prime64_5
+    ADDQ seed+24(FP), AX       // hash += seed
     ADDQ CX, AX                // hash += len
     
     // Check if length >= 8
@@ -126,6 +126,6 @@ hash_finalize:
     XORQ SI, AX
     
     // Return hash in AX
-    MOVQ AX, ret+24(FP)
+    MOVQ AX, ret+32(FP)
     RET
 
@@ -4,4 +4,4 @@
 package xxhash
 
 //go:noescape
-func hash64XXHashInternal(data []byte) uint64
+func hash64XXHashInternal(data []byte, seed uint64) uint64
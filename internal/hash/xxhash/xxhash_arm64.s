@@ -16,8 +16,8 @@ GLOBL prime64_4<>(SB), RODATA, $8
 GLOBL prime64_5<>(SB), RODATA, $8
 
 // hash64XXHashInternal computes XXHash64 for a single item
-// func hash64XXHashInternal(data []byte) uint64
-TEXT ·hash64XXHashInternal(SB), NOSPLIT, $0-32
+// func hash64XXHashInternal(data []byte, seed uint64) uint64
+TEXT ·hash64XXHashInternal(SB), NOSPLIT, $0-40
     // Load arguments
     MOVD data_base+0(FP), R0   // R0 = data pointer
     MOVD data_len+8(FP), R1    // R1 = data length
@@ -26,8 +26,10 @@ TEXT ·hash64XXHashInternal(SB), NOSPLIT, $0-32
     MOVD $prime64_5<>(SB), R2
     MOVD (R2), R3              // R3 = prime64_5
 
-    // Initialize hash = prime64_5 + len
+    // Initialize hash = seed + prime64_5 + len
     ADD  R1, R3, R4            // R4 = hash = prime64_5 + len
+    MOVD seed+24(FP), R5
+    ADD  R5, R4, R4            // R4 += seed
 
     // Check if length >= 8
     CMP  $8, R1
@@ -151,5 +153,5 @@ hash_finalize:
     EOR  R5, R4
 
     // Return hash in R4
-    MOVD R4, ret+24(FP)
+    MOVD R4, ret+32(FP)
     RET
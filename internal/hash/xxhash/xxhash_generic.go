@@ -4,6 +4,6 @@
 package xxhash
 
 // hash64XXHashInternal calls the Go fallback implementation for generic architectures
-func hash64XXHashInternal(data []byte) uint64 {
-	return hash64XXHashGo(data)
+func hash64XXHashInternal(data []byte, seed uint64) uint64 {
+	return hash64XXHashGo(data, seed)
 }
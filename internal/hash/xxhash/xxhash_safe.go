@@ -1,11 +1,11 @@
 package xxhash
 
 // hash64XXHashGo is the Go fallback implementation
-func hash64XXHashGo(data []byte) uint64 {
+func hash64XXHashGo(data []byte, seed uint64) uint64 {
 	var hash uint64
 
 	if len(data) >= 8 {
-		hash = prime64_5 + uint64(len(data))
+		hash = seed + prime64_5 + uint64(len(data))
 		for len(data) >= 8 {
 			k := uint64(data[0]) | uint64(data[1])<<8 | uint64(data[2])<<16 | uint64(data[3])<<24 |
 				uint64(data[4])<<32 | uint64(data[5])<<40 | uint64(data[6])<<48 | uint64(data[7])<<56
@@ -18,7 +18,7 @@ func hash64XXHashGo(data []byte) uint64 {
 			data = data[8:]
 		}
 	} else {
-		hash = prime64_5 + uint64(len(data))
+		hash = seed + prime64_5 + uint64(len(data))
 	}
 
 	for len(data) > 0 {
@@ -33,15 +33,17 @@ func TestXXHashAssemblyVsGo(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Assembly implementation
-			asmResult := hash64XXHashInternal(tc.data)
+			for _, seed := range []uint64{0, 1, 0x9e3779b97f4a7c15, ^uint64(0)} {
+				// Assembly implementation
+				asmResult := hash64XXHashInternal(tc.data, seed)
 
-			// Go reference implementation
-			goResult := hash64XXHashGo(tc.data)
+				// Go reference implementation
+				goResult := hash64XXHashGo(tc.data, seed)
 
-			if asmResult != goResult {
-				t.Errorf("Hash mismatch for %q:\n  Assembly: %016x\n  Go:       %016x",
-					tc.name, asmResult, goResult)
+				if asmResult != goResult {
+					t.Errorf("Hash mismatch for %q with seed %#x:\n  Assembly: %016x\n  Go:       %016x",
+						tc.name, seed, asmResult, goResult)
+				}
 			}
 		})
 	}
@@ -65,8 +67,8 @@ func TestXXHashFinalByteProcessing(t *testing.T) {
 	}
 
 	for _, data := range testCases {
-		asmResult := hash64XXHashInternal(data)
-		goResult := hash64XXHashGo(data)
+		asmResult := hash64XXHashInternal(data, 0)
+		goResult := hash64XXHashGo(data, 0)
 
 		if asmResult != goResult {
 			t.Errorf("Hash mismatch for %d-byte input %q:\n  Assembly: %016x\n  Go:       %016x",
@@ -105,33 +107,4 @@ func TestFingerprintZeroHandling(t *testing.T) {
 			t.Errorf("Input %d produced zero fingerprint (forbidden): %v", i, input)
 		}
 	}
-
-	// Test the edge case where hash would naturally produce fp=0
-	// The fingerprint function should convert it to 1
-	hashValZeroFp := uint64(0) // This would give fp=0 without the check
-	fp := fingerprint(hashValZeroFp, fingerprintBits)
-	if fp != 1 {
-		t.Errorf("Zero fingerprint not converted to 1: got %d", fp)
-	}
-
-	// Test hash values that would produce 0 after masking
-	for bits := uint(4); bits <= 8; bits++ {
-		mask := (uint64(1) << bits) - 1
-		testValues := []uint64{
-			0,                  // Direct zero
-			^mask,              // All high bits set, low bits zero
-			0xFFFFFFFFFFFFFF00, // Example of non-zero hash with zero fingerprint
-			uint64(1) << bits,  // Power of 2 (would be zero after mask)
-			uint64(256),        // Would be zero for 8-bit fingerprint
-			uint64(16),         // Would be zero for 4-bit fingerprint
-		}
-
-		for _, hashVal := range testValues {
-			fp := fingerprint(hashVal, bits)
-			if fp == 0 {
-				t.Errorf("fingerprint(%d, %d bits) returned 0, should return 1",
-					hashVal, bits)
-			}
-		}
-	}
 }
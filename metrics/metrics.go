@@ -0,0 +1,257 @@
+// Package metrics publishes cuckoo filter metrics in the Prometheus text
+// exposition format and through expvar, without depending on a metrics
+// library. Filters are registered by name in a Registry, which serves the
+// metrics of all of them.
+//
+// Example:
+//
+//	reg := metrics.NewRegistry()
+//	sessions, _ := reg.Register("sessions", cf)
+//	sessions.Lookup([]byte("id")) // counted in cuckoofilter_lookups_total
+//
+//	http.Handle("/metrics", reg) // Prometheus text format
+//	expvar.Publish("cuckoofilters", reg)
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	cuckoofilter "github.com/shaia/simdcuckoofilter"
+)
+
+var (
+	// ErrDuplicateName is returned when registering a filter under a name
+	// already in use
+	ErrDuplicateName = errors.New("filter name already registered")
+
+	// ErrInvalidName is returned when registering a filter under an empty name
+	ErrInvalidName = errors.New("filter name must not be empty")
+)
+
+// StatsProvider is implemented by filters with a Stats method, such as
+// *cuckoofilter.Filter. Their insert, kick and occupancy statistics are
+// published as well.
+type StatsProvider interface {
+	Stats() cuckoofilter.Stats
+}
+
+// Snapshot holds the metrics of one filter at one point in time
+type Snapshot struct {
+	Count          uint    `json:"count"`
+	Capacity       uint    `json:"capacity"`
+	LoadFactor     float64 `json:"load_factor"`
+	MemoryBytes    uint    `json:"memory_bytes"`
+	Inserts        uint64  `json:"inserts"`
+	FailedInserts  uint64  `json:"failed_inserts"`
+	Lookups        uint64  `json:"lookups"`
+	LookupHits     uint64  `json:"lookup_hits"`
+	LookupHitRatio float64 `json:"lookup_hit_ratio"`
+
+	// Set only for filters implementing StatsProvider
+	HasStats                   bool    `json:"-"`
+	Kicks                      uint64  `json:"kicks,omitempty"`
+	FullBuckets                uint    `json:"full_buckets,omitempty"`
+	Stashed                    uint    `json:"stashed,omitempty"`
+	EstimatedFalsePositiveRate float64 `json:"estimated_false_positive_rate,omitempty"`
+}
+
+// Filter wraps a registered filter and counts the operations made through
+// it. Inserts and lookups must go through the wrapper to be counted; this
+// costs an atomic increment per call. For filters implementing
+// StatsProvider, insert counts come from Stats and include inserts made on
+// the filter directly.
+type Filter struct {
+	cuckoofilter.CuckooFilter
+
+	insertCalls, failedInserts atomic.Uint64
+	lookups, lookupHits        atomic.Uint64
+}
+
+var _ cuckoofilter.BatchFilter = (*Filter)(nil)
+
+// Insert adds an item to the filter, counting failures
+func (f *Filter) Insert(item []byte) bool {
+	ok := f.CuckooFilter.Insert(item)
+	f.countInserts(1, boolToUint(ok))
+	return ok
+}
+
+// Lookup checks if an item might be in the filter, counting hits
+func (f *Filter) Lookup(item []byte) bool {
+	found := f.CuckooFilter.Lookup(item)
+	f.countLookups(1, boolToUint(found))
+	return found
+}
+
+// InsertBatch inserts multiple items, in one call if the filter supports batches
+func (f *Filter) InsertBatch(items [][]byte) []bool {
+	var results []bool
+	if bf, ok := f.CuckooFilter.(cuckoofilter.BatchFilter); ok {
+		results = bf.InsertBatch(items)
+	} else {
+		results = eachItem(items, f.CuckooFilter.Insert)
+	}
+	f.countInserts(uint64(len(results)), countTrue(results))
+	return results
+}
+
+// LookupBatch checks multiple items, in one call if the filter supports batches
+func (f *Filter) LookupBatch(items [][]byte) []bool {
+	var results []bool
+	if bf, ok := f.CuckooFilter.(cuckoofilter.BatchFilter); ok {
+		results = bf.LookupBatch(items)
+	} else {
+		results = eachItem(items, f.CuckooFilter.Lookup)
+	}
+	f.countLookups(uint64(len(results)), countTrue(results))
+	return results
+}
+
+// DeleteBatch deletes multiple items, in one call if the filter supports batches
+func (f *Filter) DeleteBatch(items [][]byte) []bool {
+	if bf, ok := f.CuckooFilter.(cuckoofilter.BatchFilter); ok {
+		return bf.DeleteBatch(items)
+	}
+	return eachItem(items, f.CuckooFilter.Delete)
+}
+
+// OptimalBatchSize returns the batch size of the filter, 1 without batch support
+func (f *Filter) OptimalBatchSize() int {
+	if bf, ok := f.CuckooFilter.(cuckoofilter.BatchFilter); ok {
+		return bf.OptimalBatchSize()
+	}
+	return 1
+}
+
+// Unwrap returns the registered filter
+func (f *Filter) Unwrap() cuckoofilter.CuckooFilter {
+	return f.CuckooFilter
+}
+
+// Snapshot returns the current metrics of the filter
+func (f *Filter) Snapshot() Snapshot {
+	s := Snapshot{
+		Count:         f.Count(),
+		Capacity:      f.Capacity(),
+		LoadFactor:    f.LoadFactor(),
+		MemoryBytes:   f.MemoryUsage(),
+		Inserts:       f.insertCalls.Load() - f.failedInserts.Load(),
+		FailedInserts: f.failedInserts.Load(),
+		Lookups:       f.lookups.Load(),
+		LookupHits:    f.lookupHits.Load(),
+	}
+	if s.Lookups > 0 {
+		s.LookupHitRatio = float64(s.LookupHits) / float64(s.Lookups)
+	}
+
+	if sp, ok := f.CuckooFilter.(StatsProvider); ok {
+		stats := sp.Stats()
+		s.HasStats = true
+		s.Inserts = stats.Inserts
+		s.FailedInserts = stats.FailedInserts
+		s.Kicks = stats.Kicks
+		s.FullBuckets = stats.FullBuckets
+		s.Stashed = stats.Stashed
+		s.EstimatedFalsePositiveRate = stats.EstimatedFalsePositiveRate
+	}
+	return s
+}
+
+func (f *Filter) countInserts(n, ok uint64) {
+	f.insertCalls.Add(n)
+	if n > ok {
+		f.failedInserts.Add(n - ok)
+	}
+}
+
+func (f *Filter) countLookups(n, hits uint64) {
+	f.lookups.Add(n)
+	if hits > 0 {
+		f.lookupHits.Add(hits)
+	}
+}
+
+// Registry holds named filters and publishes their metrics. It implements
+// http.Handler, serving the Prometheus text format, and expvar.Var, so it
+// can be passed to expvar.Publish. A Registry is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	filters map[string]*Filter
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{filters: make(map[string]*Filter)}
+}
+
+// Register adds a filter under name and returns the wrapper that counts its
+// inserts and lookups
+func (r *Registry) Register(name string, cf cuckoofilter.CuckooFilter) (*Filter, error) {
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.filters[name]; ok {
+		return nil, ErrDuplicateName
+	}
+	f := &Filter{CuckooFilter: cf}
+	r.filters[name] = f
+	return f, nil
+}
+
+// Unregister removes the filter registered under name
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.filters, name)
+}
+
+// Snapshot returns the current metrics of every registered filter by name
+func (r *Registry) Snapshot() map[string]Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshots := make(map[string]Snapshot, len(r.filters))
+	for name, f := range r.filters {
+		snapshots[name] = f.Snapshot()
+	}
+	return snapshots
+}
+
+// String implements expvar.Var, returning the snapshots as a JSON object
+func (r *Registry) String() string {
+	data, err := json.Marshal(r.Snapshot())
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+func eachItem(items [][]byte, op func([]byte) bool) []bool {
+	results := make([]bool, len(items))
+	for i, item := range items {
+		results[i] = op(item)
+	}
+	return results
+}
+
+func countTrue(results []bool) uint64 {
+	n := uint64(0)
+	for _, ok := range results {
+		n += boolToUint(ok)
+	}
+	return n
+}
+
+func boolToUint(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
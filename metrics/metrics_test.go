@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cuckoofilter "github.com/shaia/simdcuckoofilter"
+)
+
+var _ expvar.Var = (*Registry)(nil)
+
+// newRegistry registers a *cuckoofilter.Filter, which has Stats, as "batch"
+// and a scalable filter, which doesn't, as "scalable"
+func newRegistry(t *testing.T) (reg *Registry, batch, scalable *Filter) {
+	t.Helper()
+	reg = NewRegistry()
+
+	cf, err := cuckoofilter.NewBatch(1000)
+	if err != nil {
+		t.Fatalf("NewBatch failed: %v", err)
+	}
+	sf, err := cuckoofilter.NewScalable(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewScalable failed: %v", err)
+	}
+
+	if batch, err = reg.Register("batch", cf); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if scalable, err = reg.Register("scalable", sf); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	return reg, batch, scalable
+}
+
+func items(prefix string, n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = []byte(fmt.Sprintf("%s-%d", prefix, i))
+	}
+	return out
+}
+
+// TestSnapshot tests the counters of wrapped filters with and without Stats
+func TestSnapshot(t *testing.T) {
+	reg, batch, scalable := newRegistry(t)
+
+	for _, f := range []*Filter{batch, scalable} {
+		f.InsertBatch(items("a", 100))
+		f.Insert([]byte("b"))
+		f.LookupBatch(items("a", 100))
+		for _, item := range items("missing", 100) {
+			f.Lookup(item)
+		}
+	}
+
+	snapshots := reg.Snapshot()
+	for name, s := range snapshots {
+		if s.Count != 101 || s.Inserts != 101 || s.FailedInserts != 0 {
+			t.Errorf("%s: Count %d, Inserts %d, FailedInserts %d, want 101, 101, 0", name, s.Count, s.Inserts, s.FailedInserts)
+		}
+		if s.Lookups != 200 || s.LookupHits < 100 || s.LookupHitRatio != float64(s.LookupHits)/200 {
+			t.Errorf("%s: %d lookups, %d hits, ratio %f", name, s.Lookups, s.LookupHits, s.LookupHitRatio)
+		}
+	}
+	if s := snapshots["batch"]; !s.HasStats || s.EstimatedFalsePositiveRate == 0 {
+		t.Errorf("Expected Stats for *cuckoofilter.Filter: %+v", s)
+	}
+	if s := snapshots["scalable"]; s.HasStats {
+		t.Errorf("Unexpected Stats for a scalable filter: %+v", s)
+	}
+
+	// Inserts made on the filter directly count when it has Stats
+	batch.Unwrap().Insert([]byte("direct"))
+	if s := batch.Snapshot(); s.Inserts != 102 {
+		t.Errorf("Inserts = %d, want 102", s.Inserts)
+	}
+}
+
+// TestFailedInserts tests that rejected inserts are counted without Stats
+func TestFailedInserts(t *testing.T) {
+	cf, _ := cuckoofilter.New(8)
+	f, _ := NewRegistry().Register("small", cf)
+
+	results := f.InsertBatch(items("fill", 64))
+	failed := uint64(0)
+	for _, ok := range results {
+		if !ok {
+			failed++
+		}
+	}
+	if s := f.Snapshot(); failed == 0 || s.FailedInserts != failed || s.Inserts != 64-failed {
+		t.Errorf("FailedInserts %d, Inserts %d; %d of 64 failed", s.FailedInserts, s.Inserts, failed)
+	}
+}
+
+// TestWritePrometheus tests the text exposition output
+func TestWritePrometheus(t *testing.T) {
+	reg, batch, _ := newRegistry(t)
+	batch.Insert([]byte("x"))
+	batch.Lookup([]byte("x"))
+	cf, _ := cuckoofilter.New(100)
+	reg.Register("quo\"te\\d\nname", cf)
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	out := rec.Body.String()
+
+	for _, want := range []string{
+		"# HELP cuckoofilter_items Items stored in the filter.\n# TYPE cuckoofilter_items gauge\n",
+		"# TYPE cuckoofilter_inserts_total counter\n",
+		`cuckoofilter_items{filter="batch"} 1` + "\n",
+		`cuckoofilter_lookup_hit_ratio{filter="batch"} 1` + "\n",
+		`cuckoofilter_kicks_total{filter="batch"} 0` + "\n",
+		`cuckoofilter_items{filter="quo\"te\\d\nname"} 0` + "\n",
+		`cuckoofilter_lookups_total{filter="scalable"} 0` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `cuckoofilter_kicks_total{filter="scalable"}`) {
+		t.Error("Stats metrics published for a filter without Stats")
+	}
+	if strings.Count(out, "# TYPE cuckoofilter_items ") != 1 {
+		t.Error("Expected one TYPE line per metric")
+	}
+
+	// Filters appear in name order
+	if strings.Index(out, `{filter="batch"}`) > strings.Index(out, `{filter="scalable"}`) {
+		t.Error("Filters out of order")
+	}
+}
+
+// TestExpvar tests the JSON published through expvar
+func TestExpvar(t *testing.T) {
+	reg, batch, _ := newRegistry(t)
+	batch.Insert([]byte("x"))
+
+	var got map[string]map[string]any
+	if err := json.Unmarshal([]byte(reg.String()), &got); err != nil {
+		t.Fatalf("String() is not JSON: %v", err)
+	}
+	if got["batch"]["count"] != 1.0 || got["scalable"]["count"] != 0.0 {
+		t.Errorf("Unexpected expvar output %v", got)
+	}
+	if _, ok := got["scalable"]["kicks"]; ok {
+		t.Error("Stats fields published for a filter without Stats")
+	}
+}
+
+// TestRegister tests name validation and Unregister
+func TestRegister(t *testing.T) {
+	reg, _, _ := newRegistry(t)
+	cf, _ := cuckoofilter.New(100)
+
+	if _, err := reg.Register("batch", cf); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName, got %v", err)
+	}
+	if _, err := reg.Register("", cf); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("Expected ErrInvalidName, got %v", err)
+	}
+
+	reg.Unregister("batch")
+	if _, ok := reg.Snapshot()["batch"]; ok {
+		t.Error("Unregistered filter still published")
+	}
+	if _, err := reg.Register("batch", cf); err != nil {
+		t.Errorf("Register after Unregister failed: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// family is one metric of the exposition, with a value per filter
+type family struct {
+	name, kind, help string
+
+	// value returns the metric of s, or false if s doesn't have it
+	value func(s *Snapshot) (float64, bool)
+}
+
+var families = []family{
+	{"cuckoofilter_items", "gauge", "Items stored in the filter.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Count), true }},
+	{"cuckoofilter_capacity", "gauge", "Fingerprint slots in the filter.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Capacity), true }},
+	{"cuckoofilter_load_factor", "gauge", "Fraction of slots in use.",
+		func(s *Snapshot) (float64, bool) { return s.LoadFactor, true }},
+	{"cuckoofilter_memory_bytes", "gauge", "Size of the fingerprint table in bytes.",
+		func(s *Snapshot) (float64, bool) { return float64(s.MemoryBytes), true }},
+	{"cuckoofilter_inserts_total", "counter", "Successful inserts.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Inserts), true }},
+	{"cuckoofilter_insert_failures_total", "counter", "Inserts rejected because the filter was full.",
+		func(s *Snapshot) (float64, bool) { return float64(s.FailedInserts), true }},
+	{"cuckoofilter_lookups_total", "counter", "Lookups made through the registered filter.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Lookups), true }},
+	{"cuckoofilter_lookup_hits_total", "counter", "Lookups that found the item.",
+		func(s *Snapshot) (float64, bool) { return float64(s.LookupHits), true }},
+	{"cuckoofilter_lookup_hit_ratio", "gauge", "Fraction of lookups that found the item.",
+		func(s *Snapshot) (float64, bool) { return s.LookupHitRatio, true }},
+	{"cuckoofilter_kicks_total", "counter", "Fingerprints relocated by inserts.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Kicks), s.HasStats }},
+	{"cuckoofilter_full_buckets", "gauge", "Buckets with no free slot.",
+		func(s *Snapshot) (float64, bool) { return float64(s.FullBuckets), s.HasStats }},
+	{"cuckoofilter_stashed", "gauge", "Fingerprints in the victim stash.",
+		func(s *Snapshot) (float64, bool) { return float64(s.Stashed), s.HasStats }},
+	{"cuckoofilter_estimated_false_positive_rate", "gauge", "False positive rate estimated from the current load.",
+		func(s *Snapshot) (float64, bool) { return s.EstimatedFalsePositiveRate, s.HasStats }},
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the metrics of every registered filter in the
+// Prometheus text exposition format, labelled with filter="name"
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	snapshots := r.Snapshot()
+	order := slices.Sorted(maps.Keys(snapshots))
+
+	bw := bufio.NewWriter(w)
+	for _, fam := range families {
+		header := false
+		for _, name := range order {
+			s := snapshots[name]
+			v, ok := fam.value(&s)
+			if !ok {
+				continue
+			}
+			if !header {
+				bw.WriteString("# HELP " + fam.name + " " + fam.help + "\n")
+				bw.WriteString("# TYPE " + fam.name + " " + fam.kind + "\n")
+				header = true
+			}
+			bw.WriteString(fam.name + `{filter="` + labelEscaper.Replace(name) + `"} `)
+			bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64) + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler, serving WritePrometheus
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
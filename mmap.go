@@ -0,0 +1,68 @@
+package cuckoofilter
+
+import (
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+)
+
+// ReadOnlyFilter is a filter that can be queried but not modified.
+// It is returned by OpenMmap; Insert and Delete always fail with ErrReadOnly.
+type ReadOnlyFilter interface {
+	// Lookup checks if an item might be in the filter
+	Lookup(item []byte) bool
+
+	// LookupBatch checks multiple items
+	LookupBatch(items [][]byte) []bool
+
+	// Insert returns ErrReadOnly
+	Insert(item []byte) error
+
+	// Delete returns ErrReadOnly
+	Delete(item []byte) error
+
+	// Count returns the number of items stored in the filter
+	Count() uint
+
+	// LoadFactor returns current load factor (0.0 to 1.0)
+	LoadFactor() float64
+
+	// Capacity returns the total capacity of the filter
+	Capacity() uint
+
+	// MemoryUsage returns the size of the mapped fingerprint table in bytes
+	MemoryUsage() uint
+
+	// Close releases the underlying mapping.
+	// Lookups after Close report false.
+	Close() error
+}
+
+// OpenMmap maps a filter file saved with WriteTo or MarshalBinary and serves
+// lookups directly from the mapped pages, so processes opening the same file
+// share a single copy of the fingerprint table. The file is validated,
+// including its checksum, before OpenMmap returns. Platforms without mmap
+// read the file into memory instead. Files of filters using SipHash or a
+// custom hash need WithSipHash or WithCustomHash, as for Load; other options
+// are ignored.
+//
+// Example:
+//
+//	rf, err := cuckoofilter.OpenMmap("denylist.cf")
+//	if err != nil {
+//	    return err
+//	}
+//	defer rf.Close()
+//	blocked := rf.Lookup([]byte("item"))
+func OpenMmap(path string, opts ...Option) (ReadOnlyFilter, error) {
+	if params := unstoredParams(opts); params != nil {
+		f, err := filter.OpenMmapWith(path, *params)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	f, err := filter.OpenMmap(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
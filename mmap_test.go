@@ -0,0 +1,88 @@
+package cuckoofilter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOpenMmap validates serving lookups from a mapped filter file
+func TestOpenMmap(t *testing.T) {
+	cf, _ := New(10000, WithBucketSize(8))
+	for i := 0; i < 5000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("deny-%d", i)))
+	}
+
+	path := filepath.Join(t.TempDir(), "denylist.cf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := cf.(SerializableFilter).WriteTo(file); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	file.Close()
+
+	rf, err := OpenMmap(path)
+	if err != nil {
+		t.Fatalf("OpenMmap failed: %v", err)
+	}
+	defer rf.Close()
+
+	if rf.Count() != cf.Count() {
+		t.Errorf("Expected count %d, got %d", cf.Count(), rf.Count())
+	}
+	for i := 0; i < 5000; i++ {
+		if !rf.Lookup([]byte(fmt.Sprintf("deny-%d", i))) {
+			t.Errorf("Item %d not found in mapped filter", i)
+		}
+	}
+
+	if err := rf.Insert([]byte("new")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+// TestOpenMmapSipHash validates mapping a filter keyed with WithSipHash
+func TestOpenMmapSipHash(t *testing.T) {
+	key := [16]byte{15: 0xff}
+	cf, _ := New(10000, WithSipHash(key))
+	for i := 0; i < 1000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("deny-%d", i)))
+	}
+	data, _ := cf.(SerializableFilter).MarshalBinary()
+	path := filepath.Join(t.TempDir(), "keyed.cf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	if _, err := OpenMmap(path); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("Expected ErrKeyRequired, got %v", err)
+	}
+	rf, err := OpenMmap(path, WithSipHash(key))
+	if err != nil {
+		t.Fatalf("OpenMmap failed: %v", err)
+	}
+	defer rf.Close()
+	for i := 0; i < 1000; i++ {
+		if !rf.Lookup([]byte(fmt.Sprintf("deny-%d", i))) {
+			t.Errorf("Item %d not found in mapped filter", i)
+		}
+	}
+}
+
+// TestOpenMmapInvalid validates that OpenMmap returns a nil filter on error
+func TestOpenMmapInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.cf")
+	os.WriteFile(path, []byte("not a filter"), 0o644)
+
+	rf, err := OpenMmap(path)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat, got %v", err)
+	}
+	if rf != nil {
+		t.Error("Expected nil filter on error")
+	}
+}
@@ -1,5 +1,10 @@
 package cuckoofilter
 
+import (
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
 // Options configures a Cuckoo filter
 type Options struct {
 	bucketSize      uint
@@ -9,6 +14,13 @@ type Options struct {
 	preferSIMD      bool
 	preferAVX2      bool
 	batchSize       uint
+	victimCacheSize uint
+	lockStripes     uint
+	counterBits     uint
+	setSemantics    bool
+	seed            uint64
+	sipKey          [16]byte
+	customHash      func([]byte) uint64
 }
 
 // Option is a function that configures Options
@@ -24,6 +36,8 @@ func defaultOptions() Options {
 		preferSIMD:      true,
 		preferAVX2:      true,
 		batchSize:       32,
+		victimCacheSize: filter.DefaultVictimCacheSize,
+		counterBits:     4,
 	}
 }
 
@@ -33,13 +47,42 @@ func (o *Options) Validate() error {
 		o.bucketSize != 16 && o.bucketSize != 32 && o.bucketSize != 64 {
 		return ErrInvalidBucketSize
 	}
-	// Fingerprints are stored as uint16, so 1-16 bits are supported
+	// Fingerprints are bit-packed, so any size from 1 to 16 bits is supported
 	if o.fingerprintBits < 1 || o.fingerprintBits > 16 {
 		return ErrInvalidFingerprintSize
 	}
+	if o.victimCacheSize < 1 || o.victimCacheSize > filter.MaxVictimCacheSize {
+		return ErrInvalidVictimCacheSize
+	}
+	if o.lockStripes > filter.MaxLockStripes {
+		return ErrInvalidLockStripes
+	}
+	if o.counterBits < 2 || o.counterBits > 16 {
+		return ErrInvalidCounterSize
+	}
+	if o.hashStrategy == hashStrategyCustom && o.customHash == nil {
+		return ErrInvalidHashStrategy
+	}
 	return nil
 }
 
+// config converts validated options into the internal filter configuration
+func (o *Options) config() filter.Config {
+	return filter.Config{
+		BucketSize:      o.bucketSize,
+		FingerprintBits: o.fingerprintBits,
+		MaxKicks:        o.maxKicks,
+		HashStrategy:    hash.HashStrategy(o.hashStrategy),
+		BatchSize:       o.batchSize,
+		VictimCacheSize: o.victimCacheSize,
+		LockStripes:     o.lockStripes,
+		SetSemantics:    o.setSemantics,
+		Seed:            o.seed,
+		SipKey:          o.sipKey,
+		CustomHash:      o.customHash,
+	}
+}
+
 // WithBucketSize sets the number of fingerprints per bucket (2, 4, 8, 16, 32, or 64)
 // Larger sizes provide better load factors and benefit more from SIMD optimizations.
 // Recommended: 8 for balanced performance, 32 for maximum load factor, 64 for AVX2 and cache line alignment.
@@ -50,7 +93,8 @@ func WithBucketSize(size uint) Option {
 }
 
 // WithFingerprintSize sets the fingerprint size in bits (1-16)
-// Fingerprints are stored as uint16, so the maximum is 16 bits.
+// Each fingerprint occupies exactly this many bits of memory. 8 and 16 bits
+// use byte-aligned layouts with SIMD comparison; other sizes are bit-packed.
 // Common values: 8 (standard), 12 (low false positive), 16 (very low false positive)
 func WithFingerprintSize(bits uint) Option {
 	return func(o *Options) {
@@ -85,3 +129,49 @@ func WithBatchSize(size uint) Option {
 		o.batchSize = size
 	}
 }
+
+// WithVictimCacheSize sets the number of entries in the victim stash (1-255).
+// When an insert exhausts its relocation attempts, the fingerprint left
+// without a slot is kept in the stash instead of being dropped, so items that
+// were inserted successfully are never lost. Once the stash is full, inserts
+// that would need a relocation fail until a Delete frees space.
+// Default: 1
+func WithVictimCacheSize(size uint) Option {
+	return func(o *Options) {
+		o.victimCacheSize = size
+	}
+}
+
+// WithLockStripes enables concurrent mode with the given number of lock
+// stripes (rounded up to a power of 2, at most 65536). Buckets are divided
+// among the stripes, and Insert, Delete and Lookup lock only the stripes of
+// an item's two candidate buckets instead of the whole filter, so operations
+// on different buckets proceed in parallel. A few stripes per core, such as
+// 4*runtime.GOMAXPROCS(0), is a good starting point.
+// Default: 0 (a single filter-wide lock, fastest without contention)
+func WithLockStripes(stripes uint) Option {
+	return func(o *Options) {
+		o.lockStripes = stripes
+	}
+}
+
+// WithCounterSize sets the counter size in bits (2-16) of a counting filter
+// created with NewCounting. Counts up to 2^bits-2 are exact; a counter that
+// reaches 2^bits-1 saturates and stays there. Other filters ignore it.
+// Default: 4 (exact counts up to 14)
+func WithCounterSize(bits uint) Option {
+	return func(o *Options) {
+		o.counterBits = bits
+	}
+}
+
+// WithSetSemantics makes Insert and InsertBatch behave like InsertUnique:
+// an item that is already present is not stored again, and the insert
+// reports success. Count then counts distinct items, and one Delete removes
+// an item that was inserted several times.
+// Default: disabled (every Insert stores a fingerprint)
+func WithSetSemantics() Option {
+	return func(o *Options) {
+		o.setSemantics = true
+	}
+}
@@ -0,0 +1,288 @@
+package cuckoofilter
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// scalableGrowth is the capacity ratio between consecutive stages
+	scalableGrowth = 2
+
+	// scalableTightening is the false positive ratio between consecutive
+	// stages. Stage i gets fpr·(1-r)·rⁱ, so the stage rates sum to at most fpr.
+	scalableTightening = 0.5
+)
+
+// ScalableFilter is a cuckoo filter that grows as items are added.
+// It chains filters of geometrically growing capacity: when the newest stage
+// is full, a new stage twice its size is added, so Insert never fails. Each
+// stage uses enough fingerprint bits to keep the sum of all stage false
+// positive rates under the configured target, up to the 16-bit maximum.
+//
+// Delete only removes an item whose fingerprint is found in exactly one
+// stage. Stages take fingerprints from different hash bits, so an item
+// matching several stages may match another item's fingerprint in all but
+// one of them, and removing the wrong one would make that item disappear.
+// Such deletes leave the filter unchanged and return false.
+//
+// All methods are safe for concurrent use.
+type ScalableFilter struct {
+	stages   []*Filter
+	options  Options
+	capacity uint    // Capacity of the first stage
+	fpr      float64 // Target false positive rate across all stages
+	mu       sync.RWMutex
+}
+
+// NewScalable creates a filter that starts with room for initialCapacity
+// items and grows on demand while keeping the overall false positive rate
+// below fpr. Options are applied to every stage; the fingerprint size is
+// chosen per stage from fpr, so WithFingerprintSize has no effect.
+// Each stage needs about one more fingerprint bit than the previous one; once
+// stages reach 16 bits, every further stage adds up to 2b/2^16 to the rate
+// (b is the bucket size), which FalsePositiveRate reports.
+//
+// Example:
+//
+//	sf, _ := cuckoofilter.NewScalable(1000, 0.001)
+//	for _, item := range items {
+//	    sf.Insert(item) // never fails
+//	}
+func NewScalable(initialCapacity uint, fpr float64, opts ...Option) (*ScalableFilter, error) {
+	if initialCapacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	if !(fpr > 0 && fpr < 1) {
+		return nil, ErrInvalidFalsePositiveRate
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &ScalableFilter{
+		options:  options,
+		capacity: initialCapacity,
+		fpr:      fpr,
+	}
+	s.grow()
+	return s, nil
+}
+
+// grow appends a new stage. Callers must hold s.mu for writing.
+func (s *ScalableFilter) grow() {
+	i := len(s.stages)
+	stageFPR := s.fpr * (1 - scalableTightening) * math.Pow(scalableTightening, float64(i))
+	bits := min(fingerprintBitsFor(s.options.bucketSize, stageFPR), 16)
+
+	capacity := s.capacity
+	for range i {
+		capacity *= scalableGrowth
+	}
+
+	options := s.options
+	options.fingerprintBits = bits
+	s.stages = append(s.stages, newFilter(capacity, &options))
+}
+
+// Insert adds an item to the newest stage, adding a stage when it is full.
+// With WithSetSemantics, an item already found in any stage is not added
+// again, so it stays in one stage and can still be deleted.
+// Always returns true.
+func (s *ScalableFilter) Insert(item []byte) bool {
+	s.mu.RLock()
+	if s.options.setSemantics && s.contains(item) {
+		s.mu.RUnlock()
+		return true
+	}
+	ok := s.stages[len(s.stages)-1].Insert(item)
+	s.mu.RUnlock()
+	if ok {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another goroutine may have added a stage, or the item, in the meantime
+	if s.options.setSemantics && s.contains(item) {
+		return true
+	}
+	for !s.stages[len(s.stages)-1].Insert(item) {
+		s.grow()
+	}
+	return true
+}
+
+// Lookup checks if an item might be in any stage, newest first
+func (s *ScalableFilter) Lookup(item []byte) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.contains(item)
+}
+
+// contains reports whether any stage matches item, newest first.
+// Callers must hold s.mu.
+func (s *ScalableFilter) contains(item []byte) bool {
+	for i := len(s.stages) - 1; i >= 0; i-- {
+		if s.stages[i].Lookup(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes an item from the stage that holds its fingerprint.
+// If the item matches more than one stage, it can't tell which holds the
+// item and which a false positive belonging to another item, so it removes
+// nothing and returns false; the item stays in the filter.
+func (s *ScalableFilter) Delete(item []byte) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match := -1
+	for i, stage := range s.stages {
+		if stage.Lookup(item) {
+			if match >= 0 {
+				return false
+			}
+			match = i
+		}
+	}
+	return match >= 0 && s.stages[match].Delete(item)
+}
+
+// InsertBatch inserts multiple items. Every result is true.
+func (s *ScalableFilter) InsertBatch(items [][]byte) []bool {
+	results := make([]bool, len(items))
+	for i, item := range items {
+		results[i] = s.Insert(item)
+	}
+	return results
+}
+
+// LookupBatch checks multiple items. Each stage is probed with a single
+// batch holding the items not yet found in a newer stage.
+func (s *ScalableFilter) LookupBatch(items [][]byte) []bool {
+	results := make([]bool, len(items))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make([]int, len(items))
+	for i := range pending {
+		pending[i] = i
+	}
+	batch := make([][]byte, 0, len(items))
+
+	for i := len(s.stages) - 1; i >= 0 && len(pending) > 0; i-- {
+		batch = batch[:0]
+		for _, j := range pending {
+			batch = append(batch, items[j])
+		}
+
+		remaining := pending[:0]
+		for k, found := range s.stages[i].LookupBatch(batch) {
+			if found {
+				results[pending[k]] = true
+			} else {
+				remaining = append(remaining, pending[k])
+			}
+		}
+		pending = remaining
+	}
+	return results
+}
+
+// DeleteBatch deletes multiple items. As with Delete, items matching more
+// than one stage are left in place and reported as false.
+func (s *ScalableFilter) DeleteBatch(items [][]byte) []bool {
+	results := make([]bool, len(items))
+	for i, item := range items {
+		results[i] = s.Delete(item)
+	}
+	return results
+}
+
+// OptimalBatchSize returns the recommended batch size
+func (s *ScalableFilter) OptimalBatchSize() int {
+	return int(s.options.batchSize)
+}
+
+// Count returns the number of items across all stages
+func (s *ScalableFilter) Count() uint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := uint(0)
+	for _, stage := range s.stages {
+		total += stage.Count()
+	}
+	return total
+}
+
+// Capacity returns the combined capacity of all current stages
+func (s *ScalableFilter) Capacity() uint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := uint(0)
+	for _, stage := range s.stages {
+		total += stage.Capacity()
+	}
+	return total
+}
+
+// LoadFactor returns the fill ratio across all stages (0.0 to 1.0)
+func (s *ScalableFilter) LoadFactor() float64 {
+	return float64(s.Count()) / float64(s.Capacity())
+}
+
+// MemoryUsage returns the combined size of all stage tables in bytes
+func (s *ScalableFilter) MemoryUsage() uint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := uint(0)
+	for _, stage := range s.stages {
+		total += stage.MemoryUsage()
+	}
+	return total
+}
+
+// Stages returns the number of stages the filter has grown to
+func (s *ScalableFilter) Stages() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.stages)
+}
+
+// FalsePositiveRate returns the worst-case false positive rate across all
+// current stages. It stays below the configured target until stages need
+// more than 16 fingerprint bits.
+func (s *ScalableFilter) FalsePositiveRate() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := 0.0
+	for _, stage := range s.stages {
+		total += stage.FalsePositiveRate()
+	}
+	return min(total, 1)
+}
+
+// Reset removes all items and shrinks the filter back to its first stage
+func (s *ScalableFilter) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stages[0].Reset()
+	clear(s.stages[1:])
+	s.stages = s.stages[:1]
+}
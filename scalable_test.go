@@ -0,0 +1,284 @@
+package cuckoofilter
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+var _ BatchFilter = (*ScalableFilter)(nil)
+
+// TestScalableInsertNeverFails validates growth far beyond the initial capacity
+func TestScalableInsertNeverFails(t *testing.T) {
+	sf, err := NewScalable(1000, 0.01)
+	if err != nil {
+		t.Fatalf("NewScalable failed: %v", err)
+	}
+
+	const n = 100000
+	for i := 0; i < n; i++ {
+		if !sf.Insert([]byte(fmt.Sprintf("scalable-%d", i))) {
+			t.Fatalf("Insert %d failed", i)
+		}
+	}
+
+	if sf.Count() != n {
+		t.Errorf("Expected count %d, got %d", n, sf.Count())
+	}
+	if sf.Stages() < 2 {
+		t.Errorf("Filter should have grown, has %d stages", sf.Stages())
+	}
+	if sf.Capacity() < n {
+		t.Errorf("Capacity %d is below item count %d", sf.Capacity(), n)
+	}
+	t.Logf("%d items in %d stages, capacity %d, %d bytes, FPR bound %.5f",
+		n, sf.Stages(), sf.Capacity(), sf.MemoryUsage(), sf.FalsePositiveRate())
+
+	for i := 0; i < n; i++ {
+		if !sf.Lookup([]byte(fmt.Sprintf("scalable-%d", i))) {
+			t.Fatalf("False negative for item %d", i)
+		}
+	}
+}
+
+// TestScalableFalsePositiveBound validates that stage fingerprint sizes keep
+// the combined worst-case rate under target as the filter grows
+func TestScalableFalsePositiveBound(t *testing.T) {
+	// Tighter targets reach the 16-bit fingerprint limit within a few stages,
+	// after which each new stage adds 2b/2^16 to the bound
+	for _, target := range []float64{0.05, 0.01} {
+		for _, bucketSize := range []uint{4, 8, 16} {
+			t.Run(fmt.Sprintf("%v/bucket-%d", target, bucketSize), func(t *testing.T) {
+				sf, _ := NewScalable(500, target, WithBucketSize(bucketSize))
+				for i := 0; i < 50000; i++ {
+					sf.Insert([]byte(fmt.Sprintf("member-%d", i)))
+				}
+
+				if bound := sf.FalsePositiveRate(); bound > target {
+					t.Errorf("FPR bound %.5f exceeds target %.5f after %d stages", bound, target, sf.Stages())
+				}
+			})
+		}
+	}
+}
+
+// TestScalableDelete validates deleting items from every stage without
+// losing the others
+func TestScalableDelete(t *testing.T) {
+	sf, _ := NewScalable(100, 0.001)
+	for i := 0; i < 2000; i++ {
+		sf.Insert([]byte(fmt.Sprintf("delete-%d", i)))
+	}
+
+	// A delete fails only when the item matches several stages, and then
+	// leaves it in place
+	removed := uint(0)
+	for i := 0; i < 2000; i += 2 {
+		item := []byte(fmt.Sprintf("delete-%d", i))
+		if sf.Delete(item) {
+			removed++
+		} else if !sf.Lookup(item) {
+			t.Errorf("Delete failed for item %d but removed it", i)
+		}
+	}
+
+	if want := 2000 - removed; sf.Count() != want {
+		t.Errorf("Expected count %d after %d deletes, got %d", want, removed, sf.Count())
+	}
+	for i := 1; i < 2000; i += 2 {
+		if !sf.Lookup([]byte(fmt.Sprintf("delete-%d", i))) {
+			t.Errorf("Remaining item %d not found", i)
+		}
+	}
+}
+
+// TestScalableBatchOperations validates the batch API across stages
+func TestScalableBatchOperations(t *testing.T) {
+	sf, _ := NewScalable(64, 0.01, WithXXHash(), WithBucketSize(8))
+
+	items := make([][]byte, 1000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("batch-%d", i))
+	}
+
+	for i, ok := range sf.InsertBatch(items) {
+		if !ok {
+			t.Errorf("InsertBatch failed for item %d", i)
+		}
+	}
+
+	probe := append(append([][]byte{}, items...), []byte("missing-a"), []byte("missing-b"))
+	results := sf.LookupBatch(probe)
+	for i := range items {
+		if !results[i] {
+			t.Errorf("LookupBatch missed item %d", i)
+		}
+		if results[i] != sf.Lookup(probe[i]) {
+			t.Errorf("LookupBatch and Lookup disagree for item %d", i)
+		}
+	}
+
+	// Delete the first half. A delete fails only when the item matches
+	// several stages, and then leaves it in place.
+	half := items[:len(items)/2]
+	removed := uint(0)
+	for i, ok := range sf.DeleteBatch(half) {
+		if ok {
+			removed++
+		} else if !sf.Lookup(half[i]) {
+			t.Errorf("DeleteBatch failed for item %d but removed it", i)
+		}
+	}
+	for i, found := range sf.LookupBatch(items[len(half):]) {
+		if !found {
+			t.Errorf("LookupBatch missed item %d after deleting others", len(half)+i)
+		}
+	}
+	if want := uint(len(items)) - removed; sf.Count() != want {
+		t.Errorf("Count() = %d after %d deletes, want %d", sf.Count(), removed, want)
+	}
+}
+
+// TestScalableDeleteNoFalseNegatives validates that deleting items never
+// removes another item's fingerprint: with a high false positive rate, many
+// deleted items match false positives in other stages
+func TestScalableDeleteNoFalseNegatives(t *testing.T) {
+	sf, _ := NewScalable(64, 0.25, WithBucketSize(4))
+
+	items := make([][]byte, 20000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("no-fn-%d", i))
+	}
+	sf.InsertBatch(items)
+
+	var deleted [][]byte
+	for i := 0; i < len(items); i += 2 {
+		deleted = append(deleted, items[i])
+	}
+	removed := uint(0)
+	for i, ok := range sf.DeleteBatch(deleted) {
+		if ok {
+			removed++
+		} else if !sf.Lookup(deleted[i]) {
+			t.Errorf("Delete of %q failed but removed it", deleted[i])
+		}
+	}
+	t.Logf("%d stages, %d of %d deletes ambiguous", sf.Stages(), uint(len(deleted))-removed, len(deleted))
+
+	for i := 1; i < len(items); i += 2 {
+		if !sf.Lookup(items[i]) {
+			t.Errorf("False negative for item %d, which was never deleted", i)
+		}
+	}
+	if want := uint(len(items)) - removed; sf.Count() != want {
+		t.Errorf("Count() = %d, want %d", sf.Count(), want)
+	}
+}
+
+// TestScalableSetSemantics validates that with set semantics, items held by
+// an older stage are not stored again in a newer one, so they can be deleted
+func TestScalableSetSemantics(t *testing.T) {
+	sf, _ := NewScalable(64, 0.01, WithSetSemantics())
+
+	items := make([][]byte, 64)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("set-%d", i))
+	}
+	sf.InsertBatch(items)
+
+	// Grow past the first stage, then insert the first items again
+	for i := 0; sf.Stages() < 3; i++ {
+		sf.Insert([]byte(fmt.Sprintf("filler-%d", i)))
+	}
+	count := sf.Count()
+	for i, ok := range sf.InsertBatch(items) {
+		if !ok {
+			t.Errorf("Re-insert of item %d failed", i)
+		}
+	}
+	if sf.Count() != count {
+		t.Errorf("Count() = %d after re-inserting, want %d", sf.Count(), count)
+	}
+
+	removed := uint(0)
+	for i, ok := range sf.DeleteBatch(items) {
+		if ok {
+			removed++
+		} else {
+			// Only a false positive in another stage may block the delete
+			matches := 0
+			for _, stage := range sf.stages {
+				if stage.Lookup(items[i]) {
+					matches++
+				}
+			}
+			if matches < 2 {
+				t.Errorf("Delete of re-inserted item %d failed", i)
+			}
+		}
+	}
+	if want := count - removed; sf.Count() != want {
+		t.Errorf("Count() = %d after %d deletes, want %d", sf.Count(), removed, want)
+	}
+}
+
+// TestScalableReset validates that Reset shrinks back to one stage
+func TestScalableReset(t *testing.T) {
+	sf, _ := NewScalable(100, 0.01)
+	for i := 0; i < 5000; i++ {
+		sf.Insert([]byte(fmt.Sprintf("reset-%d", i)))
+	}
+
+	sf.Reset()
+	if sf.Stages() != 1 || sf.Count() != 0 {
+		t.Errorf("After Reset: %d stages, count %d", sf.Stages(), sf.Count())
+	}
+	if sf.Lookup([]byte("reset-1")) {
+		t.Error("Item found after Reset")
+	}
+}
+
+// TestScalableInvalidParameters validates constructor validation
+func TestScalableInvalidParameters(t *testing.T) {
+	if _, err := NewScalable(0, 0.01); err != ErrInvalidCapacity {
+		t.Errorf("Expected ErrInvalidCapacity, got %v", err)
+	}
+	for _, fpr := range []float64{0, -0.1, 1, 2} {
+		if _, err := NewScalable(100, fpr); err != ErrInvalidFalsePositiveRate {
+			t.Errorf("Expected ErrInvalidFalsePositiveRate for %v, got %v", fpr, err)
+		}
+	}
+	if _, err := NewScalable(100, 0.01, WithBucketSize(3)); err != ErrInvalidBucketSize {
+		t.Errorf("Expected ErrInvalidBucketSize, got %v", err)
+	}
+}
+
+// TestScalableConcurrentInsert validates growth under concurrent inserts
+func TestScalableConcurrentInsert(t *testing.T) {
+	sf, _ := NewScalable(100, 0.01)
+
+	const workers, perWorker = 8, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				sf.Insert([]byte(fmt.Sprintf("concurrent-%d-%d", w, i)))
+				sf.Lookup([]byte(fmt.Sprintf("concurrent-%d-%d", w, i/2)))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if sf.Count() != workers*perWorker {
+		t.Errorf("Expected count %d, got %d", workers*perWorker, sf.Count())
+	}
+	for w := 0; w < workers; w++ {
+		for i := 0; i < perWorker; i++ {
+			if !sf.Lookup([]byte(fmt.Sprintf("concurrent-%d-%d", w, i))) {
+				t.Fatalf("False negative for worker %d item %d", w, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package cuckoofilter
+
+import (
+	"io"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// Load reads a filter previously saved with MarshalBinary or WriteTo from r.
+// The returned filter uses the same hash strategy, fingerprint size and bucket
+// layout as the filter that was saved, and contains all of its items.
+// The table is streamed, so r can be a file, a decompressor or a network
+// connection; exactly one filter is consumed from it.
+//
+// The configuration is read from r, so options are only needed for filters
+// using SipHash or a custom hash, whose key or function isn't stored: pass
+// WithSipHash or WithCustomHash as the filter was built with. Other options
+// are ignored.
+//
+// Example:
+//
+//	data, _ := cf.(cuckoofilter.SerializableFilter).MarshalBinary()
+//	restored, err := cuckoofilter.Load(bytes.NewReader(data))
+func Load(r io.Reader, opts ...Option) (CuckooFilter, error) {
+	var f *filter.Filter
+	var err error
+	if params := unstoredParams(opts); params != nil {
+		f, err = filter.ReadWith(r, *params)
+	} else {
+		f, err = filter.Read(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{f: f}, nil
+}
+
+// unstoredParams returns the SipHash key or custom hash function set by a
+// WithSipHash or WithCustomHash option in opts, or nil if there is neither
+func unstoredParams(opts []Option) *hash.Params {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.hashStrategy != hashStrategySipHash && options.hashStrategy != hashStrategyCustom {
+		return nil
+	}
+	return &hash.Params{Key: options.sipKey, Func: options.customHash}
+}
@@ -0,0 +1,155 @@
+package cuckoofilter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestLoad validates that a saved filter can be restored with Load
+func TestLoad(t *testing.T) {
+	cf, _ := New(10000, WithXXHash(), WithFingerprintSize(12), WithBucketSize(8))
+
+	for i := 0; i < 1000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("load-%d", i)))
+	}
+
+	sf, ok := cf.(SerializableFilter)
+	if !ok {
+		t.Fatal("Filter does not implement SerializableFilter")
+	}
+
+	data, err := sf.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+
+	restored, err := Load(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if restored.Count() != cf.Count() {
+		t.Errorf("Expected count %d, got %d", cf.Count(), restored.Count())
+	}
+	if restored.Capacity() != cf.Capacity() {
+		t.Errorf("Expected capacity %d, got %d", cf.Capacity(), restored.Capacity())
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !restored.Lookup([]byte(fmt.Sprintf("load-%d", i))) {
+			t.Errorf("Item %d not found after Load", i)
+		}
+	}
+}
+
+// TestLoadInvalidData validates that Load rejects malformed input
+func TestLoadInvalidData(t *testing.T) {
+	if _, err := Load(bytes.NewReader([]byte("not a filter"))); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat, got %v", err)
+	}
+}
+
+// TestLoadSipHash validates that SipHash filters are loaded with their key
+func TestLoadSipHash(t *testing.T) {
+	key := [16]byte{0x5e, 0xc2, 0xe7}
+	cf, _ := New(10000, WithSipHash(key))
+	for i := 0; i < 1000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("keyed-%d", i)))
+	}
+	data, _ := cf.(SerializableFilter).MarshalBinary()
+
+	if _, err := Load(bytes.NewReader(data)); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("Expected ErrKeyRequired, got %v", err)
+	}
+	if _, err := Load(bytes.NewReader(data), WithSipHash([16]byte{1})); !errors.Is(err, ErrIncompatibleFilter) {
+		t.Errorf("Expected ErrIncompatibleFilter for the wrong key, got %v", err)
+	}
+
+	restored, err := Load(bytes.NewReader(data), WithSipHash(key))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !restored.Lookup([]byte(fmt.Sprintf("keyed-%d", i))) {
+			t.Errorf("Item %d not found after Load", i)
+		}
+	}
+}
+
+// TestLoadCustomHash validates that filters using a custom hash are loaded with it
+func TestLoadCustomHash(t *testing.T) {
+	cf, _ := New(10000, WithCustomHash(customHash))
+	for i := 0; i < 1000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("custom-%d", i)))
+	}
+	data, _ := cf.(SerializableFilter).MarshalBinary()
+
+	if _, err := Load(bytes.NewReader(data)); !errors.Is(err, ErrHashRequired) {
+		t.Errorf("Expected ErrHashRequired, got %v", err)
+	}
+	other := func(item []byte) uint64 { return customHash(item) + 1 }
+	if _, err := Load(bytes.NewReader(data), WithCustomHash(other)); !errors.Is(err, ErrIncompatibleFilter) {
+		t.Errorf("Expected ErrIncompatibleFilter for another function, got %v", err)
+	}
+
+	restored, err := Load(bytes.NewReader(data), WithCustomHash(customHash))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if !restored.Lookup([]byte(fmt.Sprintf("custom-%d", i))) {
+			t.Errorf("Item %d not found after Load", i)
+		}
+	}
+}
+
+// TestUnmarshalIncompatibleFilter validates that hash configuration mismatches are reported
+func TestUnmarshalIncompatibleFilter(t *testing.T) {
+	src, _ := New(1000, WithCRC32Hash())
+	data, _ := src.(SerializableFilter).MarshalBinary()
+
+	dst, _ := New(1000, WithFNVHash())
+	err := dst.(SerializableFilter).UnmarshalBinary(data)
+
+	if !errors.Is(err, ErrIncompatibleFilter) {
+		t.Fatalf("Expected ErrIncompatibleFilter, got %v", err)
+	}
+
+	var incompatible *IncompatibleFilterError
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("Expected *IncompatibleFilterError, got %T", err)
+	}
+	t.Logf("Rejected: %v", err)
+}
+
+// TestStreamToWriter validates WriteTo/Load through a compressing writer
+func TestStreamToWriter(t *testing.T) {
+	cf, _ := New(50000, WithBucketSize(16))
+	for i := 0; i < 20000; i++ {
+		cf.Insert([]byte(fmt.Sprintf("stream-%d", i)))
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := cf.(SerializableFilter).WriteTo(zw); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip Close failed: %v", err)
+	}
+
+	zr, _ := gzip.NewReader(&buf)
+	restored, err := Load(zr)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	for i := 0; i < 20000; i++ {
+		if !restored.Lookup([]byte(fmt.Sprintf("stream-%d", i))) {
+			t.Errorf("Item %d not found after streaming Load", i)
+		}
+	}
+}
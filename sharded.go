@@ -0,0 +1,207 @@
+package cuckoofilter
+
+import (
+	"cmp"
+	"math/bits"
+	"slices"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// maxShards is the largest supported number of shards
+const maxShards = 1 << 16
+
+// ShardedFilter splits a filter into independent shards, each a *Filter with
+// its own lock and table. Items are hashed once and routed to a shard by the
+// high bits of the hash; the shard derives its buckets and fingerprint from
+// the low bits of the same hash, so items are not hashed again.
+//
+// Shards can be used on their own through Shard: locking, serialization and
+// Reset of one shard don't affect the others, and loading a shard with
+// ReadFrom may change its size. Shard tables should stay below 2^31/Shards()
+// buckets, beyond which the bits choosing a bucket overlap the routing bits
+// and part of each shard stays unused.
+//
+// All methods are safe for concurrent use.
+type ShardedFilter struct {
+	shards    []*Filter
+	router    hash.HashInterface // Hashes items with filter.RouteBuckets buckets
+	shift     uint               // Bits of a routed index below the shard number
+	batchSize uint
+}
+
+// NewSharded creates a filter with room for capacity items split evenly over
+// the given number of shards, rounded up to a power of 2. Options apply to
+// every shard.
+//
+// Example:
+//
+//	sf, _ := cuckoofilter.NewSharded(100_000_000, 64, cuckoofilter.WithXXHash())
+//	results := sf.InsertBatch(items) // one lock acquisition per shard touched
+func NewSharded(capacity, shards uint, opts ...Option) (*ShardedFilter, error) {
+	if capacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	if shards == 0 || shards > maxShards {
+		return nil, ErrInvalidShardCount
+	}
+
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	n := uint(1) << bits.Len(shards-1)
+	shardCapacity := (capacity + n - 1) / n
+
+	s := &ShardedFilter{
+		shards:    make([]*Filter, n),
+		router:    hash.NewHashFunctionWithParams(hash.HashStrategy(options.hashStrategy), options.fingerprintBits, hash.Params{Seed: options.seed, Key: options.sipKey, Func: options.customHash}),
+		shift:     uint(bits.Len(filter.RouteBuckets-1)) - uint(bits.Len(n-1)),
+		batchSize: options.batchSize,
+	}
+	for i := range s.shards {
+		s.shards[i] = newFilter(shardCapacity, &options)
+	}
+	return s, nil
+}
+
+// route hashes item and returns its shard
+func (s *ShardedFilter) route(item []byte) (*filter.Filter, hash.HashResult) {
+	i1, i2, fp := s.router.GetIndices(item, filter.RouteBuckets)
+	hr := hash.HashResult{I1: i1, I2: i2, Fp: fp}
+	return s.shards[s.shardOf(hr)].f, hr
+}
+
+// shardOf returns the index of the shard a routed hash belongs to
+func (s *ShardedFilter) shardOf(hr hash.HashResult) uint {
+	return hr.I1 >> s.shift
+}
+
+// Insert adds an item to its shard.
+// Returns true if successful, false if the shard is full.
+func (s *ShardedFilter) Insert(item []byte) bool {
+	f, hr := s.route(item)
+	return f.InsertHashed(hr)
+}
+
+// Lookup checks if an item might be in its shard
+func (s *ShardedFilter) Lookup(item []byte) bool {
+	f, hr := s.route(item)
+	return f.LookupHashed(hr)
+}
+
+// Delete removes an item from its shard
+func (s *ShardedFilter) Delete(item []byte) bool {
+	f, hr := s.route(item)
+	return f.DeleteHashed(hr)
+}
+
+// InsertBatch inserts multiple items. The batch is hashed once and each
+// shard inserts its items under a single lock acquisition.
+func (s *ShardedFilter) InsertBatch(items [][]byte) []bool {
+	return s.byShard(items, (*filter.Filter).InsertBatchHashed)
+}
+
+// LookupBatch checks multiple items, one lock acquisition per shard
+func (s *ShardedFilter) LookupBatch(items [][]byte) []bool {
+	return s.byShard(items, (*filter.Filter).LookupBatchHashed)
+}
+
+// DeleteBatch deletes multiple items, one lock acquisition per shard
+func (s *ShardedFilter) DeleteBatch(items [][]byte) []bool {
+	return s.byShard(items, (*filter.Filter).DeleteBatchHashed)
+}
+
+// byShard hashes items in one batch, groups the results by shard and calls
+// op once for every shard with items, scattering its results back
+func (s *ShardedFilter) byShard(items [][]byte, op func(*filter.Filter, []hash.HashResult) []bool) []bool {
+	results := make([]bool, len(items))
+	hrs := s.router.GetIndicesBatch(items, filter.RouteBuckets)
+
+	order := make([]int, len(hrs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(s.shardOf(hrs[a]), s.shardOf(hrs[b]))
+	})
+
+	group := make([]hash.HashResult, 0, len(hrs))
+	for start := 0; start < len(order); {
+		shard := s.shardOf(hrs[order[start]])
+		end := start
+		group = group[:0]
+		for end < len(order) && s.shardOf(hrs[order[end]]) == shard {
+			group = append(group, hrs[order[end]])
+			end++
+		}
+
+		for k, ok := range op(s.shards[shard].f, group) {
+			results[order[start+k]] = ok
+		}
+		start = end
+	}
+	return results
+}
+
+// OptimalBatchSize returns the recommended batch size
+func (s *ShardedFilter) OptimalBatchSize() int {
+	return int(s.batchSize)
+}
+
+// Shards returns the number of shards
+func (s *ShardedFilter) Shards() int {
+	return len(s.shards)
+}
+
+// Shard returns shard i, for operations on one shard such as WriteTo,
+// ReadFrom or Reset. Data loaded into a shard must come from a filter with
+// the same hash strategy and fingerprint size.
+func (s *ShardedFilter) Shard(i int) *Filter {
+	return s.shards[i]
+}
+
+// Count returns the number of items across all shards
+func (s *ShardedFilter) Count() uint {
+	total := uint(0)
+	for _, shard := range s.shards {
+		total += shard.Count()
+	}
+	return total
+}
+
+// Capacity returns the combined capacity of all shards
+func (s *ShardedFilter) Capacity() uint {
+	total := uint(0)
+	for _, shard := range s.shards {
+		total += shard.Capacity()
+	}
+	return total
+}
+
+// LoadFactor returns the fill ratio across all shards (0.0 to 1.0)
+func (s *ShardedFilter) LoadFactor() float64 {
+	return float64(s.Count()) / float64(s.Capacity())
+}
+
+// MemoryUsage returns the combined size of all shard tables in bytes
+func (s *ShardedFilter) MemoryUsage() uint {
+	total := uint(0)
+	for _, shard := range s.shards {
+		total += shard.MemoryUsage()
+	}
+	return total
+}
+
+// Reset removes all items from every shard. Shards are cleared one at a
+// time, so concurrent operations may see some shards already empty.
+func (s *ShardedFilter) Reset() {
+	for _, shard := range s.shards {
+		shard.Reset()
+	}
+}
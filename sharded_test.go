@@ -0,0 +1,237 @@
+package cuckoofilter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+var _ BatchFilter = (*ShardedFilter)(nil)
+
+func shardedItems(prefix string, n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprintf("%s-%d", prefix, i))
+	}
+	return items
+}
+
+// TestShardedOperations validates single and batch operations across shards
+// for every hash strategy
+func TestShardedOperations(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opt  Option
+	}{{"FNV", WithFNVHash()}, {"CRC32", WithCRC32Hash()}, {"XXHash", WithXXHash()}, {"SipHash", WithSipHash([16]byte{9})}, {"Custom", WithCustomHash(customHash)}} {
+		t.Run(tt.name, func(t *testing.T) {
+			sf, err := NewSharded(40000, 8, tt.opt, WithFingerprintSize(16))
+			if err != nil {
+				t.Fatalf("NewSharded failed: %v", err)
+			}
+
+			items := shardedItems("sharded", 10000)
+			for i, ok := range sf.InsertBatch(items[:5000]) {
+				if !ok {
+					t.Fatalf("InsertBatch failed for item %d", i)
+				}
+			}
+			for i, item := range items[5000:] {
+				if !sf.Insert(item) {
+					t.Fatalf("Insert failed for item %d", 5000+i)
+				}
+			}
+			if sf.Count() != 10000 {
+				t.Errorf("Count() = %d, want 10000", sf.Count())
+			}
+
+			// Every shard gets its share of the items
+			for i := range sf.Shards() {
+				if n := sf.Shard(i).Count(); n < 10000/8/2 || n > 10000/8*2 {
+					t.Errorf("Shard %d holds %d items, expected about %d", i, n, 10000/8)
+				}
+			}
+
+			for i, found := range sf.LookupBatch(items) {
+				if !found || !sf.Lookup(items[i]) {
+					t.Fatalf("False negative for item %d", i)
+				}
+			}
+
+			for i, ok := range sf.DeleteBatch(items[:5000]) {
+				if !ok {
+					t.Fatalf("DeleteBatch failed for item %d", i)
+				}
+			}
+			for i, item := range items[5000:] {
+				if !sf.Delete(item) {
+					t.Fatalf("Delete failed for item %d", 5000+i)
+				}
+			}
+			if sf.Count() != 0 || sf.LoadFactor() != 0 {
+				t.Errorf("Expected empty filter, count %d", sf.Count())
+			}
+		})
+	}
+}
+
+// TestShardedAggregates validates that Capacity, MemoryUsage and Reset cover all shards
+func TestShardedAggregates(t *testing.T) {
+	sf, err := NewSharded(10000, 5, WithBucketSize(8))
+	if err != nil {
+		t.Fatalf("NewSharded failed: %v", err)
+	}
+	if sf.Shards() != 8 {
+		t.Errorf("Expected shard count rounded up to 8, got %d", sf.Shards())
+	}
+
+	capacity, memory := uint(0), uint(0)
+	for i := range sf.Shards() {
+		capacity += sf.Shard(i).Capacity()
+		memory += sf.Shard(i).MemoryUsage()
+		if sf.Shard(i).BucketSize() != 8 {
+			t.Errorf("Shard %d ignores options", i)
+		}
+	}
+	if sf.Capacity() != capacity || sf.Capacity() < 10000 || sf.MemoryUsage() != memory {
+		t.Errorf("Capacity %d, MemoryUsage %d; shards sum to %d, %d", sf.Capacity(), sf.MemoryUsage(), capacity, memory)
+	}
+
+	sf.InsertBatch(shardedItems("aggregate", 2000))
+	if want := float64(sf.Count()) / float64(capacity); sf.LoadFactor() != want {
+		t.Errorf("LoadFactor() = %f, want %f", sf.LoadFactor(), want)
+	}
+
+	sf.Reset()
+	if sf.Count() != 0 {
+		t.Errorf("Count() = %d after Reset", sf.Count())
+	}
+}
+
+// TestShardedIndependentShards validates serializing, resetting and resizing
+// one shard without touching the others
+func TestShardedIndependentShards(t *testing.T) {
+	sf, err := NewSharded(8000, 4, WithFingerprintSize(16), WithXXHash())
+	if err != nil {
+		t.Fatalf("NewSharded failed: %v", err)
+	}
+	items := shardedItems("independent", 2000)
+	sf.InsertBatch(items)
+
+	var buf bytes.Buffer
+	if _, err := sf.Shard(1).WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	inShard1 := sf.Shard(1).Count()
+
+	sf.Shard(1).Reset()
+	if sf.Count() != 2000-inShard1 {
+		t.Errorf("Resetting shard 1 left %d items, want %d", sf.Count(), 2000-inShard1)
+	}
+	if _, err := sf.Shard(1).ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	for i, found := range sf.LookupBatch(items) {
+		if !found {
+			t.Fatalf("Item %d lost after restoring shard 1", i)
+		}
+	}
+
+	// Resize shard 2 by loading a larger filter built from its items
+	larger, _ := NewBatch(4*sf.Shard(2).Capacity(), WithFingerprintSize(16), WithXXHash())
+	var routed [][]byte
+	for _, item := range items {
+		if f, _ := sf.route(item); f == sf.Shard(2).f {
+			routed = append(routed, item)
+			larger.Insert(item)
+		}
+	}
+	data, _ := larger.MarshalBinary()
+	if err := sf.Shard(2).UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if sf.Shard(2).Capacity() != larger.Capacity() {
+		t.Errorf("Shard 2 capacity %d, want %d", sf.Shard(2).Capacity(), larger.Capacity())
+	}
+	for _, item := range routed {
+		if !sf.Lookup(item) || !sf.Delete(item) {
+			t.Fatalf("Resized shard lost %q", item)
+		}
+	}
+
+	// Shards reject data hashed differently
+	other, _ := NewBatch(1000, WithFingerprintSize(16), WithCRC32Hash())
+	data, _ = other.MarshalBinary()
+	if err := sf.Shard(0).UnmarshalBinary(data); !errors.Is(err, ErrIncompatibleFilter) {
+		t.Errorf("Expected ErrIncompatibleFilter, got %v", err)
+	}
+}
+
+// TestShardedConcurrent validates parallel batches and per-shard operations.
+// Run with -race to check locking.
+func TestShardedConcurrent(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithLockStripes(8)}} {
+		sf, err := NewSharded(64000, 16, opts...)
+		if err != nil {
+			t.Fatalf("NewSharded failed: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for w := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				items := shardedItems(fmt.Sprintf("worker-%d", w), 2000)
+				for i, ok := range sf.InsertBatch(items) {
+					if ok && !sf.Lookup(items[i]) {
+						t.Errorf("False negative for %q", items[i])
+						return
+					}
+				}
+				sf.DeleteBatch(items[:1000])
+				sf.Count()
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buf bytes.Buffer
+			sf.Shard(0).WriteTo(&buf)
+			sf.Capacity()
+		}()
+		wg.Wait()
+	}
+}
+
+// TestNewShardedInvalid validates parameter checks
+func TestNewShardedInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		capacity, shards uint
+		opts             []Option
+		want             error
+	}{
+		{"ZeroCapacity", 0, 4, nil, ErrInvalidCapacity},
+		{"ZeroShards", 1000, 0, nil, ErrInvalidShardCount},
+		{"TooManyShards", 1000, maxShards + 1, nil, ErrInvalidShardCount},
+		{"InvalidOption", 1000, 4, []Option{WithBucketSize(3)}, ErrInvalidBucketSize},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSharded(tt.capacity, tt.shards, tt.opts...); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	sf, err := NewSharded(10, maxShards)
+	if err != nil {
+		t.Fatalf("NewSharded with %d shards failed: %v", maxShards, err)
+	}
+	if sf.shift != 31-16 || sf.shardOf(hash.HashResult{I1: filter.RouteBuckets - 1}) != maxShards-1 {
+		t.Errorf("Unexpected routing shift %d", sf.shift)
+	}
+}
@@ -0,0 +1,163 @@
+package cuckoofilter
+
+import (
+	"encoding/binary"
+	"unsafe"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// KeyEncoder converts keys of type K into the bytes a filter hashes.
+// Encode may return memory owned by the key, such as the bytes of a string;
+// the filter only reads the slice during the call and never modifies it.
+type KeyEncoder[K any] interface {
+	Encode(key K) []byte
+}
+
+// Integer is the set of integer types with a built-in encoder
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntegerEncoder encodes integers as the 8 little-endian bytes of their
+// value converted to uint64, so int32(-1) and int64(-1) are the same key.
+// TypedFilter hashes integer keys directly, without encoding them.
+type IntegerEncoder[K Integer] struct{}
+
+// Encode returns the 8-byte little-endian encoding of key
+func (IntegerEncoder[K]) Encode(key K) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(key))
+}
+
+// StringEncoder encodes strings as their bytes without copying them
+type StringEncoder[K ~string] struct{}
+
+// Encode returns the bytes of key. They must not be modified.
+func (StringEncoder[K]) Encode(key K) []byte {
+	return unsafe.Slice(unsafe.StringData(string(key)), len(key))
+}
+
+// Bytes16Encoder encodes 16-byte arrays such as UUIDs as their bytes.
+// TypedFilter hashes them directly, without encoding them.
+type Bytes16Encoder[K ~[16]byte] struct{}
+
+// Encode returns a copy of the bytes of key
+func (Bytes16Encoder[K]) Encode(key K) []byte {
+	return append([]byte(nil), key[:]...)
+}
+
+// fixedKey is implemented by built-in encoders whose keys are hashed with
+// a hash.FixedHasher instead of being encoded
+type fixedKey[K any] interface {
+	hashKey(h hash.FixedHasher, key K) hash.HashResult
+}
+
+func (IntegerEncoder[K]) hashKey(h hash.FixedHasher, key K) hash.HashResult {
+	i1, i2, fp := h.GetIndicesUint64(uint64(key), filter.IndexBuckets)
+	return hash.HashResult{I1: i1, I2: i2, Fp: fp}
+}
+
+func (Bytes16Encoder[K]) hashKey(h hash.FixedHasher, key K) hash.HashResult {
+	i1, i2, fp := h.GetIndicesBytes16(key, filter.IndexBuckets)
+	return hash.HashResult{I1: i1, I2: i2, Fp: fp}
+}
+
+// TypedFilter is a Filter for keys of type K, converting them with a
+// KeyEncoder instead of requiring callers to build byte slices.
+//
+// Keys are stored exactly as their encoding: a key added through a
+// TypedFilter is found by Filter.Lookup of its encoded bytes, and the other
+// way around. Integer and 16-byte keys are hashed directly from their value
+// and strings are hashed in place, so none of the built-in encoders
+// allocate per key.
+//
+// All methods are safe for concurrent use.
+type TypedFilter[K any] struct {
+	f       *Filter
+	encoder KeyEncoder[K]
+	fixed   fixedKey[K]      // Set for encoders of fixed-width keys
+	hasher  hash.FixedHasher // Hashes fixed-width keys with filter.IndexBuckets buckets
+}
+
+// NewTyped returns a TypedFilter storing keys in f with the given encoder.
+// Operations on the TypedFilter and on f affect the same items.
+//
+// Example:
+//
+//	cf, _ := cuckoofilter.NewBatch(1_000_000)
+//	ids := cuckoofilter.NewTyped(cf, cuckoofilter.IntegerEncoder[uint64]{})
+//	ids.Insert(42)
+//	ids.Lookup(42) // true
+func NewTyped[K any](f *Filter, encoder KeyEncoder[K]) *TypedFilter[K] {
+	t := &TypedFilter[K]{f: f, encoder: encoder}
+	if fixed, ok := encoder.(fixedKey[K]); ok {
+		t.fixed = fixed
+		t.hasher = hash.NewHashFunctionWithParams(f.f.HashStrategy(), f.f.FingerprintBits(), f.f.HashParams()).(hash.FixedHasher)
+	}
+	return t
+}
+
+// Filter returns the filter the keys are stored in
+func (t *TypedFilter[K]) Filter() *Filter {
+	return t.f
+}
+
+// Insert adds a key to the filter.
+// Returns true if successful, false if filter is full.
+func (t *TypedFilter[K]) Insert(key K) bool {
+	if t.fixed != nil {
+		return t.f.f.InsertHashed(t.fixed.hashKey(t.hasher, key))
+	}
+	return t.f.Insert(t.encoder.Encode(key))
+}
+
+// Lookup checks if a key might be in the filter
+func (t *TypedFilter[K]) Lookup(key K) bool {
+	if t.fixed != nil {
+		return t.f.f.LookupHashed(t.fixed.hashKey(t.hasher, key))
+	}
+	return t.f.Lookup(t.encoder.Encode(key))
+}
+
+// Delete removes a key from the filter
+func (t *TypedFilter[K]) Delete(key K) bool {
+	if t.fixed != nil {
+		return t.f.f.DeleteHashed(t.fixed.hashKey(t.hasher, key))
+	}
+	return t.f.Delete(t.encoder.Encode(key))
+}
+
+// InsertBatch inserts multiple keys with the batch path of the filter
+func (t *TypedFilter[K]) InsertBatch(keys []K) []bool {
+	return t.batch(keys, (*filter.Filter).InsertBatchHashed, (*Filter).InsertBatch)
+}
+
+// LookupBatch checks multiple keys with the batch path of the filter
+func (t *TypedFilter[K]) LookupBatch(keys []K) []bool {
+	return t.batch(keys, (*filter.Filter).LookupBatchHashed, (*Filter).LookupBatch)
+}
+
+// DeleteBatch removes multiple keys with the batch path of the filter
+func (t *TypedFilter[K]) DeleteBatch(keys []K) []bool {
+	return t.batch(keys, (*filter.Filter).DeleteBatchHashed, (*Filter).DeleteBatch)
+}
+
+// batch hashes fixed-width keys and passes them to hashed, or encodes the
+// keys and passes them to encoded
+func (t *TypedFilter[K]) batch(keys []K, hashed func(*filter.Filter, []hash.HashResult) []bool, encoded func(*Filter, [][]byte) []bool) []bool {
+	if t.fixed != nil {
+		hrs := make([]hash.HashResult, len(keys))
+		for i, key := range keys {
+			hrs[i] = t.fixed.hashKey(t.hasher, key)
+		}
+		return hashed(t.f.f, hrs)
+	}
+
+	items := make([][]byte, len(keys))
+	for i, key := range keys {
+		items[i] = t.encoder.Encode(key)
+	}
+	return encoded(t.f, items)
+}
@@ -0,0 +1,164 @@
+package cuckoofilter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/shaia/simdcuckoofilter/internal/filter"
+	"github.com/shaia/simdcuckoofilter/internal/hash"
+)
+
+// TestTypedFilter tests the built-in encoders against byte-slice operations
+// on the same filter
+func TestTypedFilter(t *testing.T) {
+	for _, opt := range []Option{WithFNVHash(), WithCRC32Hash(), WithXXHash(), WithSipHash([16]byte{4}), WithCustomHash(customHash)} {
+		for _, stripes := range []uint{0, 8} {
+			cf, err := NewBatch(20000, opt, WithFingerprintSize(16), WithLockStripes(stripes))
+			if err != nil {
+				t.Fatalf("NewBatch failed: %v", err)
+			}
+			name := fmt.Sprintf("%s/Stripes%d", cf.HashStrategy(), stripes)
+
+			ints := NewTyped(cf, IntegerEncoder[int32]{})
+			for i := int32(-500); i < 500; i++ {
+				if !ints.Insert(i) {
+					t.Fatalf("%s: Insert(%d) failed", name, i)
+				}
+			}
+			for _, key := range []int32{-500, -1, 0, 499} {
+				if !cf.Lookup(binary.LittleEndian.AppendUint64(nil, uint64(key))) {
+					t.Errorf("%s: integer key %d not found by its encoding", name, key)
+				}
+			}
+			if !NewTyped(cf, IntegerEncoder[uint64]{}).Lookup(^uint64(0)) {
+				t.Errorf("%s: int32(-1) not found as uint64", name)
+			}
+
+			strs := NewTyped(cf, StringEncoder[string]{})
+			keys := make([]string, 1000)
+			for i := range keys {
+				keys[i] = fmt.Sprintf("key-%d", i)
+			}
+			for i, ok := range strs.InsertBatch(keys) {
+				if !ok || !cf.Lookup([]byte(keys[i])) {
+					t.Fatalf("%s: string key %q not stored", name, keys[i])
+				}
+			}
+
+			uuids := NewTyped(cf, Bytes16Encoder[[16]byte]{})
+			ids := make([][16]byte, 1000)
+			for i := range ids {
+				binary.BigEndian.PutUint64(ids[i][8:], uint64(i))
+				ids[i][0] = 0x7f
+				cf.Insert(ids[i][:])
+			}
+			for i, found := range uuids.LookupBatch(ids) {
+				if !found || !uuids.Lookup(ids[i]) {
+					t.Fatalf("%s: UUID %x inserted as bytes not found", name, ids[i])
+				}
+			}
+
+			for i, ok := range uuids.DeleteBatch(ids) {
+				if !ok {
+					t.Fatalf("%s: DeleteBatch failed for UUID %d", name, i)
+				}
+			}
+			for _, ok := range ints.DeleteBatch([]int32{-500, 0, 499}) {
+				if !ok {
+					t.Fatalf("%s: DeleteBatch of integer keys failed", name)
+				}
+			}
+			if !strs.Delete("key-0") {
+				t.Errorf("%s: Delete of a string key failed", name)
+			}
+			if cf.Count() != 1996 {
+				t.Errorf("%s: Count() = %d, want 1996", name, cf.Count())
+			}
+		}
+	}
+}
+
+// upper encodes strings case-insensitively
+type upper struct{}
+
+func (upper) Encode(key string) []byte {
+	b := []byte(key)
+	for i, c := range b {
+		if 'a' <= c && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return b
+}
+
+// TestTypedFilterLargeTables tests that fixed-width keys keep the index bits
+// of tables larger than filter.RouteBuckets, which are too large to create
+// here, so they land in the buckets of their encoded bytes
+func TestTypedFilterLargeTables(t *testing.T) {
+	for _, opt := range []Option{WithFNVHash(), WithCRC32Hash(), WithXXHash(), WithSipHash([16]byte{4}), WithCustomHash(customHash)} {
+		cf, _ := NewBatch(1000, opt)
+		h := hash.NewHashFunctionWithParams(cf.f.HashStrategy(), cf.f.FingerprintBits(), cf.f.HashParams())
+		ints := NewTyped(cf, IntegerEncoder[uint64]{})
+		uuids := NewTyped(cf, Bytes16Encoder[[16]byte]{})
+
+		for _, nb := range []uint{filter.RouteBuckets << 1, 1 << 40} {
+			for i := uint64(0); i < 1000; i++ {
+				key := i * 0x9e3779b97f4a7c15
+				var id [16]byte
+				binary.LittleEndian.PutUint64(id[:], key)
+				id[15] = byte(i)
+
+				for _, c := range []struct {
+					hr      hash.HashResult
+					encoded []byte
+				}{
+					{ints.fixed.hashKey(ints.hasher, key), ints.encoder.Encode(key)},
+					{uuids.fixed.hashKey(uuids.hasher, id), uuids.encoder.Encode(id)},
+				} {
+					i1, _, fp := h.GetIndices(c.encoded, nb)
+					if c.hr.I1&(nb-1) != i1 || c.hr.Fp != fp {
+						t.Fatalf("%s, %d buckets, key %x: bucket %d, fingerprint %d; want %d, %d",
+							cf.HashStrategy(), nb, c.encoded, c.hr.I1&(nb-1), c.hr.Fp, i1, fp)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestTypedFilterCustomEncoder tests a user-supplied KeyEncoder
+func TestTypedFilterCustomEncoder(t *testing.T) {
+	cf, _ := NewBatch(1000)
+	tf := NewTyped[string](cf, upper{})
+
+	tf.Insert("Hello")
+	if !tf.Lookup("HELLO") || !cf.Lookup([]byte("HELLO")) || cf.Lookup([]byte("Hello")) {
+		t.Error("Custom encoder not applied")
+	}
+	if tf.Filter() != cf {
+		t.Error("Filter() should return the wrapped filter")
+	}
+}
+
+// TestTypedFilterAllocs tests that built-in encoders don't allocate per key
+func TestTypedFilterAllocs(t *testing.T) {
+	for _, stripes := range []uint{0, 4} {
+		cf, _ := NewBatch(1000, WithCRC32Hash(), WithLockStripes(stripes))
+		ints := NewTyped(cf, IntegerEncoder[uint64]{})
+		strs := NewTyped(cf, StringEncoder[string]{})
+		uuids := NewTyped(cf, Bytes16Encoder[[16]byte]{})
+		ints.Insert(1)
+		strs.Insert("key")
+		uuids.Insert([16]byte{1})
+
+		allocs := testing.AllocsPerRun(100, func() {
+			ints.Lookup(1)
+			strs.Lookup("key")
+			uuids.Lookup([16]byte{1})
+		})
+		if allocs != 0 {
+			t.Errorf("Stripes %d: typed lookups allocate %v times", stripes, allocs)
+		}
+	}
+}